@@ -0,0 +1,157 @@
+package seekable
+
+import (
+	"container/list"
+	"sync"
+)
+
+// FrameCache is an LRU cache of decoded frames, bounded by the total number of
+// decompressed bytes it holds.
+//
+// A FrameCache is safe for concurrent use and may be shared by several Readers
+// through WithFrameCache. Entries are keyed by archive identity and frame
+// index, so Readers over the same archive share decoded frames while Readers
+// over different archives never see each other's data.
+type FrameCache struct {
+	mu       sync.Mutex
+	maxBytes uint64
+	bytes    uint64
+	lru      *list.List
+	entries  map[frameKey]*list.Element
+
+	// archiveBytes tracks the cached bytes of each archive.
+	archiveBytes map[string]uint64
+}
+
+// frameKey identifies one frame of one archive.
+type frameKey struct {
+	archive string
+	index   int
+}
+
+type cacheEntry struct {
+	key  frameKey
+	data []byte
+}
+
+// NewFrameCache returns a cache holding up to maxBytes of decoded frames.
+// Frames larger than maxBytes are never cached.
+func NewFrameCache(maxBytes uint64) *FrameCache {
+	return &FrameCache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[frameKey]*list.Element),
+
+		archiveBytes: make(map[string]uint64),
+	}
+}
+
+// Cache returns the FrameCache the Reader was opened with, or nil if it has
+// none.
+func (r *Reader) Cache() *FrameCache {
+	return r.cfg.cache
+}
+
+// Len returns the number of cached frames.
+func (c *FrameCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Bytes returns the total decompressed size of the cached frames.
+func (c *FrameCache) Bytes() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bytes
+}
+
+// Trim evicts least recently used frames until the cache holds at most
+// targetBytes, and returns the number of bytes evicted. It lets a memory
+// watchdog shrink the cache under pressure; the cache's budget is unchanged,
+// so it grows back as frames are decoded again.
+func (c *FrameCache) Trim(targetBytes uint64) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var evicted uint64
+	for c.bytes > targetBytes {
+		evicted += c.removeElement(c.lru.Back())
+	}
+	return evicted
+}
+
+// TrimOnSignal trims the cache to targetBytes each time a value is received
+// from signal, until signal is closed. It returns immediately, handling the
+// signal on its own goroutine, and suits pressure notifications such as a
+// channel fed by a cgroup memory event watcher.
+func (c *FrameCache) TrimOnSignal(signal <-chan struct{}, targetBytes uint64) {
+	go func() {
+		for range signal {
+			c.Trim(targetBytes)
+		}
+	}()
+}
+
+// archiveSize returns the decompressed size of the cached frames of archive.
+func (c *FrameCache) archiveSize(archive string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.archiveBytes[archive]
+}
+
+// get returns the cached frame for key. The returned slice must not be
+// modified.
+func (c *FrameCache) get(key frameKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).data, true
+}
+
+// put stores a decoded frame, evicting least recently used frames until the
+// cache fits its budget. It returns the number of frames and bytes evicted.
+func (c *FrameCache) put(key frameKey, data []byte) (evicted int, evictedBytes uint64) {
+	size := uint64(len(data))
+	if size > c.maxBytes {
+		return 0, 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return 0, 0
+	}
+
+	for c.bytes+size > c.maxBytes {
+		evictedBytes += c.removeElement(c.lru.Back())
+		evicted++
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, data: data})
+	c.bytes += size
+	c.archiveBytes[key.archive] += size
+	return evicted, evictedBytes
+}
+
+// removeElement drops an entry and returns its size.
+func (c *FrameCache) removeElement(elem *list.Element) uint64 {
+	entry := elem.Value.(*cacheEntry)
+	size := uint64(len(entry.data))
+	c.lru.Remove(elem)
+	delete(c.entries, entry.key)
+	c.bytes -= size
+	if left := c.archiveBytes[entry.key.archive] - size; left > 0 {
+		c.archiveBytes[entry.key.archive] = left
+	} else {
+		delete(c.archiveBytes, entry.key.archive)
+	}
+	return size
+}
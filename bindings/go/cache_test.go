@@ -0,0 +1,223 @@
+package seekable
+
+import (
+	"testing"
+)
+
+func TestFrameCacheShared(t *testing.T) {
+	cache := NewFrameCache(1 << 20)
+	path := writeArchive(t, buildArchive(testChunks...))
+
+	a, err := Open(path, WithFrameCache(cache))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer a.Close()
+
+	b, err := Open(path, WithFrameCache(cache))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer b.Close()
+
+	for _, r := range []*Reader{a, b} {
+		got, err := r.ReadRange(4, 15)
+		if err != nil {
+			t.Fatalf("ReadRange(4, 15) failed: %v", err)
+		}
+		if string(got) != testContent[4:15] {
+			t.Errorf("Expected '%s', got '%s'", testContent[4:15], string(got))
+		}
+	}
+
+	// Frames 0..2 overlap [4, 15); the second reader must reuse them.
+	if cache.Len() != 3 {
+		t.Errorf("Expected 3 cached frames, got %d", cache.Len())
+	}
+	if cache.Bytes() != 20 {
+		t.Errorf("Expected 20 cached bytes, got %d", cache.Bytes())
+	}
+}
+
+func TestFrameCacheSeparatesArchives(t *testing.T) {
+	cache := NewFrameCache(1 << 20)
+
+	first := openArchive(t, []string{"first"}, WithFrameCache(cache))
+	second := openArchive(t, []string{"other"}, WithFrameCache(cache))
+
+	for _, tt := range []struct {
+		r    *Reader
+		want string
+	}{{first, "first"}, {second, "other"}, {first, "first"}} {
+		got, err := tt.r.ReadRange(0, 5)
+		if err != nil {
+			t.Fatalf("ReadRange failed: %v", err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Expected '%s', got '%s'", tt.want, string(got))
+		}
+	}
+
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 cached frames, got %d", cache.Len())
+	}
+}
+
+func TestFrameCacheKey(t *testing.T) {
+	cache := NewFrameCache(1 << 20)
+	data := buildArchive(testChunks...)
+
+	// Two copies of the same content share entries under one key.
+	a := openArchivePath(t, writeArchive(t, data), WithFrameCache(cache), WithCacheKey("shared"))
+	b := openArchivePath(t, writeArchive(t, data), WithFrameCache(cache), WithCacheKey("shared"))
+
+	if _, err := a.ReadRange(0, 6); err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if _, err := b.ReadRange(0, 6); err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Expected 1 cached frame, got %d", cache.Len())
+	}
+}
+
+func TestFrameCacheEviction(t *testing.T) {
+	// Room for two 6-byte frames only.
+	cache := NewFrameCache(12)
+	r := openArchive(t, testChunks, WithFrameCache(cache))
+
+	data, err := r.DecompressAll()
+	if err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+	if string(data) != testContent {
+		t.Errorf("Expected '%s', got '%s'", testContent, string(data))
+	}
+
+	// "charlie-" (8 bytes) evicts both 6-byte frames, then "delta" (5 bytes)
+	// evicts "charlie-", leaving only the last frame.
+	if cache.Bytes() > 12 {
+		t.Errorf("Cache exceeds budget: %d bytes", cache.Bytes())
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Expected 1 cached frame, got %d", cache.Len())
+	}
+	if got := r.Stats().CacheBytes; got != 5 {
+		t.Errorf("Expected 5 cached bytes for the archive, got %d", got)
+	}
+}
+
+func TestFrameCacheTrim(t *testing.T) {
+	cache := NewFrameCache(1 << 20)
+	r := openArchive(t, testChunks, WithFrameCache(cache))
+	if r.Cache() != cache {
+		t.Fatal("Cache() does not return the configured cache")
+	}
+	if openArchive(t, testChunks).Cache() != nil {
+		t.Error("Cache() without a cache is not nil")
+	}
+
+	if _, err := r.DecompressAll(); err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+	// Touch "alpha-" so "bravo-" is the least recently used frame.
+	if _, err := r.ReadRange(0, 1); err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+
+	if evicted := cache.Trim(14); evicted != 14 {
+		t.Errorf("Trim(14) evicted %d bytes, want 14", evicted)
+	}
+	if cache.Bytes() != 11 || cache.Len() != 2 {
+		t.Errorf("after Trim: %d frames, %d bytes", cache.Len(), cache.Bytes())
+	}
+	if _, ok := cache.get(frameKey{archive: r.id, index: 0}); !ok {
+		t.Error("recently used frame was trimmed")
+	}
+
+	if evicted := cache.Trim(100); evicted != 0 {
+		t.Errorf("Trim above the cache size evicted %d bytes", evicted)
+	}
+	cache.Trim(0)
+	if cache.Len() != 0 || r.Stats().CacheBytes != 0 {
+		t.Errorf("Trim(0) left %d frames", cache.Len())
+	}
+}
+
+func TestFrameCacheTrimOnSignal(t *testing.T) {
+	cache := NewFrameCache(1 << 20)
+	r := openArchive(t, testChunks, WithFrameCache(cache))
+	if _, err := r.DecompressAll(); err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+
+	signal := make(chan struct{})
+	cache.TrimOnSignal(signal, 0)
+	signal <- struct{}{}
+	// The unbuffered send returns once the trim has started; a second send
+	// completes only after it has finished.
+	signal <- struct{}{}
+	close(signal)
+	if cache.Len() != 0 {
+		t.Errorf("cache holds %d frames after a pressure signal", cache.Len())
+	}
+}
+
+func TestWithPinnedFrames(t *testing.T) {
+	// A tiny cache that cannot hold anything, so only pinning keeps frames.
+	cache := NewFrameCache(1)
+	r := openArchive(t, testChunks, WithFrameCache(cache), WithPinnedFrames(2))
+
+	if len(r.pinned) != 2 {
+		t.Fatalf("Expected 2 pinned frames, got %d", len(r.pinned))
+	}
+
+	if _, err := r.DecompressAll(); err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+
+	got, err := r.ReadRange(0, 12)
+	if err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if string(got) != "alpha-bravo-" {
+		t.Errorf("Expected 'alpha-bravo-', got '%s'", string(got))
+	}
+	if string(r.pinned[0]) != "alpha-" || string(r.pinned[1]) != "bravo-" {
+		t.Errorf("Unexpected pinned frames: %q", r.pinned)
+	}
+}
+
+func TestWithPinnedFramesEmptyFrame(t *testing.T) {
+	chunks := append([]string{""}, testChunks...)
+	for name, opts := range map[string][]Option{
+		"core": nil,
+		"go":   {WithSizeChecks()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := openArchive(t, chunks, append(opts, WithPinnedFrames(2))...)
+			if len(r.pinned) != 2 || len(r.pinned[0]) != 0 || string(r.pinned[1]) != "alpha-" {
+				t.Fatalf("Unexpected pinned frames: %q", r.pinned)
+			}
+			if got, err := r.DecompressAll(); err != nil || string(got) != testContent {
+				t.Errorf("DecompressAll = (%q, %v)", got, err)
+			}
+		})
+	}
+}
+
+func TestWithPinnedFramesClamped(t *testing.T) {
+	r := openArchive(t, testChunks, WithPinnedFrames(100))
+	if len(r.pinned) != len(testChunks) {
+		t.Errorf("Expected %d pinned frames, got %d", len(testChunks), len(r.pinned))
+	}
+
+	got, err := r.ReadRange(0, uint64(len(testContent)))
+	if err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if string(got) != testContent {
+		t.Errorf("Expected '%s', got '%s'", testContent, string(got))
+	}
+}
@@ -0,0 +1,8 @@
+//go:build system_zstd
+
+package seekable
+
+/*
+#cgo pkg-config: seekable_zstd_core
+*/
+import "C"
@@ -0,0 +1,64 @@
+//go:build go1.23
+
+package seekable
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// Chunks returns an iterator over the decompressed content, one chunk per
+// non-empty frame, in order:
+//
+//	for chunk, err := range r.Chunks() {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Frames are decoded as the iteration reaches them, so breaking out of the
+// loop stops decoding. A failure is yielded once, with a nil chunk, and ends
+// the iteration. The chunk buffer is reused: a chunk is only valid until the
+// next iteration, so copy it to retain it.
+func (r *Reader) Chunks() iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		err := r.forEachChunk(0, r.Size(), func(chunk []byte) error {
+			if !yield(chunk, nil) {
+				return ErrStopIteration
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, ErrStopIteration) {
+			yield(nil, err)
+		}
+	}
+}
+
+// ChunksOf is like Chunks, but yields chunks of size bytes regardless of the
+// frame layout; only the last chunk may be shorter. A frame that spans two
+// chunks is decoded once for each. A size below 1 yields an error.
+func (r *Reader) ChunksOf(size int) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		if size < 1 {
+			yield(nil, fmt.Errorf("seekable: invalid chunk size %d", size))
+			return
+		}
+
+		total := r.Size()
+		buf := make([]byte, min(uint64(size), total))
+		for off := uint64(0); off < total; {
+			chunk := buf[:min(uint64(size), total-off)]
+			if err := r.readChunk(chunk, off); err != nil {
+				yield(nil, err)
+				return
+			}
+			off += uint64(len(chunk))
+			r.reportProgress(off, total)
+			if !yield(chunk, nil) {
+				return
+			}
+		}
+	}
+}
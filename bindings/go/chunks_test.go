@@ -0,0 +1,86 @@
+//go:build go1.23
+
+package seekable
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestChunks(t *testing.T) {
+	r := openArchive(t, []string{"alpha-", "", "bravo-", "charlie-", "delta"})
+
+	var got []string
+	for chunk, err := range r.Chunks() {
+		if err != nil {
+			t.Fatalf("Chunks yielded error: %v", err)
+		}
+		got = append(got, string(chunk))
+	}
+	if want := []string{"alpha-", "bravo-", "charlie-", "delta"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Chunks yielded %q, want %q", got, want)
+	}
+}
+
+func TestChunksBreak(t *testing.T) {
+	c := &recordingCollector{}
+	r := openArchive(t, testChunks, WithCollector(c))
+
+	for chunk, err := range r.Chunks() {
+		if err != nil || string(chunk) != "alpha-" {
+			t.Fatalf("first chunk = (%q, %v)", chunk, err)
+		}
+		break
+	}
+	if n := len(c.decoded); n != 1 {
+		t.Errorf("decoded %d frames after breaking on the first, want 1", n)
+	}
+}
+
+func TestChunksError(t *testing.T) {
+	r := openArchive(t, testChunks)
+	r.Close()
+
+	n := 0
+	for chunk, err := range r.Chunks() {
+		n++
+		if err == nil || chunk != nil {
+			t.Errorf("Chunks on a closed Reader yielded (%q, %v)", chunk, err)
+		}
+	}
+	if n != 1 {
+		t.Errorf("Chunks on a closed Reader yielded %d times, want 1", n)
+	}
+}
+
+func TestChunksOf(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	for _, size := range []int{1, 4, 7, 25, 100} {
+		var got bytes.Buffer
+		count := 0
+		for chunk, err := range r.ChunksOf(size) {
+			if err != nil {
+				t.Fatalf("ChunksOf(%d) yielded error: %v", size, err)
+			}
+			if len(chunk) > size {
+				t.Errorf("ChunksOf(%d) yielded %d bytes", size, len(chunk))
+			}
+			got.Write(chunk)
+			count++
+		}
+		if got.String() != testContent {
+			t.Errorf("ChunksOf(%d) = %q", size, got.String())
+		}
+		if want := (len(testContent) + size - 1) / size; count != want {
+			t.Errorf("ChunksOf(%d) yielded %d chunks, want %d", size, count, want)
+		}
+	}
+
+	for _, err := range r.ChunksOf(0) {
+		if err == nil {
+			t.Error("Expected ChunksOf(0) to yield an error")
+		}
+	}
+}
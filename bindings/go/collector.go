@@ -0,0 +1,154 @@
+package seekable
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// Collector receives live instrumentation events from a Reader, for feeding
+// into a metrics system. Methods are called synchronously on the goroutine
+// doing the read, possibly from several goroutines at once, so they must be
+// safe for concurrent use and should return quickly.
+type Collector interface {
+	// OnFrameDecoded is called after frame index is decoded into n bytes,
+	// taking dur.
+	OnFrameDecoded(index uint64, n int, dur time.Duration)
+	// OnCacheHit is called when frame index is served from the FrameCache.
+	OnCacheHit(index uint64)
+	// OnCacheMiss is called when frame index is not in the FrameCache and
+	// must be decoded.
+	OnCacheMiss(index uint64)
+}
+
+// nopCollector is the default Collector; it discards every event.
+type nopCollector struct{}
+
+func (nopCollector) OnFrameDecoded(uint64, int, time.Duration) {}
+func (nopCollector) OnCacheHit(uint64)                         {}
+func (nopCollector) OnCacheMiss(uint64)                        {}
+
+// latencyBuckets is the number of LatencyHistogram buckets: one per power of
+// two of nanoseconds, which covers every non-negative time.Duration.
+const latencyBuckets = 63
+
+// LatencyHistogram is a Collector that records the distribution of frame
+// decode durations, for spotting frames that decode much more slowly than the
+// rest. Pass it to WithCollector; one histogram may be shared by several
+// Readers. Recording a decode costs a few counter updates, and cache events
+// are ignored.
+//
+// Durations are counted in buckets bounded by powers of two of nanoseconds,
+// so a duration is known to within a factor of two, which is enough to tell
+// pathological frames from ordinary ones at any scale. The slowest decode is
+// kept exactly.
+type LatencyHistogram struct {
+	mu      sync.Mutex
+	counts  [latencyBuckets]uint64
+	n       uint64
+	total   time.Duration
+	slowest time.Duration
+	frame   uint64
+}
+
+// OnFrameDecoded records that frame index took dur to decode.
+func (h *LatencyHistogram) OnFrameDecoded(index uint64, n int, dur time.Duration) {
+	dur = max(dur, 0)
+	b := max(bits.Len64(uint64(dur))-1, 0)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[b]++
+	h.n++
+	h.total += dur
+	if dur > h.slowest || h.n == 1 {
+		h.slowest = dur
+		h.frame = index
+	}
+}
+
+func (h *LatencyHistogram) OnCacheHit(uint64)  {}
+func (h *LatencyHistogram) OnCacheMiss(uint64) {}
+
+// Reset discards every recorded decode.
+func (h *LatencyHistogram) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts = [latencyBuckets]uint64{}
+	h.n, h.total, h.slowest, h.frame = 0, 0, 0, 0
+}
+
+// LatencyBucket counts the decodes whose duration d satisfies
+// Min <= d < Max.
+type LatencyBucket struct {
+	Min, Max time.Duration
+	Count    uint64
+}
+
+// LatencySnapshot is the state of a LatencyHistogram at one point in time.
+type LatencySnapshot struct {
+	// Buckets lists the non-empty buckets in ascending order.
+	Buckets []LatencyBucket
+	// Count is the number of decodes and Total their combined duration.
+	Count uint64
+	Total time.Duration
+	// Slowest is the longest decode and SlowestFrame the index of its frame,
+	// relative to the Reader that decoded it.
+	Slowest      time.Duration
+	SlowestFrame uint64
+}
+
+// Snapshot returns the decodes recorded so far.
+func (h *LatencyHistogram) Snapshot() LatencySnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := LatencySnapshot{
+		Count:        h.n,
+		Total:        h.total,
+		Slowest:      h.slowest,
+		SlowestFrame: h.frame,
+	}
+	for b, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		var lo time.Duration
+		if b > 0 {
+			lo = 1 << b
+		}
+		hi := time.Duration(math.MaxInt64)
+		if b < latencyBuckets-1 {
+			hi = 2 << b
+		}
+		s.Buckets = append(s.Buckets, LatencyBucket{Min: lo, Max: hi, Count: c})
+	}
+	return s
+}
+
+// Mean returns the average decode duration, or 0 if none was recorded.
+func (s LatencySnapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// Quantile returns an upper bound on the q-quantile of the decode durations,
+// for q in [0, 1]: the Max of the bucket holding it, capped at Slowest. It
+// returns 0 if no decode was recorded.
+func (s LatencySnapshot) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(s.Count))
+	var seen uint64
+	for _, b := range s.Buckets {
+		seen += b.Count
+		if seen > rank {
+			return min(b.Max, s.Slowest)
+		}
+	}
+	return s.Slowest
+}
@@ -0,0 +1,118 @@
+package seekable
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingCollector counts the events it receives.
+type recordingCollector struct {
+	mu      sync.Mutex
+	decoded []uint64
+	bytes   int
+	hits    int
+	misses  int
+}
+
+func (c *recordingCollector) OnFrameDecoded(index uint64, n int, dur time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.decoded = append(c.decoded, index)
+	c.bytes += n
+}
+
+func (c *recordingCollector) OnCacheHit(uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hits++
+}
+
+func (c *recordingCollector) OnCacheMiss(uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.misses++
+}
+
+func TestWithCollector(t *testing.T) {
+	c := &recordingCollector{}
+	r := openArchive(t, testChunks, WithCollector(c))
+
+	// A range on the core decoder is still reported frame by frame.
+	if _, err := r.ReadRange(4, 15); err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if len(c.decoded) != 3 || c.decoded[0] != 0 || c.decoded[2] != 2 {
+		t.Errorf("Expected frames 0..2 decoded, got %v", c.decoded)
+	}
+	if c.bytes != 20 {
+		t.Errorf("Expected 20 decoded bytes, got %d", c.bytes)
+	}
+	if c.hits != 0 || c.misses != 0 {
+		t.Errorf("Expected no cache events without a cache, got %d hits, %d misses", c.hits, c.misses)
+	}
+}
+
+func TestWithCollectorCache(t *testing.T) {
+	c := &recordingCollector{}
+	r := openArchive(t, testChunks, WithCollector(c), WithFrameCache(NewFrameCache(1<<20)))
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.DecompressAll(); err != nil {
+			t.Fatalf("DecompressAll failed: %v", err)
+		}
+	}
+	if c.misses != 4 || c.hits != 4 {
+		t.Errorf("Expected 4 misses and 4 hits, got %d and %d", c.misses, c.hits)
+	}
+	if len(c.decoded) != 4 {
+		t.Errorf("Expected 4 decodes, got %d", len(c.decoded))
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	var h LatencyHistogram
+	for i, d := range []time.Duration{0, 1, 3, 900, 1000, 1023, 5 * time.Millisecond} {
+		h.OnFrameDecoded(uint64(i), 100, d)
+	}
+
+	s := h.Snapshot()
+	if s.Count != 7 || s.Slowest != 5*time.Millisecond || s.SlowestFrame != 6 {
+		t.Fatalf("Snapshot = %+v", s)
+	}
+	want := []LatencyBucket{
+		{0, 2, 2},
+		{2, 4, 1},
+		{512, 1024, 3},
+		{4194304, 8388608, 1},
+	}
+	if !slices.Equal(s.Buckets, want) {
+		t.Errorf("Buckets = %v, want %v", s.Buckets, want)
+	}
+	if got := s.Quantile(0.5); got != 1024 {
+		t.Errorf("Quantile(0.5) = %v, want 1.024µs", got)
+	}
+	if got := s.Quantile(1); got != 5*time.Millisecond {
+		t.Errorf("Quantile(1) = %v, want 5ms", got)
+	}
+	if got := s.Mean(); got != (5*time.Millisecond+2927)/7 {
+		t.Errorf("Mean() = %v", got)
+	}
+
+	h.Reset()
+	if s := h.Snapshot(); s.Count != 0 || len(s.Buckets) != 0 || s.Slowest != 0 || s.Quantile(0.9) != 0 {
+		t.Errorf("Snapshot after Reset = %+v", s)
+	}
+}
+
+func TestLatencyHistogramCollector(t *testing.T) {
+	h := &LatencyHistogram{}
+	r := openArchive(t, testChunks, WithCollector(h))
+	if _, err := r.DecompressAll(); err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+	if s := h.Snapshot(); s.Count != 4 || s.SlowestFrame > 3 {
+		t.Errorf("Snapshot = %+v", s)
+	}
+}
@@ -0,0 +1,506 @@
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// WriteTo implements io.WriterTo by writing the decompressed content from the
+// cursor to the end to w, one frame at a time. On a fresh Reader the cursor is
+// at 0, so the whole content is written.
+//
+// The cursor advances past every byte w accepts, including on failure. After
+// a write error, another WriteTo therefore resumes with the first byte w did
+// not accept, which makes WriteTo resumable over unreliable connections.
+func (r *Reader) WriteTo(w io.Writer) (int64, error) {
+	size := r.Size()
+	start := min(uint64(r.pos), size)
+	n, err := r.CopyRange(w, start, size)
+	r.pos = int64(start) + n
+	return n, err
+}
+
+// CopyRange writes the decompressed bytes in the range [start, end) to w.
+//
+// The range is decoded one frame at a time, so memory use is bounded by the
+// largest frame rather than by the size of the range.
+func (r *Reader) CopyRange(w io.Writer, start, end uint64) (int64, error) {
+	if start > end {
+		return 0, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
+	}
+
+	if end > r.Size() {
+		return 0, fmt.Errorf("range end (%d) exceeds size (%d)", end, r.Size())
+	}
+
+	var written int64
+	err := r.forEachChunk(start, end, func(chunk []byte) error {
+		n, err := writeChunk(w, chunk)
+		written += int64(n)
+		return err
+	})
+	return written, err
+}
+
+// CopyRangeTee writes the decompressed bytes in the range [start, end) to
+// primary and to every tap, decoding each frame once. Each chunk is written to
+// primary first, then to the taps in order. It stops at the first write error
+// from any destination and returns the number of bytes written to primary.
+func (r *Reader) CopyRangeTee(primary io.Writer, start, end uint64, taps ...io.Writer) (int64, error) {
+	if start > end {
+		return 0, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
+	}
+
+	if end > r.Size() {
+		return 0, fmt.Errorf("range end (%d) exceeds size (%d)", end, r.Size())
+	}
+
+	var written int64
+	err := r.forEachChunk(start, end, func(chunk []byte) error {
+		n, err := writeChunk(primary, chunk)
+		written += int64(n)
+		if err != nil {
+			return err
+		}
+		for _, tap := range taps {
+			if _, err := writeChunk(tap, chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return written, err
+}
+
+// ReadRangeToWriterAt decodes the range [start, end) and writes it to wa at
+// the matching offsets, so byte i of the decompressed content lands at offset
+// i of wa. It decodes one frame at a time and returns the number of bytes
+// written.
+func (r *Reader) ReadRangeToWriterAt(wa io.WriterAt, start, end uint64) (int, error) {
+	if start > end {
+		return 0, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
+	}
+
+	if end > r.Size() {
+		return 0, fmt.Errorf("range end (%d) exceeds size (%d)", end, r.Size())
+	}
+
+	written := 0
+	off := int64(start)
+	err := r.forEachChunk(start, end, func(chunk []byte) error {
+		n, err := wa.WriteAt(chunk, off)
+		written += n
+		off += int64(n)
+		if err == nil && n != len(chunk) {
+			err = io.ErrShortWrite
+		}
+		return err
+	})
+	return written, err
+}
+
+// fileWriteSize is the amount of decoded content CopyRangeToFile gathers for
+// each write.
+const fileWriteSize = 1 << 20
+
+// CopyRangeToFile writes the decompressed bytes in the range [start, end) to f
+// at its current offset and returns the number of bytes written.
+//
+// Decoded content has to pass through user space, so splice and sendfile do
+// not apply. Instead, as many whole frames as fit in 1 MiB, or a single frame
+// if that is larger, are decoded into one buffer, and each buffer is written
+// with one write call. This keeps the syscall count low for archives
+// with small frames, where CopyRange writes once per frame and io.Copy once
+// per 32 KiB. A WithLimits MaxSingleReadBytes below 1 MiB lowers the buffer
+// size to it.
+func (r *Reader) CopyRangeToFile(f *os.File, start, end uint64) (int64, error) {
+	v := r.view.Load()
+	if start > end {
+		return 0, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
+	}
+
+	if end > v.size {
+		return 0, fmt.Errorf("range end (%d) exceeds size (%d)", end, v.size)
+	}
+
+	batch := uint64(fileWriteSize)
+	if limit := r.cfg.maxReadBytes; limit > 0 {
+		batch = min(batch, limit)
+	}
+
+	total := end - start
+	var written int64
+	var buf []byte
+	for start < end {
+		chunkEnd := start
+		for i := v.table.frameIndex(start); i < len(v.table.frames) && chunkEnd < end; i++ {
+			fr := v.table.frames[i]
+			frameEnd := fr.decompOffset + fr.decompSize
+			if chunkEnd > start && frameEnd-start > batch {
+				break
+			}
+			chunkEnd = min(frameEnd, end)
+		}
+
+		n := chunkEnd - start
+		if uint64(cap(buf)) < n {
+			buf = make([]byte, n)
+		}
+		chunk := buf[:n]
+
+		if err := r.readChunk(chunk, start); err != nil {
+			return written, err
+		}
+		m, err := writeChunk(f, chunk)
+		written += int64(m)
+		if err != nil {
+			return written, err
+		}
+
+		start = chunkEnd
+		r.reportProgress(uint64(written), total)
+	}
+	return written, nil
+}
+
+// writeChunk writes chunk to w, treating a short write as an error.
+func writeChunk(w io.Writer, chunk []byte) (int, error) {
+	n, err := w.Write(chunk)
+	if err == nil && n != len(chunk) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
+// DecompressAll returns the entire decompressed content in a single buffer.
+// An empty archive yields an empty, non-nil slice.
+func (r *Reader) DecompressAll() ([]byte, error) {
+	size := r.Size()
+	if size == 0 {
+		return []byte{}, nil
+	}
+	if err := r.checkReadSize(size); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+
+	var done uint64
+	for _, f := range r.view.Load().table.frames {
+		if f.decompSize == 0 {
+			continue
+		}
+		if err := r.readChunk(buf[f.decompOffset:f.decompOffset+f.decompSize], f.decompOffset); err != nil {
+			return nil, err
+		}
+		done += f.decompSize
+		r.reportProgress(done, size)
+	}
+
+	return buf, nil
+}
+
+// DecompressBestEffort is like DecompressAll, but a frame that cannot be read
+// or decoded does not abort the operation. Its bytes are left as zeros, sized
+// from the seek table so that every later frame stays at its offset, and its
+// index is added to the returned list of failed frames, in ascending order.
+//
+// The error is reserved for failures that affect the whole archive, such as
+// ErrClosed and the WithLimits limits, which are checked before the buffer
+// is allocated and end the operation when a frame reaches them; frame
+// failures, including corrupt data and errors reading the compressed source,
+// only add to the list.
+func (r *Reader) DecompressBestEffort() ([]byte, []uint64, error) {
+	size := r.Size()
+	if err := r.checkReadSize(size); err != nil {
+		return nil, nil, err
+	}
+	buf := make([]byte, size)
+
+	var failed []uint64
+	var done uint64
+	for i, f := range r.view.Load().table.frames {
+		if f.decompSize == 0 {
+			continue
+		}
+		chunk := buf[f.decompOffset : f.decompOffset+f.decompSize]
+		if err := r.readChunk(chunk, f.decompOffset); err != nil {
+			if errors.Is(err, ErrClosed) || isLimitError(err) {
+				return nil, nil, err
+			}
+			clear(chunk)
+			failed = append(failed, uint64(i))
+		}
+		done += f.decompSize
+		r.reportProgress(done, size)
+	}
+
+	return buf, failed, nil
+}
+
+// EqualContent reports whether a and b decompress to the same bytes.
+//
+// The archives may use different frame layouts and compression settings.
+// Content is compared one frame of a at a time, so neither archive is loaded
+// into memory in full. Archives of different sizes compare unequal without
+// decoding anything.
+func EqualContent(a, b *Reader) (bool, error) {
+	if a.Size() != b.Size() {
+		return false, nil
+	}
+
+	var bufA, bufB []byte
+	for _, f := range a.view.Load().table.frames {
+		if f.decompSize == 0 {
+			continue
+		}
+		if uint64(cap(bufA)) < f.decompSize {
+			bufA = make([]byte, f.decompSize)
+			bufB = make([]byte, f.decompSize)
+		}
+		chunkA, chunkB := bufA[:f.decompSize], bufB[:f.decompSize]
+
+		if err := a.readChunk(chunkA, f.decompOffset); err != nil {
+			return false, err
+		}
+		if err := b.readChunk(chunkB, f.decompOffset); err != nil {
+			return false, err
+		}
+		if !bytes.Equal(chunkA, chunkB) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// DiffFrames returns, in ascending order, the indices of the frames of next
+// that differ from the frame at the same index in old, or have no
+// counterpart there. It underpins incremental sync: transferring those frames
+// of next, plus its seek table, brings a copy of old up to date.
+//
+// Frames are compared by index, so the comparison is only meaningful for
+// archives that share a frame layout, such as successive versions of data
+// written with the same frame size; inserting data near the start shifts
+// every later frame and marks it changed. Frames with different decompressed
+// sizes differ. Frames of equal size are compared by their stored checksums
+// when both seek tables have them, and decoded and compared byte for byte
+// otherwise. Checksums are 32 bits, so a changed frame whose checksum
+// collides goes unreported; compare content with EqualContent when that
+// matters.
+//
+// When next has more frames than old, the extra frames are all reported as
+// changed. When it has fewer, the frames of old past the end of next are not
+// reported, since they have no index in next; FrameCount tells that case
+// apart. Empty frames never differ from each other.
+func DiffFrames(old, next *Reader) ([]uint64, error) {
+	oldTable, err := old.openTable()
+	if err != nil {
+		return nil, err
+	}
+	newTable, err := next.openTable()
+	if err != nil {
+		return nil, err
+	}
+	oldFrames, newFrames := oldTable.frames, newTable.frames
+	byChecksum := oldTable.hasChecksums && newTable.hasChecksums
+
+	var changed []uint64
+	var bufOld, bufNew []byte
+	for i, f := range newFrames {
+		if i >= len(oldFrames) {
+			changed = append(changed, uint64(i))
+			continue
+		}
+		g := oldFrames[i]
+		if g.decompSize != f.decompSize {
+			changed = append(changed, uint64(i))
+			continue
+		}
+		if f.decompSize == 0 {
+			continue
+		}
+		if byChecksum {
+			if g.checksum != f.checksum {
+				changed = append(changed, uint64(i))
+			}
+			continue
+		}
+
+		if uint64(cap(bufOld)) < f.decompSize {
+			bufOld = make([]byte, f.decompSize)
+			bufNew = make([]byte, f.decompSize)
+		}
+		chunkOld, chunkNew := bufOld[:f.decompSize], bufNew[:f.decompSize]
+		if err := old.readChunk(chunkOld, g.decompOffset); err != nil {
+			return nil, err
+		}
+		if err := next.readChunk(chunkNew, f.decompOffset); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(chunkOld, chunkNew) {
+			changed = append(changed, uint64(i))
+		}
+	}
+	return changed, nil
+}
+
+// ErrStopIteration may be returned by a ForEachFrame callback to stop the
+// iteration early without an error.
+var ErrStopIteration = errors.New("seekable: stop iteration")
+
+// ForEachFrame decodes the frames in order and calls fn with each frame's
+// index, decompressed content and decompressed offset. Frames that decode to
+// nothing are passed as empty slices. The data buffer is reused between
+// calls, so fn must not retain it.
+//
+// Iteration stops at the first error, which ForEachFrame returns; if fn
+// returns ErrStopIteration, ForEachFrame stops and returns nil.
+func (r *Reader) ForEachFrame(fn func(index uint64, data []byte, decompOffset uint64) error) error {
+	size := r.Size()
+	var buf []byte
+	for i, f := range r.view.Load().table.frames {
+		if uint64(cap(buf)) < f.decompSize {
+			buf = make([]byte, f.decompSize)
+		}
+		data := buf[:f.decompSize]
+
+		if f.decompSize > 0 {
+			if err := r.readChunk(data, f.decompOffset); err != nil {
+				return err
+			}
+		}
+		if err := fn(uint64(i), data, f.decompOffset); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+		r.reportProgress(f.decompOffset+f.decompSize, size)
+	}
+	return nil
+}
+
+// forEachChunk decodes the range [start, end) frame by frame and passes each
+// decoded chunk to fn. The chunk buffer is reused between calls, so fn must
+// not retain it.
+func (r *Reader) forEachChunk(start, end uint64, fn func(chunk []byte) error) error {
+	v := r.view.Load()
+	if start >= end {
+		return nil
+	}
+
+	total := end - start
+	var done uint64
+	var buf []byte
+
+	for i := v.table.frameIndex(start); i < len(v.table.frames) && start < end; i++ {
+		f := v.table.frames[i]
+		chunkEnd := min(f.decompOffset+f.decompSize, end)
+		if chunkEnd <= start {
+			continue
+		}
+
+		n := chunkEnd - start
+		if uint64(cap(buf)) < n {
+			buf = make([]byte, n)
+		}
+		chunk := buf[:n]
+
+		if err := r.readChunk(chunk, start); err != nil {
+			return err
+		}
+		if err := fn(chunk); err != nil {
+			return err
+		}
+
+		start = chunkEnd
+		done += n
+		r.reportProgress(done, total)
+	}
+
+	return nil
+}
+
+// ReadAtProgress is like ReadAtContext but reports progress through the
+// WithProgress callback after each frame, for reads large enough to want a
+// progress bar. Progress counts bytes from off up to the end of the read,
+// clamped to Size(). Each frame is read with its own ReadAtContext call, so
+// ctx is checked and Close can proceed between frames; a read cut short
+// returns the bytes read so far. The result follows the ReadAt policy.
+func (r *Reader) ReadAtProgress(ctx context.Context, p []byte, off int64) (int, error) {
+	table, err := r.openTable()
+	if err != nil {
+		return 0, err
+	}
+	size := table.size()
+	if off < 0 || len(p) == 0 || uint64(off) >= size {
+		return r.ReadAtContext(ctx, p, off)
+	}
+	start := uint64(off)
+	end := min(start+uint64(len(p)), size)
+	if err := r.checkReadSize(end - start); err != nil {
+		return 0, err
+	}
+
+	total := end - start
+	var n int
+	for start < end {
+		f := table.frames[table.frameIndex(start)]
+		chunkEnd := min(f.decompOffset+f.decompSize, end)
+		// The last frame reads the rest of p, leaving the end-of-file result
+		// to ReadAtContext.
+		chunk := p[n : n+int(chunkEnd-start)]
+		if chunkEnd == end {
+			chunk = p[n:]
+		}
+
+		m, err := r.ReadAtContext(ctx, chunk, int64(start))
+		n += m
+		if m > 0 {
+			r.reportProgress(uint64(n), total)
+		}
+		if err != nil || chunkEnd == end {
+			return n, err
+		}
+		start = chunkEnd
+	}
+	return n, nil
+}
+
+// openTable returns r's seek table, or ErrClosed if r is closed. Refresh
+// only appends frames, so the frames of the returned table stay valid for
+// callers that read them through ReadAt without holding r.mu.
+func (r *Reader) openTable() (*seekTable, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h == nil {
+		return nil, ErrClosed
+	}
+	return r.view.Load().table, nil
+}
+
+// readChunk fills p with the decompressed bytes starting at off.
+func (r *Reader) readChunk(p []byte, off uint64) error {
+	n, err := r.ReadAt(p, int64(off))
+	if n == len(p) {
+		return nil
+	}
+	if err == nil || err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// reportProgress invokes the progress callback, if one is configured.
+func (r *Reader) reportProgress(done, total uint64) {
+	if r.cfg.progress != nil {
+		r.cfg.progress(done, total)
+	}
+}
+
+// Ensure Reader implements io.WriterTo
+var _ io.WriterTo = (*Reader)(nil)
@@ -0,0 +1,514 @@
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+var testChunks = []string{"alpha-", "bravo-", "charlie-", "delta"}
+
+const testContent = "alpha-bravo-charlie-delta"
+
+func TestWriteTo(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	var buf bytes.Buffer
+	n, err := r.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if n != int64(len(testContent)) {
+		t.Errorf("Expected n=%d, got %d", len(testContent), n)
+	}
+	if buf.String() != testContent {
+		t.Errorf("Expected '%s', got '%s'", testContent, buf.String())
+	}
+}
+
+func TestCopyRange(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	tests := []struct {
+		name       string
+		start, end uint64
+		wantErr    bool
+	}{
+		{"empty", 3, 3, false},
+		{"within frame", 1, 4, false},
+		{"across frames", 4, 15, false},
+		{"frame aligned", 6, 20, false},
+		{"everything", 0, uint64(len(testContent)), false},
+		{"reversed", 5, 4, true},
+		{"past end", 0, 100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := r.CopyRange(&buf, tt.start, tt.end)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CopyRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want := testContent[tt.start:tt.end]
+			if n != int64(len(want)) || buf.String() != want {
+				t.Errorf("CopyRange() = (%d, '%s'), want (%d, '%s')", n, buf.String(), len(want), want)
+			}
+		})
+	}
+}
+
+type failingWriter struct {
+	limit int
+	buf   bytes.Buffer
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, errors.New("writer full")
+	}
+	return w.buf.Write(p)
+}
+
+func TestCopyRangeWriteError(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	w := &failingWriter{limit: 10}
+	n, err := r.CopyRange(w, 0, uint64(len(testContent)))
+	if err == nil {
+		t.Fatal("Expected write error")
+	}
+	if n != 6 {
+		t.Errorf("Expected n=6 (first frame), got %d", n)
+	}
+}
+
+func TestCopyRangeToFile(t *testing.T) {
+	// Small frames, so the copy gathers many of them into each write.
+	content := bytes.Repeat([]byte("copy range to file "), 150000)
+	r := openArchivePath(t, writeArchive(t, writeTestArchive(t, content, WithFrameSize(4096))))
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("prefix:"); err != nil {
+		t.Fatal(err)
+	}
+
+	start, end := uint64(5), uint64(len(content)-3)
+	n, err := r.CopyRangeToFile(f, start, end)
+	if err != nil {
+		t.Fatalf("CopyRangeToFile failed: %v", err)
+	}
+	if n != int64(end-start) {
+		t.Errorf("CopyRangeToFile wrote %d bytes, want %d", n, end-start)
+	}
+
+	got, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append([]byte("prefix:"), content[start:end]...); !bytes.Equal(got, want) {
+		t.Errorf("file holds %d bytes, want %d matching bytes", len(got), len(want))
+	}
+
+	if n, err := r.CopyRangeToFile(f, 7, 7); n != 0 || err != nil {
+		t.Errorf("empty CopyRangeToFile = (%d, %v)", n, err)
+	}
+	if _, err := r.CopyRangeToFile(f, 0, r.Size()+1); err == nil {
+		t.Error("CopyRangeToFile past the end succeeded")
+	}
+}
+
+func BenchmarkCopyRangeToFile(b *testing.B) {
+	content := bytes.Repeat([]byte("benchmark copy "), 300000)
+	r := openArchivePath(b, writeArchive(b, writeTestArchive(b, content, WithFrameSize(64*1024))))
+	f, err := os.Create(filepath.Join(b.TempDir(), "out"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	copies := map[string]func() error{
+		"CopyRangeToFile": func() error {
+			_, err := r.CopyRangeToFile(f, 0, r.Size())
+			return err
+		},
+		"io.Copy": func() error {
+			_, err := io.Copy(f, io.NewSectionReader(r, 0, int64(r.Size())))
+			return err
+		},
+	}
+	for name, copyAll := range copies {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(content)))
+			for i := 0; i < b.N; i++ {
+				if _, err := f.Seek(0, io.SeekStart); err != nil {
+					b.Fatal(err)
+				}
+				if err := copyAll(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestCopyRangeTee(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	var primary bytes.Buffer
+	h := sha256.New()
+	var tap bytes.Buffer
+	n, err := r.CopyRangeTee(&primary, 4, 22, h, &tap)
+	if err != nil {
+		t.Fatalf("CopyRangeTee failed: %v", err)
+	}
+	if n != 18 {
+		t.Errorf("Expected 18 bytes, got %d", n)
+	}
+
+	want := testContent[4:22]
+	if primary.String() != want || tap.String() != want {
+		t.Errorf("Got primary '%s' and tap '%s', want '%s'", primary.String(), tap.String(), want)
+	}
+	if sum := sha256.Sum256([]byte(want)); !bytes.Equal(h.Sum(nil), sum[:]) {
+		t.Error("Tap hash does not match the range")
+	}
+}
+
+func TestCopyRangeTeeTapError(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	var primary bytes.Buffer
+	tap := &failingWriter{limit: 10}
+	n, err := r.CopyRangeTee(&primary, 0, uint64(len(testContent)), tap)
+	if err == nil {
+		t.Fatal("Expected tap write error")
+	}
+	// The second frame reaches the primary before the tap rejects it.
+	if n != 12 || primary.String() != "alpha-bravo-" {
+		t.Errorf("Expected 12 bytes to primary, got %d ('%s')", n, primary.String())
+	}
+}
+
+func TestReadRangeToWriterAt(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "sparse"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer f.Close()
+
+	// Fill the output out of order, across frame boundaries.
+	for _, rng := range [][2]uint64{{15, 25}, {0, 4}, {4, 15}} {
+		n, err := r.ReadRangeToWriterAt(f, rng[0], rng[1])
+		if err != nil {
+			t.Fatalf("ReadRangeToWriterAt(%d, %d) failed: %v", rng[0], rng[1], err)
+		}
+		if n != int(rng[1]-rng[0]) {
+			t.Errorf("ReadRangeToWriterAt(%d, %d) wrote %d bytes", rng[0], rng[1], n)
+		}
+	}
+
+	got, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(got) != testContent {
+		t.Errorf("Expected '%s', got '%s'", testContent, string(got))
+	}
+
+	if _, err := r.ReadRangeToWriterAt(f, 0, 26); err == nil {
+		t.Error("Expected error for end past size")
+	}
+}
+
+func TestDecompressAll(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	data, err := r.DecompressAll()
+	if err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+	if string(data) != testContent {
+		t.Errorf("Expected '%s', got '%s'", testContent, string(data))
+	}
+}
+
+func TestDecompressBestEffort(t *testing.T) {
+	archive := buildArchive(testChunks...)
+	// Mark the first block of frame 1 with the reserved block type.
+	archive[len(rawFrame([]byte(testChunks[0])))+6] |= 3 << 1
+
+	want := "alpha-" + string(make([]byte, len("bravo-"))) + "charlie-delta"
+	for name, open := range map[string]func() (*Reader, error){
+		"file":  func() (*Reader, error) { return Open(writeArchive(t, archive)) },
+		"bytes": func() (*Reader, error) { return OpenBytes(archive) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			r, err := open()
+			if err != nil {
+				t.Fatalf("open failed: %v", err)
+			}
+			defer r.Close()
+
+			if _, err := r.DecompressAll(); err == nil {
+				t.Fatal("DecompressAll of a corrupt archive succeeded")
+			}
+
+			data, failed, err := r.DecompressBestEffort()
+			if err != nil {
+				t.Fatalf("DecompressBestEffort failed: %v", err)
+			}
+			if string(data) != want {
+				t.Errorf("DecompressBestEffort = %q, want %q", data, want)
+			}
+			if !slices.Equal(failed, []uint64{1}) {
+				t.Errorf("failed frames = %v, want [1]", failed)
+			}
+		})
+	}
+
+	r := openArchive(t, testChunks)
+	if data, failed, err := r.DecompressBestEffort(); err != nil || string(data) != testContent || failed != nil {
+		t.Errorf("DecompressBestEffort of an intact archive = (%q, %v, %v)", data, failed, err)
+	}
+	r.Close()
+	if _, _, err := r.DecompressBestEffort(); err == nil {
+		t.Error("DecompressBestEffort on a closed Reader succeeded")
+	}
+}
+
+func TestForEachFrame(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	var got []string
+	var offsets []uint64
+	err := r.ForEachFrame(func(index uint64, data []byte, decompOffset uint64) error {
+		if index != uint64(len(got)) {
+			t.Errorf("frame index %d, want %d", index, len(got))
+		}
+		got = append(got, string(data))
+		offsets = append(offsets, decompOffset)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachFrame failed: %v", err)
+	}
+	if !slices.Equal(got, testChunks) {
+		t.Errorf("frames = %q, want %q", got, testChunks)
+	}
+	if !slices.Equal(offsets, []uint64{0, 6, 12, 20}) {
+		t.Errorf("offsets = %v", offsets)
+	}
+}
+
+func TestForEachFrameStop(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	calls := 0
+	err := r.ForEachFrame(func(index uint64, data []byte, decompOffset uint64) error {
+		calls++
+		if index == 1 {
+			return ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("ForEachFrame = %v after %d calls, want nil after 2", err, calls)
+	}
+
+	errBoom := errors.New("boom")
+	calls = 0
+	err = r.ForEachFrame(func(uint64, []byte, uint64) error {
+		calls++
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) || calls != 1 {
+		t.Errorf("ForEachFrame = %v after %d calls, want errBoom after 1", err, calls)
+	}
+}
+
+type progressEvent struct{ done, total uint64 }
+
+func TestWithProgress(t *testing.T) {
+	var events []progressEvent
+	r := openArchive(t, testChunks, WithProgress(func(done, total uint64) {
+		events = append(events, progressEvent{done, total})
+	}))
+
+	total := uint64(len(testContent))
+	frameEnds := []progressEvent{{6, total}, {12, total}, {20, total}, {25, total}}
+
+	check := func(name string, want []progressEvent) {
+		t.Helper()
+		if len(events) != len(want) {
+			t.Fatalf("%s: expected %d progress events, got %v", name, len(want), events)
+		}
+		for i := range want {
+			if events[i] != want[i] {
+				t.Errorf("%s: event %d = %v, want %v", name, i, events[i], want[i])
+			}
+		}
+		events = nil
+	}
+
+	if _, err := r.WriteTo(&bytes.Buffer{}); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	check("WriteTo", frameEnds)
+
+	if _, err := r.DecompressAll(); err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+	check("DecompressAll", frameEnds)
+
+	if _, err := r.CopyRange(&bytes.Buffer{}, 8, 15); err != nil {
+		t.Fatalf("CopyRange failed: %v", err)
+	}
+	check("CopyRange", []progressEvent{{4, 7}, {7, 7}})
+}
+
+func TestReadAtProgress(t *testing.T) {
+	var events []progressEvent
+	r := openArchive(t, testChunks, WithProgress(func(done, total uint64) {
+		events = append(events, progressEvent{done, total})
+	}))
+
+	for _, tt := range []struct {
+		off, size int
+		want      []progressEvent
+		eof       bool
+	}{
+		{0, 25, []progressEvent{{6, 25}, {12, 25}, {20, 25}, {25, 25}}, false},
+		{8, 7, []progressEvent{{4, 7}, {7, 7}}, false},
+		{7, 2, []progressEvent{{2, 2}}, false},
+		{20, 10, []progressEvent{{5, 5}}, true},
+		{25, 4, nil, true},
+	} {
+		events = nil
+		p := make([]byte, tt.size)
+		n, err := r.ReadAtProgress(context.Background(), p, int64(tt.off))
+		want := testContent[min(tt.off, 25):min(tt.off+tt.size, 25)]
+		if string(p[:n]) != want || (err == io.EOF) != tt.eof || (err != nil && err != io.EOF) {
+			t.Errorf("ReadAtProgress(%d bytes at %d) = (%q, %v)", tt.size, tt.off, p[:n], err)
+		}
+		if fmt.Sprint(events) != fmt.Sprint(tt.want) {
+			t.Errorf("ReadAtProgress(%d bytes at %d) reported %v, want %v", tt.size, tt.off, events, tt.want)
+		}
+	}
+
+	// Cancellation stops the read between frames.
+	ctx, cancel := context.WithCancel(context.Background())
+	r = openArchive(t, testChunks, WithProgress(func(done, total uint64) {
+		if done >= 12 {
+			cancel()
+		}
+	}))
+	p := make([]byte, 25)
+	if n, err := r.ReadAtProgress(ctx, p, 0); n != 12 || !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled ReadAtProgress = (%d, %v), want (12, context.Canceled)", n, err)
+	}
+}
+
+func TestEqualContent(t *testing.T) {
+	base := openArchive(t, testChunks)
+
+	tests := []struct {
+		name   string
+		chunks []string
+		want   bool
+	}{
+		{"same layout", testChunks, true},
+		{"different layout", []string{"alpha-bravo-", "charlie-delta"}, true},
+		{"single frame", []string{testContent}, true},
+		{"same size, different content", []string{"alpha-bravo-charlie-DELTA"}, false},
+		{"different size", []string{"alpha"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := openArchive(t, tt.chunks)
+
+			got, err := EqualContent(base, other)
+			if err != nil {
+				t.Fatalf("EqualContent failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EqualContent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffFrames(t *testing.T) {
+	base := openArchive(t, testChunks)
+
+	for _, tt := range []struct {
+		name   string
+		chunks []string
+		want   []uint64
+	}{
+		{"unchanged", testChunks, nil},
+		{"edited", []string{"alpha-", "bravo!", "charlie-", "delta"}, []uint64{1}},
+		{"resized", []string{"alpha-", "bravo-", "charlie", "delta"}, []uint64{2}},
+		{"appended", []string{"alpha-", "bravo-", "charlie-", "delta", "echo"}, []uint64{4}},
+		{"truncated", []string{"alpha-", "bravo-"}, nil},
+		{"shifted", []string{"", "alpha-", "bravo-", "charlie-", "delta"}, []uint64{0, 1, 2, 3, 4}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffFrames(base, openArchive(t, tt.chunks))
+			if err != nil {
+				t.Fatalf("DiffFrames failed: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DiffFrames = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffFramesByChecksum(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 256) // 4 frames of 1 KiB
+	old, err := OpenBytes(writeTestArchive(t, content, WithFrameSize(1024)))
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer old.Close()
+
+	edited := append(bytes.Clone(content), "tail"...)
+	edited[1500] = 'X'
+	next, err := OpenBytes(writeTestArchive(t, edited, WithFrameSize(1024)))
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer next.Close()
+
+	got, err := DiffFrames(old, next)
+	if err != nil {
+		t.Fatalf("DiffFrames failed: %v", err)
+	}
+	if want := []uint64{1, 4}; !slices.Equal(got, want) {
+		t.Errorf("DiffFrames = %v, want %v", got, want)
+	}
+
+	// Without checksums on one side the frames are decoded and compared.
+	plain := openArchive(t, []string{string(content[:1024]), string(content[1024:2048]), string(content[2048:3072]), string(content[3072:])})
+	if got, err := DiffFrames(plain, next); err != nil || !slices.Equal(got, []uint64{1, 4}) {
+		t.Errorf("DiffFrames against an archive without checksums = (%v, %v)", got, err)
+	}
+}
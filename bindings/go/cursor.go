@@ -0,0 +1,105 @@
+package seekable
+
+import (
+	"errors"
+	"io"
+)
+
+// Read implements io.Reader, reading from the cursor and advancing it. The
+// cursor is shared with Seek and WriteTo and is independent of ReadAt, which
+// neither uses nor moves it. Like other cursor methods, Read is not safe for
+// concurrent use.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.pos >= r.Size64() {
+		return 0, io.EOF
+	}
+	n, err := r.ReadAt(p, r.pos)
+	r.pos += int64(n)
+	return n, err
+}
+
+// DecodeInto decodes the content at the cursor into p and advances the cursor
+// past the n bytes it wrote, for streaming loops that prefer a done flag to
+// io.EOF. done reports that the cursor has reached the end of the content; it
+// may be set together with n > 0, so the final bytes must still be consumed.
+// Once done, further calls return 0, true, nil. On error, n counts the bytes
+// written before it, and the cursor has advanced past them only.
+//
+// DecodeInto fills p across frame boundaries until p is full or the content
+// ends. Whole frames that fit are decoded straight into p. When p ends
+// partway through a frame, the rest of that frame is decoded once and served
+// to the following calls from an internal buffer that is reused for the
+// lifetime of the Reader, so small buffers cost no repeated decoding and a
+// steady loop does not allocate. The buffered bytes are discarded once Seek,
+// Read or WriteTo moves the cursor elsewhere.
+func (r *Reader) DecodeInto(p []byte) (n int, done bool, err error) {
+	v := r.view.Load()
+	size := v.size
+	for n < len(p) && uint64(r.pos) < size {
+		if len(r.pending) == 0 || r.pendingOff != r.pos {
+			start := uint64(r.pos)
+			room := uint64(len(p) - n)
+
+			// Whole frames that fit go straight into p.
+			end := start
+			for i := v.table.frameIndex(start); i < len(v.table.frames); i++ {
+				f := v.table.frames[i]
+				if f.decompOffset+f.decompSize-start > room {
+					break
+				}
+				end = f.decompOffset + f.decompSize
+			}
+			if end > start {
+				if err := r.readChunk(p[n:uint64(n)+end-start], start); err != nil {
+					return n, false, err
+				}
+				n += int(end - start)
+				r.pos = int64(end)
+				continue
+			}
+
+			// The next frame does not fit, so decode the rest of it once.
+			f := v.table.frames[v.table.frameIndex(start)]
+			rest := f.decompOffset + f.decompSize - start
+			if uint64(cap(r.pendingBuf)) < rest {
+				r.pendingBuf = make([]byte, rest)
+			}
+			buf := r.pendingBuf[:rest]
+			if err := r.readChunk(buf, start); err != nil {
+				return n, false, err
+			}
+			r.pending, r.pendingOff = buf, r.pos
+		}
+
+		m := copy(p[n:], r.pending)
+		r.pending = r.pending[m:]
+		r.pendingOff += int64(m)
+		r.pos += int64(m)
+		n += m
+	}
+	return n, uint64(r.pos) >= size, nil
+}
+
+// Seek implements io.Seeker, moving the cursor used by Read and WriteTo.
+// Seeking past the end is allowed; reads there return io.EOF.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.pos + offset
+	case io.SeekEnd:
+		abs = r.Size64() + offset
+	default:
+		return 0, errors.New("seekable: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("seekable: negative position")
+	}
+	r.pos = abs
+	return abs, nil
+}
+
+// Ensure Reader implements io.ReadSeeker
+var _ io.ReadSeeker = (*Reader)(nil)
@@ -0,0 +1,174 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// flakyWriter accepts up to limit bytes, then fails.
+type flakyWriter struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+var errFlaky = errors.New("connection reset")
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	room := w.limit - w.buf.Len()
+	if room >= len(p) {
+		return w.buf.Write(p)
+	}
+	w.buf.Write(p[:room])
+	return room, errFlaky
+}
+
+func TestWriteToResumes(t *testing.T) {
+	content := strings.Repeat("resumable stream ", 2000)
+	r := openArchivePath(t, writeArchive(t, writeTestArchive(t, []byte(content), WithFrameSize(1000))))
+
+	// Fail mid-frame, then on a frame boundary.
+	var got []byte
+	for _, limit := range []int{2500, 8000} {
+		w := &flakyWriter{limit: limit}
+		n, err := r.WriteTo(w)
+		if !errors.Is(err, errFlaky) || n != int64(limit) {
+			t.Fatalf("WriteTo = (%d, %v), want (%d, errFlaky)", n, err, limit)
+		}
+		got = append(got, w.buf.Bytes()...)
+	}
+
+	var rest bytes.Buffer
+	if _, err := r.WriteTo(&rest); err != nil {
+		t.Fatalf("resumed WriteTo failed: %v", err)
+	}
+	got = append(got, rest.Bytes()...)
+	if string(got) != content {
+		t.Errorf("resumed stream differs: %d bytes, want %d", len(got), len(content))
+	}
+
+	// At the end, WriteTo writes nothing until the cursor moves back.
+	if n, err := r.WriteTo(&rest); n != 0 || err != nil {
+		t.Errorf("WriteTo at the end = (%d, %v)", n, err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	rest.Reset()
+	if n, err := r.WriteTo(&rest); n != int64(len(content)) || err != nil {
+		t.Errorf("WriteTo after Seek = (%d, %v)", n, err)
+	}
+}
+
+func TestReadSeek(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	p := make([]byte, 8)
+	if n, err := r.Read(p); err != nil || string(p[:n]) != "alpha-br" {
+		t.Fatalf("Read = (%q, %v)", p[:n], err)
+	}
+	if pos, err := r.Seek(-5, io.SeekEnd); err != nil || pos != 20 {
+		t.Fatalf("Seek(-5, SeekEnd) = (%d, %v)", pos, err)
+	}
+	all, err := io.ReadAll(r)
+	if err != nil || string(all) != "delta" {
+		t.Errorf("ReadAll after Seek = (%q, %v)", all, err)
+	}
+
+	if pos, err := r.Seek(-13, io.SeekCurrent); err != nil || pos != 12 {
+		t.Fatalf("Seek(-13, SeekCurrent) = (%d, %v)", pos, err)
+	}
+	if n, err := r.Read(p); string(p[:n]) != "charlie-" || (err != nil && err != io.EOF) {
+		t.Errorf("Read = (%q, %v)", p[:n], err)
+	}
+
+	if _, err := r.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Seek to a negative position succeeded")
+	}
+	if _, err := r.Seek(100, io.SeekStart); err != nil {
+		t.Fatalf("Seek past the end failed: %v", err)
+	}
+	if n, err := r.Read(p); n != 0 || err != io.EOF {
+		t.Errorf("Read past the end = (%d, %v)", n, err)
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	content := []byte(strings.Repeat("decode into a fixed buffer ", 400))
+	for _, bufSize := range []int{1, 7, 1000, 4096, len(content) + 10} {
+		r := openArchivePath(t, writeArchive(t, writeTestArchive(t, content, WithFrameSize(1024))))
+
+		var out []byte
+		p := make([]byte, bufSize)
+		for calls := 0; ; calls++ {
+			if calls > len(content)+1 {
+				t.Fatalf("buffer %d: DecodeInto never finished", bufSize)
+			}
+			n, done, err := r.DecodeInto(p)
+			if err != nil {
+				t.Fatalf("buffer %d: DecodeInto failed: %v", bufSize, err)
+			}
+			out = append(out, p[:n]...)
+			if done {
+				break
+			}
+			if n != bufSize {
+				t.Fatalf("buffer %d: short fill of %d bytes before the end", bufSize, n)
+			}
+		}
+		if !bytes.Equal(out, content) {
+			t.Errorf("buffer %d: decoded %d bytes, want %d matching", bufSize, len(out), len(content))
+		}
+
+		if n, done, err := r.DecodeInto(p); n != 0 || !done || err != nil {
+			t.Errorf("buffer %d: DecodeInto at end = (%d, %v, %v)", bufSize, n, done, err)
+		}
+	}
+}
+
+func TestDecodeIntoSeek(t *testing.T) {
+	r := openArchive(t, testChunks)
+	p := make([]byte, 3)
+
+	if n, _, _ := r.DecodeInto(p); string(p[:n]) != "alp" {
+		t.Fatalf("first DecodeInto = %q", p[:n])
+	}
+	// The rest of frame 0 is buffered; seeking elsewhere must not serve it.
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if n, _, _ := r.DecodeInto(p); string(p[:n]) != "cha" {
+		t.Errorf("DecodeInto after Seek = %q, want \"cha\"", p[:n])
+	}
+	if _, err := r.Seek(-2, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	if n, done, err := r.DecodeInto(p); string(p[:n]) != "ta" || !done || err != nil {
+		t.Errorf("DecodeInto at the tail = (%q, %v, %v)", p[:n], done, err)
+	}
+	if _, err := r.Seek(100, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if n, done, err := r.DecodeInto(p); n != 0 || !done || err != nil {
+		t.Errorf("DecodeInto past the end = (%d, %v, %v)", n, done, err)
+	}
+}
+
+func TestDecodeIntoAllocs(t *testing.T) {
+	content := []byte(strings.Repeat("steady loop ", 2000))
+	r := openArchivePath(t, writeArchive(t, writeTestArchive(t, content, WithFrameSize(4096))))
+	p := make([]byte, 512)
+
+	// Warm the internal buffer, then stream through the rest.
+	r.DecodeInto(p)
+	allocs := testing.AllocsPerRun(20, func() {
+		if _, done, _ := r.DecodeInto(p); done {
+			r.Seek(0, io.SeekStart)
+		}
+	})
+	if allocs > 0 {
+		t.Errorf("DecodeInto allocates %.1f times per call", allocs)
+	}
+}
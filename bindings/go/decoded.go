@@ -0,0 +1,30 @@
+package seekable
+
+// OpenFullyDecoded opens the archive at path like Open and, if its
+// decompressed size is at most maxBytes, decodes it in full before returning.
+// Every read is then served by copying from that buffer, and the core decoder
+// is freed straight away, so no decoder state is held between reads. This
+// suits many small archives read repeatedly, where per-read decoding would
+// dominate.
+//
+// Archives larger than maxBytes are returned as Open would return them. The
+// compressed file stays open, so methods that read compressed frames, such as
+// DeepValidate, keep working. Sub-readers share the decoded buffer.
+func OpenFullyDecoded(path string, maxBytes uint64, opts ...Option) (*Reader, error) {
+	r, err := Open(path, opts...)
+	if err != nil || r.Size() > maxBytes {
+		return r, err
+	}
+
+	data, err := r.DecompressAll()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	r.decoded = data
+	r.pinned = nil
+	r.ptr = nil
+	r.h.closeDecoder()
+	return r, nil
+}
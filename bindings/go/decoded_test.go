@@ -0,0 +1,64 @@
+package seekable
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOpenFullyDecoded(t *testing.T) {
+	content := []byte(strings.Repeat("decoded up front ", 500))
+	path := writeArchive(t, writeTestArchive(t, content, WithFrameSize(1000)))
+
+	r, err := OpenFullyDecoded(path, 1<<20)
+	if err != nil {
+		t.Fatalf("OpenFullyDecoded failed: %v", err)
+	}
+	defer r.Close()
+	if r.decoded == nil || r.ptr != nil {
+		t.Fatal("archive was not decoded up front")
+	}
+
+	got, err := r.ReadRange(900, 4100)
+	if err != nil || !bytes.Equal(got, content[900:4100]) {
+		t.Errorf("ReadRange = (%d bytes, %v)", len(got), err)
+	}
+	p := make([]byte, 100)
+	if n, err := r.ReadAt(p, int64(len(content)-40)); n != 40 || err == nil {
+		t.Errorf("ReadAt at the end = (%d, %v), want (40, io.EOF)", n, err)
+	}
+	if got := r.Stats(); got.DecoderBytes != 0 || got.PinnedBytes != uint64(len(content)) {
+		t.Errorf("Stats() = %+v", got)
+	}
+
+	// Compressed frames are still readable.
+	if err := r.DeepValidate(); err != nil {
+		t.Errorf("DeepValidate failed: %v", err)
+	}
+
+	sub, err := r.SubReader(2, 4)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+	all, err := sub.DecompressAll()
+	if err != nil || !bytes.Equal(all, content[2000:4000]) {
+		t.Errorf("SubReader DecompressAll = (%d bytes, %v)", len(all), err)
+	}
+}
+
+func TestOpenFullyDecodedTooLarge(t *testing.T) {
+	path := writeArchive(t, buildArchive(testChunks...))
+
+	r, err := OpenFullyDecoded(path, 10)
+	if err != nil {
+		t.Fatalf("OpenFullyDecoded failed: %v", err)
+	}
+	defer r.Close()
+	if r.decoded != nil {
+		t.Error("archive larger than maxBytes was decoded up front")
+	}
+	if got, err := r.DecompressAll(); err != nil || string(got) != testContent {
+		t.Errorf("DecompressAll = (%q, %v)", got, err)
+	}
+}
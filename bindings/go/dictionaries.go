@@ -0,0 +1,103 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrMissingDictionary is returned when a frame declares a dictionary ID
+// that WithDictionaries did not supply. The error names the ID.
+var ErrMissingDictionary = errors.New("seekable: missing dictionary")
+
+// dictSet holds the dictionaries supplied with WithDictionaries, digested
+// once and shared by every decompression context of a Reader and its
+// sub-readers.
+type dictSet struct {
+	dicts map[uint32]*ddict
+}
+
+// newDictSet digests the dictionaries in raw, keyed by dictionary ID. The
+// dictionary under ID 0, if any, is the default for frames that declare none.
+func newDictSet(raw map[uint32][]byte) (*dictSet, error) {
+	s := &dictSet{dicts: make(map[uint32]*ddict, len(raw))}
+	for id, dict := range raw {
+		dd, err := newDDict(dict)
+		if err != nil {
+			s.free()
+			return nil, fmt.Errorf("seekable: dictionary %d: %w", id, err)
+		}
+		s.dicts[id] = dd
+		switch got := dd.id(); {
+		case got == 0 && id != 0:
+			s.free()
+			return nil, fmt.Errorf("seekable: dictionary supplied for ID %d has no ID; supply raw content dictionaries under ID 0", id)
+		case got != id:
+			s.free()
+			return nil, fmt.Errorf("seekable: dictionary supplied for ID %d has ID %d", id, got)
+		}
+	}
+	return s, nil
+}
+
+// apply makes d ready to decode the compressed frame in comp, referencing
+// the dictionary its header declares, or the default dictionary if it
+// declares none. It is safe on a nil set, which holds no dictionaries.
+func (s *dictSet) apply(d *dctx, comp []byte) error {
+	id := frameDictID(comp, d.magicless)
+	var dd *ddict
+	if s != nil {
+		dd = s.dicts[id]
+	}
+	if dd == nil && id != 0 {
+		return fmt.Errorf("%w: frame needs dictionary ID %d", ErrMissingDictionary, id)
+	}
+	return d.useDict(dd)
+}
+
+// free releases the digested dictionaries. It is safe on a nil set.
+func (s *dictSet) free() {
+	if s == nil {
+		return
+	}
+	for _, dd := range s.dicts {
+		dd.free()
+	}
+}
+
+// frameDictID returns the dictionary ID in the header of the zstd frame in
+// comp, which is magicless if noMagic is set, or 0 if it declares none or the
+// header is too short to tell.
+func frameDictID(comp []byte, noMagic bool) uint32 {
+	hdr := comp
+	if !noMagic {
+		if len(comp) < 4 {
+			return 0
+		}
+		hdr = comp[4:]
+	}
+	if len(hdr) == 0 {
+		return 0
+	}
+
+	fhd := hdr[0]
+	pos := 1
+	if fhd&0x20 == 0 {
+		pos++ // window descriptor, absent in single-segment frames
+	}
+	switch fhd & 3 {
+	case 1:
+		if len(hdr) >= pos+1 {
+			return uint32(hdr[pos])
+		}
+	case 2:
+		if len(hdr) >= pos+2 {
+			return uint32(binary.LittleEndian.Uint16(hdr[pos:]))
+		}
+	case 3:
+		if len(hdr) >= pos+4 {
+			return binary.LittleEndian.Uint32(hdr[pos:])
+		}
+	}
+	return 0
+}
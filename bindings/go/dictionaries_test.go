@@ -0,0 +1,152 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// dictionaryFixture returns the content of dictionaries.szst and the
+// dictionaries its frames use. Frames 0 and 3 use dictionary 1001, frame 1
+// uses 2002 and frame 2 uses none.
+func dictionaryFixture(t testing.TB) ([][]byte, map[uint32][]byte) {
+	t.Helper()
+	var json, xml bytes.Buffer
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&json, "{\"user\": \"u%d\", \"event\": \"login\", \"region\": \"eu-west-%d\", \"ok\": true}\n", i*7919, i%3+1)
+		fmt.Fprintf(&xml, "<record id=\"%d\"><status>shipped</status><carrier>acme-%d</carrier></record>\n", i*104729, i%9+1)
+	}
+	frames := [][]byte{json.Bytes(), xml.Bytes(), bytes.Repeat([]byte("no dictionary for this frame\n"), 10), json.Bytes()[:500]}
+
+	wd, _ := os.Getwd()
+	dicts := make(map[uint32][]byte)
+	for _, id := range []uint32{1001, 2002} {
+		dict, err := os.ReadFile(filepath.Join(wd, "../../tests/fixtures", fmt.Sprintf("dictionary-%d.zdict", id)))
+		if err != nil {
+			t.Fatalf("Failed to read dictionary: %v", err)
+		}
+		dicts[id] = dict
+	}
+	return frames, dicts
+}
+
+func TestWithDictionaries(t *testing.T) {
+	frames, dicts := dictionaryFixture(t)
+	content := bytes.Join(frames, nil)
+
+	r := openFixture(t, "dictionaries.szst", WithDictionaries(dicts))
+	for i, want := range frames {
+		got, err := r.ReadFrames(uint64(i), uint64(i)+1)
+		if err != nil {
+			t.Fatalf("ReadFrames(%d) failed: %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("frame %d content does not match", i)
+		}
+	}
+	if all, err := r.DecompressAll(); err != nil || !bytes.Equal(all, content) {
+		t.Errorf("DecompressAll = (%d bytes, %v)", len(all), err)
+	}
+	if err := r.DeepValidate(); err != nil {
+		t.Errorf("DeepValidate failed: %v", err)
+	}
+
+	sub, err := r.SubReader(1, 3)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+	if got, err := sub.ReadRange(0, sub.Size()); err != nil || !bytes.Equal(got, content[len(frames[0]):len(frames[0])+len(frames[1])+len(frames[2])]) {
+		t.Errorf("sub-reader ReadRange = (%d bytes, %v)", len(got), err)
+	}
+}
+
+func TestWithDictionariesMissing(t *testing.T) {
+	frames, dicts := dictionaryFixture(t)
+	delete(dicts, 2002)
+
+	r := openFixture(t, "dictionaries.szst", WithDictionaries(dicts))
+	if got, err := r.ReadFrames(0, 1); err != nil || !bytes.Equal(got, frames[0]) {
+		t.Errorf("ReadFrames(0) = (%d bytes, %v)", len(got), err)
+	}
+	if got, err := r.ReadFrames(2, 3); err != nil || !bytes.Equal(got, frames[2]) {
+		t.Errorf("ReadFrames(2) = (%d bytes, %v)", len(got), err)
+	}
+	_, err := r.ReadFrames(1, 2)
+	if !errors.Is(err, ErrMissingDictionary) || !strings.Contains(err.Error(), "2002") {
+		t.Errorf("ReadFrames(1) = %v, want ErrMissingDictionary naming 2002", err)
+	}
+}
+
+func TestWithDictionariesRawContent(t *testing.T) {
+	dict := []byte(strings.Repeat("raw content dictionary: shared prefix for every record\n", 20))
+	records := []byte(strings.Repeat("raw content dictionary: shared prefix for every record\n", 3))
+
+	// The CLI treats a file without the dictionary magic as raw content.
+	dictFile := filepath.Join(t.TempDir(), "raw.dict")
+	if err := os.WriteFile(dictFile, dict, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(zstdCLI(t), "-q", "-c", "-D", dictFile)
+	cmd.Stdin = bytes.NewReader(records)
+	frame, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("zstd -D failed: %v", err)
+	}
+	if id := frameDictID(frame, false); id != 0 {
+		t.Fatalf("raw content dictionary frame declares ID %d", id)
+	}
+
+	plain := rawFrame([]byte("no dictionary"))
+	data := append(append(bytes.Clone(frame), plain...), seekTableFrame(
+		[]uint32{uint32(len(frame)), uint32(len(plain))},
+		[]uint32{uint32(len(records)), 13})...)
+	r, err := OpenBytes(data, WithDictionaries(map[uint32][]byte{0: dict}))
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	want := append(bytes.Clone(records), "no dictionary"...)
+	if got, err := r.DecompressAll(); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("DecompressAll = (%q, %v)", got, err)
+	}
+}
+
+func TestWithDictionariesInvalid(t *testing.T) {
+	_, dicts := dictionaryFixture(t)
+	wd, _ := os.Getwd()
+	path := filepath.Join(wd, "../../tests/fixtures/dictionaries.szst")
+
+	for name, bad := range map[string]map[uint32][]byte{
+		"wrong ID":  {7: dicts[1001]},
+		"ID 0":      {0: dicts[1001]},
+		"raw as ID": {7: []byte("raw content dictionary")},
+		"empty":     {1001: nil},
+	} {
+		if r, err := Open(path, WithDictionaries(bad)); err == nil {
+			r.Close()
+			t.Errorf("Open accepted dictionaries with %s", name)
+		}
+	}
+}
+
+func TestFrameDictID(t *testing.T) {
+	r := openFixture(t, "dictionaries.szst")
+	for i, want := range []uint32{1001, 2002, 0, 1001} {
+		frame, err := r.ReadCompressedFrame(uint64(i))
+		if err != nil {
+			t.Fatalf("ReadCompressedFrame(%d) failed: %v", i, err)
+		}
+		if got := frameDictID(frame, false); got != want {
+			t.Errorf("frameDictID(frame %d) = %d, want %d", i, got, want)
+		}
+	}
+	if got := frameDictID(rawFrame([]byte("x")), false); got != 0 {
+		t.Errorf("frameDictID(raw frame) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,109 @@
+package seekable
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"unsafe"
+)
+
+// DirectBuffer holds decompressed data in memory allocated outside the Go
+// heap, which the garbage collector neither scans nor accounts for. It is
+// returned by ReadRangeDirect and must be released with Free.
+//
+// The slice returned by Bytes aliases the C allocation. It must not be used,
+// and no slice derived from it may be retained, after Free; doing so reads or
+// corrupts freed memory rather than failing cleanly. A DirectBuffer is not
+// safe for concurrent use with Free.
+//
+// The memory is never freed behind the caller's back, since a slice from
+// Bytes can outlive the DirectBuffer it came from. A buffer that becomes
+// unreachable without being freed leaks its memory; if the Reader has a
+// WithLogger logger, the leak is logged at warning level once the garbage
+// collector notices it.
+type DirectBuffer struct {
+	ptr    unsafe.Pointer
+	n      int
+	logger *slog.Logger
+}
+
+// Bytes returns the buffer contents. The slice is only valid until Free; once
+// the buffer is freed, Bytes returns an empty slice.
+func (b *DirectBuffer) Bytes() []byte {
+	if b.ptr == nil {
+		return []byte{}
+	}
+	return unsafe.Slice((*byte)(b.ptr), b.n)
+}
+
+// Len returns the number of bytes in the buffer, or 0 once it is freed.
+func (b *DirectBuffer) Len() int {
+	return b.n
+}
+
+// Free releases the buffer's memory. It is safe to call more than once.
+func (b *DirectBuffer) Free() {
+	if b.ptr != nil {
+		C.free(b.ptr)
+		b.ptr = nil
+		if b.logger != nil {
+			runtime.SetFinalizer(b, nil)
+		}
+	}
+	b.n = 0
+}
+
+// reportLeak logs a buffer that was dropped without Free. It runs as a
+// finalizer and leaves the memory alone, as a slice from Bytes may still be
+// in use.
+func (b *DirectBuffer) reportLeak() {
+	if b.ptr != nil {
+		b.logger.LogAttrs(context.Background(), slog.LevelWarn, "seekable: DirectBuffer was not freed",
+			slog.Int("size", b.n))
+	}
+}
+
+// ReadRangeDirect decodes the range [start, end) into a DirectBuffer, so large
+// ranges can be processed and released without going through the Go heap.
+// The caller must call Free on the result.
+func (r *Reader) ReadRangeDirect(start, end uint64) (*DirectBuffer, error) {
+	if start > end {
+		return nil, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
+	}
+
+	if end > r.Size() {
+		return nil, fmt.Errorf("range end (%d) exceeds size (%d)", end, r.Size())
+	}
+
+	if start == end {
+		return &DirectBuffer{}, nil
+	}
+
+	size := end - start
+	if err := r.checkReadSize(size); err != nil {
+		return nil, err
+	}
+	if size > uint64(^uint(0)>>1) {
+		return nil, fmt.Errorf("range of %d bytes is too large for memory", size)
+	}
+	ptr := C.malloc(C.size_t(size))
+	if ptr == nil {
+		return nil, errors.New("seekable: cannot allocate direct buffer")
+	}
+	b := &DirectBuffer{ptr: ptr, n: int(size), logger: r.cfg.logger}
+	if b.logger != nil {
+		runtime.SetFinalizer(b, (*DirectBuffer).reportLeak)
+	}
+
+	if err := r.readChunk(b.Bytes(), start); err != nil {
+		b.Free()
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,97 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadRangeDirect(t *testing.T) {
+	content := strings.Repeat("off-heap decode ", 1000)
+	r := openArchivePath(t, writeArchive(t, writeTestArchive(t, []byte(content), WithFrameSize(1000))))
+
+	b, err := r.ReadRangeDirect(500, 12000)
+	if err != nil {
+		t.Fatalf("ReadRangeDirect failed: %v", err)
+	}
+	if b.Len() != 11500 || string(b.Bytes()) != content[500:12000] {
+		t.Error("ReadRangeDirect content does not match")
+	}
+
+	b.Free()
+	b.Free()
+	if b.Len() != 0 || len(b.Bytes()) != 0 {
+		t.Errorf("freed buffer has %d bytes", len(b.Bytes()))
+	}
+
+	empty, err := r.ReadRangeDirect(7, 7)
+	if err != nil {
+		t.Fatalf("ReadRangeDirect(empty) failed: %v", err)
+	}
+	if got := empty.Bytes(); got == nil || len(got) != 0 {
+		t.Errorf("empty range = %v, want an empty slice", got)
+	}
+	empty.Free()
+
+	if _, err := r.ReadRangeDirect(10, 5); err == nil {
+		t.Error("ReadRangeDirect with start > end succeeded")
+	}
+	if _, err := r.ReadRangeDirect(0, r.Size()+1); err == nil {
+		t.Error("ReadRangeDirect past the end succeeded")
+	}
+}
+
+func TestReadRangeDirectLimits(t *testing.T) {
+	r := openArchive(t, testChunks, WithLimits(Limits{MaxSingleReadBytes: 8}))
+	if _, err := r.ReadRangeDirect(0, 9); !errors.Is(err, ErrReadTooLarge) {
+		t.Errorf("ReadRangeDirect over the limit = %v, want ErrReadTooLarge", err)
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for use from a finalizer.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestReadRangeDirectOutlivesBuffer(t *testing.T) {
+	var logs lockedBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	r := openArchive(t, testChunks, WithLogger(logger))
+
+	b, err := r.ReadRangeDirect(0, r.Size())
+	if err != nil {
+		t.Fatalf("ReadRangeDirect failed: %v", err)
+	}
+	data := b.Bytes()
+	b = nil
+
+	// Dropping the buffer must not free the memory under data.
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if string(data) != testContent {
+		t.Errorf("data after the buffer became unreachable = %q", data)
+	}
+	if !strings.Contains(logs.String(), "DirectBuffer was not freed") {
+		t.Errorf("leak not logged; log = %q", logs.String())
+	}
+}
@@ -0,0 +1,174 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+)
+
+// metadataIndex holds the entry index: a directory of named byte ranges of
+// the decompressed content. Its payload is an entry count (u32) followed by
+// one record per entry, all little-endian:
+//
+//	name length (u16), name, start (u64), end (u64), mode (u32)
+//
+// Mode holds file mode bits, or 0 when the entry is not a file.
+const metadataIndex = "IDX1"
+
+// ErrEntryNotFound is returned by ReadEntry for names that are not in the
+// archive's entry index. It wraps fs.ErrNotExist.
+var ErrEntryNotFound = fmt.Errorf("seekable: entry not found: %w", fs.ErrNotExist)
+
+// indexEntry is one named range of the entry index.
+type indexEntry struct {
+	name       string
+	start, end uint64
+	mode       uint32
+}
+
+// appendIndex encodes entries as an index payload.
+func appendIndex(dst []byte, entries []indexEntry) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(entries)))
+	for _, e := range entries {
+		dst = binary.LittleEndian.AppendUint16(dst, uint16(len(e.name)))
+		dst = append(dst, e.name...)
+		dst = binary.LittleEndian.AppendUint64(dst, e.start)
+		dst = binary.LittleEndian.AppendUint64(dst, e.end)
+		dst = binary.LittleEndian.AppendUint32(dst, e.mode)
+	}
+	return dst
+}
+
+// parseIndex decodes the entry index, if the archive has one, and checks that
+// every entry lies within the content.
+func (st *seekTable) parseIndex() error {
+	payload, ok := st.metadata[metadataIndex]
+	if !ok {
+		return nil
+	}
+
+	errCorrupt := errors.New("invalid entry index: truncated")
+	if len(payload) < 4 {
+		return errCorrupt
+	}
+	count := binary.LittleEndian.Uint32(payload)
+	payload = payload[4:]
+
+	size := st.size()
+	st.entries = make([]indexEntry, 0, min(count, uint32(len(payload)/22)))
+	st.entryIndex = make(map[string]int, cap(st.entries))
+	for i := uint32(0); i < count; i++ {
+		if len(payload) < 2 {
+			return errCorrupt
+		}
+		nameLen := int(binary.LittleEndian.Uint16(payload))
+		if len(payload) < 2+nameLen+20 {
+			return errCorrupt
+		}
+		e := indexEntry{
+			name:  string(payload[2 : 2+nameLen]),
+			start: binary.LittleEndian.Uint64(payload[2+nameLen:]),
+			end:   binary.LittleEndian.Uint64(payload[10+nameLen:]),
+			mode:  binary.LittleEndian.Uint32(payload[18+nameLen:]),
+		}
+		payload = payload[22+nameLen:]
+
+		if e.start > e.end || e.end > size {
+			return fmt.Errorf("invalid entry index: entry %q spans [%d, %d) of %d bytes", e.name, e.start, e.end, size)
+		}
+		if _, dup := st.entryIndex[e.name]; dup {
+			return fmt.Errorf("invalid entry index: duplicate entry %q", e.name)
+		}
+		st.entryIndex[e.name] = len(st.entries)
+		st.entries = append(st.entries, e)
+	}
+	return nil
+}
+
+// AddEntry records name as the decompressed range [start, end) in the
+// archive's entry index, which Close writes as a metadata frame. Readers look
+// entries up with ReadEntry and ListEntries.
+//
+// The range must already have been written, so end may not exceed Offset().
+// Names must be unique and non-empty, and at most 65535 bytes long. Ranges
+// may overlap.
+func (w *Writer) AddEntry(name string, start, end uint64) error {
+	return w.addEntry(indexEntry{name: name, start: start, end: end})
+}
+
+func (w *Writer) addEntry(e indexEntry) error {
+	if w.closed {
+		return errWriterClosed
+	}
+	if e.name == "" || len(e.name) > math.MaxUint16 {
+		return fmt.Errorf("seekable: invalid entry name length %d", len(e.name))
+	}
+	if e.start > e.end || e.end > w.Offset() {
+		return fmt.Errorf("seekable: entry %q range [%d, %d) is not within the %d bytes written", e.name, e.start, e.end, w.Offset())
+	}
+	if w.entryNames == nil {
+		w.entryNames = make(map[string]bool)
+	}
+	if w.entryNames[e.name] {
+		return fmt.Errorf("seekable: duplicate entry %q", e.name)
+	}
+
+	w.entryNames[e.name] = true
+	w.entries = append(w.entries, e)
+	return nil
+}
+
+// ListEntries returns the names in the archive's entry index, in the order
+// they were added. It returns nil if the archive has no index.
+func (r *Reader) ListEntries() []string {
+	v := r.view.Load()
+	if len(v.table.entries) == 0 {
+		return nil
+	}
+	names := make([]string, len(v.table.entries))
+	for i, e := range v.table.entries {
+		names[i] = e.name
+	}
+	return names
+}
+
+// EntryInfo describes one entry of an archive's entry index.
+type EntryInfo struct {
+	Name string
+	// Offset is the decompressed offset at which the entry starts.
+	Offset uint64
+	Size   uint64
+	// Mode holds the entry's file mode bits, or 0 if none were recorded.
+	Mode fs.FileMode
+}
+
+// Entries describes the entries in the archive's entry index, in the order
+// they were added. It returns nil if the archive has no index. The index is
+// parsed when the archive is opened, so Entries reads nothing and the error
+// is always nil.
+func (r *Reader) Entries() ([]EntryInfo, error) {
+	v := r.view.Load()
+	if len(v.table.entries) == 0 {
+		return nil, nil
+	}
+	infos := make([]EntryInfo, len(v.table.entries))
+	for i, e := range v.table.entries {
+		infos[i] = EntryInfo{Name: e.name, Offset: e.start, Size: e.end - e.start, Mode: fs.FileMode(e.mode)}
+	}
+	return infos, nil
+}
+
+// ReadEntry returns the decompressed content of the named entry. Only the
+// frames overlapping the entry are decoded. It returns an error wrapping
+// ErrEntryNotFound if the archive's entry index has no such entry.
+func (r *Reader) ReadEntry(name string) ([]byte, error) {
+	v := r.view.Load()
+	i, ok := v.table.entryIndex[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrEntryNotFound, name)
+	}
+	e := v.table.entries[i]
+	return r.ReadRange(e.start, e.end)
+}
@@ -0,0 +1,156 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+func TestEntriesRoundTrip(t *testing.T) {
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"a.txt", bytes.Repeat([]byte("alpha "), 500)},
+		{"dir/b.txt", bytes.Repeat([]byte("bravo "), 2000)},
+		{"empty", nil},
+		{"c.bin", bytes.Repeat([]byte{0, 1, 2, 3}, 300)},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithFrameSize(1024))
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	for _, f := range files {
+		start := w.Offset()
+		if _, err := w.Write(f.content); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if err := w.AddEntry(f.name, start, w.Offset()); err != nil {
+			t.Fatalf("AddEntry(%q) failed: %v", f.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r := openArchivePath(t, writeArchive(t, buf.Bytes()))
+
+	want := []string{"a.txt", "dir/b.txt", "empty", "c.bin"}
+	if got := r.ListEntries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ListEntries() = %q, want %q", got, want)
+	}
+	infos, err := r.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	var offset uint64
+	for i, info := range infos {
+		want := EntryInfo{Name: files[i].name, Offset: offset, Size: uint64(len(files[i].content))}
+		if info != want {
+			t.Errorf("Entries()[%d] = %+v, want %+v", i, info, want)
+		}
+		offset += info.Size
+	}
+	if len(infos) != len(files) {
+		t.Errorf("Entries() returned %d entries, want %d", len(infos), len(files))
+	}
+
+	for _, f := range files {
+		got, err := r.ReadEntry(f.name)
+		if err != nil {
+			t.Fatalf("ReadEntry(%q) failed: %v", f.name, err)
+		}
+		if !bytes.Equal(got, f.content) {
+			t.Errorf("ReadEntry(%q) returned %d bytes, want %d", f.name, len(got), len(f.content))
+		}
+	}
+
+	_, err = r.ReadEntry("missing")
+	if !errors.Is(err, ErrEntryNotFound) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadEntry(missing) error = %v, want ErrEntryNotFound", err)
+	}
+}
+
+func TestEntriesAbsent(t *testing.T) {
+	r := openArchivePath(t, writeArchive(t, writeTestArchive(t, []byte("no index"))))
+
+	if got := r.ListEntries(); got != nil {
+		t.Errorf("ListEntries() = %q, want nil", got)
+	}
+	if infos, err := r.Entries(); infos != nil || err != nil {
+		t.Errorf("Entries() = (%v, %v), want (nil, nil)", infos, err)
+	}
+	if _, err := r.ReadEntry("x"); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("ReadEntry error = %v, want ErrEntryNotFound", err)
+	}
+}
+
+func TestAddEntryInvalid(t *testing.T) {
+	w, err := NewWriter(&bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.AddEntry("ok", 0, 10); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		entry      string
+		start, end uint64
+	}{
+		{"empty name", "", 0, 1},
+		{"duplicate", "ok", 0, 1},
+		{"reversed", "r", 5, 4},
+		{"past offset", "p", 0, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := w.AddEntry(tt.entry, tt.start, tt.end); err == nil {
+				t.Error("AddEntry succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestEntriesCorruptIndex(t *testing.T) {
+	content := []byte("content")
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Rebuild the archive with an index entry that runs past the content.
+	data := buf.Bytes()
+	frames := len(data) - (skippableHeaderSize + 12 + seekTableFooterSize)
+	archive := append([]byte(nil), data[:frames]...)
+	index := appendIndex(nil, []indexEntry{{name: "x", start: 0, end: 100}})
+	archive = appendMetadataFrame(archive, metadataIndex, index)
+	archive = append(archive, data[frames:]...)
+
+	if _, err := OpenBytes(archive); err == nil {
+		t.Error("OpenBytes succeeded with an out-of-range entry")
+	}
+
+	truncated := append([]byte(nil), data[:frames]...)
+	truncated = appendMetadataFrame(truncated, metadataIndex, index[:len(index)-3])
+	truncated = append(truncated, data[frames:]...)
+	if _, err := OpenBytes(truncated); err == nil {
+		t.Error("OpenBytes succeeded with a truncated index")
+	}
+}
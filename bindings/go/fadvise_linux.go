@@ -0,0 +1,52 @@
+//go:build linux && (amd64 || arm64)
+
+package seekable
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// posix_fadvise advice values from <fcntl.h>.
+var linuxAdvice = map[Advice]uintptr{
+	AdviceNormal:     0,
+	AdviceRandom:     1,
+	AdviceSequential: 2,
+	AdviceWillNeed:   3,
+	AdviceDontNeed:   4,
+}
+
+// fadvise applies advice to the whole of f.
+func fadvise(f *os.File, advice Advice) error {
+	value, ok := linuxAdvice[advice]
+	if !ok {
+		return syscall.EINVAL
+	}
+
+	sc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := sc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall6(syscall.SYS_FADVISE64, fd, 0, 0, value, 0, 0)
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// openNoATime opens path read-only without updating its access time. The
+// kernel only allows this for the file's owner, so it falls back to a plain
+// open when the flag is refused.
+func openNoATime(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOATIME, 0)
+	if errors.Is(err, syscall.EPERM) {
+		return os.Open(path)
+	}
+	return f, err
+}
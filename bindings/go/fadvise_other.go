@@ -0,0 +1,16 @@
+//go:build !(linux && (amd64 || arm64))
+
+package seekable
+
+import "os"
+
+// fadvise is a no-op outside Linux.
+func fadvise(f *os.File, advice Advice) error {
+	return nil
+}
+
+// openNoATime opens path read-only; access time updates cannot be suppressed
+// outside Linux.
+func openNoATime(path string) (*os.File, error) {
+	return os.Open(path)
+}
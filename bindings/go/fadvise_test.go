@@ -0,0 +1,70 @@
+package seekable
+
+import (
+	"io"
+	"runtime"
+	"testing"
+)
+
+func TestWithFadvise(t *testing.T) {
+	path := writeArchive(t, buildArchive(testChunks...))
+
+	r := openArchivePath(t, path,
+		WithFadvise(AdviceRandom),
+		WithFadvise(AdviceWillNeed),
+		WithNoATime())
+	if r.ptr != nil {
+		t.Error("Expected frames to be decoded in Go")
+	}
+
+	got, err := r.ReadRange(4, 15)
+	if err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if string(got) != testContent[4:15] {
+		t.Errorf("Expected '%s', got '%s'", testContent[4:15], string(got))
+	}
+}
+
+func TestWithNoReadahead(t *testing.T) {
+	r := openArchivePath(t, writeArchive(t, buildArchive(testChunks...)), WithNoReadahead())
+	if r.ptr != nil {
+		t.Error("Expected frames to be decoded in Go")
+	}
+	if len(r.cfg.advice) != 1 || r.cfg.advice[0] != AdviceRandom {
+		t.Errorf("advice = %v, want [AdviceRandom]", r.cfg.advice)
+	}
+
+	s, err := r.NewStream(0, r.Size(), WithStreamPipelineDepth(4))
+	if err != nil {
+		t.Fatalf("NewStream failed: %v", err)
+	}
+	defer s.Close()
+	if s.rr == nil {
+		t.Error("NewStream returned a pipelined stream despite WithNoReadahead")
+	}
+	if got, err := io.ReadAll(s); err != nil || string(got) != testContent {
+		t.Errorf("ReadAll = (%q, %v)", got, err)
+	}
+}
+
+func TestFadviseSyscall(t *testing.T) {
+	if runtime.GOOS != "linux" || (runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64") {
+		t.Skip("posix_fadvise is only issued on Linux")
+	}
+
+	f, err := openNoATime(writeArchive(t, buildArchive(testChunks...)))
+	if err != nil {
+		t.Fatalf("openNoATime failed: %v", err)
+	}
+	defer f.Close()
+
+	for _, advice := range []Advice{AdviceNormal, AdviceRandom, AdviceSequential, AdviceWillNeed, AdviceDontNeed} {
+		if err := fadvise(f, advice); err != nil {
+			t.Errorf("fadvise(%d) failed: %v", advice, err)
+		}
+	}
+	if err := fadvise(f, Advice(99)); err == nil {
+		t.Error("Expected unknown advice to be rejected")
+	}
+}
@@ -0,0 +1,381 @@
+package seekable
+
+/*
+#include "include/seekable_zstd.h"
+*/
+import "C"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+	"unsafe"
+)
+
+// readFrames fills p with the decompressed bytes starting at off, one frame at
+// a time. It serves archives that are not decoded by the core decoder, and
+// readers that cache decoded frames.
+func (r *Reader) readFrames(ctx context.Context, p []byte, off uint64) (int, error) {
+	v := r.view.Load()
+	n := 0
+	for i := v.table.frameIndex(off); i < len(v.table.frames) && n < len(p); i++ {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		f := v.table.frames[i]
+		if f.decompSize == 0 {
+			continue
+		}
+
+		data, err := r.frame(ctx, i)
+		if err != nil {
+			return n, err
+		}
+
+		skip := off + uint64(n) - f.decompOffset
+		n += copy(p[n:], data[skip:])
+	}
+
+	return n, nil
+}
+
+// readCore fills p with the decompressed bytes starting at off using the core
+// decoder. Each call into the decoder covers at most one frame, so ctx is
+// checked between frames and no single cgo call runs for long.
+func (r *Reader) readCore(ctx context.Context, p []byte, off uint64) (int, error) {
+	v := r.view.Load()
+	n := 0
+	end := off + uint64(len(p))
+	for i := v.table.frameIndex(off); i < len(v.table.frames) && n < len(p); i++ {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		f := v.table.frames[i]
+		if f.decompSize == 0 {
+			continue
+		}
+
+		if err := r.chargeDecode(f.decompSize); err != nil {
+			return n, err
+		}
+
+		pos := off + uint64(n)
+		dst := p[n : uint64(n)+min(f.decompOffset+f.decompSize, end)-pos]
+		cLen := C.uintptr_t(len(dst))
+
+		release := acquireDecode()
+		r.h.decode.Lock()
+		res := C.seekable_read_range(
+			r.ptr,
+			C.uint64_t(r.base+pos),
+			C.uint64_t(r.base+pos+uint64(len(dst))),
+			(*C.uint8_t)(unsafe.Pointer(&dst[0])),
+			&cLen,
+		)
+		r.h.decode.Unlock()
+		release()
+
+		if res < 0 {
+			errStr := C.seekable_last_error()
+			if errStr == nil {
+				return n, errors.New("unknown error")
+			}
+			return n, errors.New(C.GoString(errStr))
+		}
+
+		n += int(cLen)
+		if int(cLen) < len(dst) {
+			break
+		}
+	}
+	return n, nil
+}
+
+// readsByFrame reports whether reads must go through readFrames rather than
+// a single core decoder call for the whole range.
+func (r *Reader) readsByFrame() bool {
+	_, nop := r.cfg.collector.(nopCollector)
+	return r.ptr == nil || r.cfg.cache != nil || len(r.pinned) > 0 || r.cfg.sizeChecks || r.cfg.verifier != nil || r.cfg.decodeTimeout > 0 || !nop
+}
+
+// pinFrames decodes the first k frames and keeps them resident for the
+// lifetime of the Reader.
+func (r *Reader) pinFrames(k int) error {
+	frames := r.view.Load().table.frames
+	k = min(k, len(frames))
+	if k <= 0 {
+		return nil
+	}
+
+	pinned := make([][]byte, k)
+	for i := range pinned {
+		if frames[i].decompSize == 0 {
+			pinned[i] = []byte{}
+			continue
+		}
+		data, err := r.frame(context.Background(), i)
+		if err != nil {
+			return fmt.Errorf("pinning frames: %w", err)
+		}
+		pinned[i] = data
+	}
+	r.pinned = pinned
+	return nil
+}
+
+// frame returns the decoded content of frame i, consulting the pinned frames
+// and the frame cache. The returned slice may be shared and must not be
+// modified. ctx bounds the retries of WithFrameRetry.
+func (r *Reader) frame(ctx context.Context, i int) ([]byte, error) {
+	v := r.view.Load()
+	if i < len(r.pinned) {
+		return r.pinned[i], nil
+	}
+
+	cache := r.cfg.cache
+	key := frameKey{archive: r.id, index: r.frameBase + i}
+	if cache != nil {
+		if data, ok := cache.get(key); ok {
+			r.cfg.collector.OnCacheHit(uint64(i))
+			return data, nil
+		}
+		r.cfg.collector.OnCacheMiss(uint64(i))
+	}
+
+	if err := r.chargeDecode(v.table.frames[i].decompSize); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var err error
+	release := acquireDecode()
+	started := time.Now()
+	if d := r.cfg.decodeTimeout; d > 0 {
+		data, err = r.decodeWithin(ctx, i, d, release)
+	} else {
+		data, err = r.decode(ctx, i)
+		release()
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.cfg.collector.OnFrameDecoded(uint64(i), len(data), time.Since(started))
+
+	if verify := r.cfg.verifier; verify != nil {
+		if err := verify(uint64(i), data); err != nil {
+			if l := r.cfg.logger; l != nil {
+				l.LogAttrs(context.Background(), slog.LevelDebug, "seekable: frame verification failed",
+					slog.Int("frame", i),
+					slog.String("error", err.Error()))
+			}
+			return nil, &FrameError{Frame: i, Err: err}
+		}
+	}
+
+	if l := r.cfg.logger; l != nil {
+		f := v.table.frames[i]
+		l.LogAttrs(context.Background(), slog.LevelDebug, "seekable: decoded frame",
+			slog.Int("frame", i),
+			slog.Uint64("compressed_size", f.compSize),
+			slog.Int("size", len(data)))
+	}
+
+	if cache != nil {
+		evicted, evictedBytes := cache.put(key, data)
+		if l := r.cfg.logger; l != nil && evicted > 0 {
+			l.LogAttrs(context.Background(), slog.LevelDebug, "seekable: frame cache eviction",
+				slog.Int("frame", i),
+				slog.Int("evicted_frames", evicted),
+				slog.Uint64("evicted_bytes", evictedBytes))
+		}
+	}
+	return data, nil
+}
+
+// decode decodes frame i with the core decoder or, if the Reader has none,
+// checks frame sizes or has had a core decode time out, in Go.
+func (r *Reader) decode(ctx context.Context, i int) ([]byte, error) {
+	if r.decodesInCore() {
+		return r.coreFrame(i)
+	}
+	return r.decodeFrame(ctx, i)
+}
+
+// decodesInCore reports whether decode uses the core decoder.
+func (r *Reader) decodesInCore() bool {
+	return r.ptr != nil && !r.cfg.sizeChecks && !r.h.coreStalled.Load()
+}
+
+// decodeWithin decodes frame i like decode, but gives up once d has passed.
+// The decode runs on its own goroutine against a snapshot of the Reader, so
+// an abandoned decode can finish in the background without racing a Close
+// or Refresh. It holds a reference to the shared handles until it finishes,
+// so the decoder outlives a Close, and calls release when done.
+//
+// An abandoned core decode keeps the core decoder locked, so a timeout there
+// switches the Reader, and the sub-readers sharing its decoder, to decoding
+// in Go rather than queueing every later frame behind it.
+func (r *Reader) decodeWithin(ctx context.Context, i int, d time.Duration, release func()) ([]byte, error) {
+	snap := &Reader{ptr: r.ptr, cfg: r.cfg, src: r.src, h: r.h, base: r.base}
+	snap.view.Store(r.view.Load())
+	snap.h.acquire()
+	core := snap.decodesInCore()
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer snap.h.release()
+		defer release()
+		data, err := snap.decode(ctx, i)
+		done <- result{data, err}
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.data, res.err
+	case <-timer.C:
+		if core && !r.h.coreStalled.Swap(true) {
+			if l := r.cfg.logger; l != nil {
+				l.LogAttrs(context.Background(), slog.LevelDebug, "seekable: core decode timed out, decoding in Go",
+					slog.Int("frame", i),
+					slog.Duration("timeout", d))
+			}
+		}
+		return nil, fmt.Errorf("seekable: decoding frame %d timed out after %v: %w", i, d, os.ErrDeadlineExceeded)
+	}
+}
+
+// coreFrame decodes frame i with the core decoder.
+func (r *Reader) coreFrame(i int) ([]byte, error) {
+	f := r.view.Load().table.frames[i]
+	out := make([]byte, f.decompSize)
+	cLen := C.uintptr_t(len(out))
+
+	start := r.base + f.decompOffset
+	r.h.decode.Lock()
+	res := C.seekable_read_range(
+		r.ptr,
+		C.uint64_t(start),
+		C.uint64_t(start+f.decompSize),
+		(*C.uint8_t)(unsafe.Pointer(&out[0])),
+		&cLen,
+	)
+	r.h.decode.Unlock()
+	if res < 0 {
+		errStr := C.seekable_last_error()
+		if errStr == nil {
+			return nil, fmt.Errorf("decoding frame %d: unknown error", i)
+		}
+		return nil, fmt.Errorf("decoding frame %d: %s", i, C.GoString(errStr))
+	}
+
+	return out[:cLen], nil
+}
+
+// decodeFrame reads frame i from the compressed source and decodes it in Go.
+func (r *Reader) decodeFrame(ctx context.Context, i int) ([]byte, error) {
+	comp, err := r.readCompressed(ctx, i, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := r.getDCtx()
+	if err != nil {
+		return nil, err
+	}
+	defer r.putDCtx(d)
+
+	out := make([]byte, r.view.Load().table.frames[i].decompSize)
+	if err := r.decodeInto(d, i, comp, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// getDCtx returns a decompression context with the configured decode
+// parameters, reusing an idle one when possible. Return it with putDCtx.
+func (r *Reader) getDCtx() (*dctx, error) {
+	if h := r.h; h != nil {
+		return h.dctxs.get(r.cfg.newDCtx)
+	}
+	return r.cfg.newDCtx()
+}
+
+// putDCtx makes a context obtained from getDCtx available for reuse.
+func (r *Reader) putDCtx(d *dctx) {
+	if h := r.h; h != nil {
+		h.dctxs.put(d)
+		return
+	}
+	d.free()
+}
+
+// readCompressed reads the compressed bytes of frame i into buf, which is
+// reallocated if it is too small, and returns them.
+func (r *Reader) readCompressed(ctx context.Context, i int, buf []byte) ([]byte, error) {
+	if r.src == nil {
+		return nil, ErrClosed
+	}
+
+	f := r.view.Load().table.frames[i]
+	if uint64(cap(buf)) < f.compSize {
+		buf = make([]byte, f.compSize)
+	}
+	comp, err := r.readFrameBytes(ctx, buf[:f.compSize], int64(f.compOffset))
+	if err != nil {
+		return nil, fmt.Errorf("reading frame %d: %w", i, err)
+	}
+	return comp, nil
+}
+
+// decodeInto decodes the compressed frame i in comp with d. out must be
+// exactly the frame's decompressed size.
+func (r *Reader) decodeInto(d *dctx, i int, comp, out []byte) error {
+	if err := r.checkWindow(comp); err != nil {
+		return fmt.Errorf("decoding frame %d: %w", i, err)
+	}
+	if err := decodeExact(d, comp, out); err != nil {
+		return fmt.Errorf("decoding frame %d: %w", i, err)
+	}
+	return nil
+}
+
+// decodeExact decodes the frame in comp into out and fails with
+// ErrSizeMismatch unless it fills out exactly. With WithMagiclessFrames every
+// frame is decoded as magicless; otherwise a frame without the magic fails
+// with ErrUnsupportedFrame. Frames that declare a dictionary use it.
+func decodeExact(d *dctx, comp, out []byte) error {
+	if err := d.dicts.apply(d, comp); err != nil {
+		return err
+	}
+
+	var n int
+	var err error
+	switch {
+	case d.magicless:
+		n, err = decompressMagicless(d, out, comp)
+	case magicless(comp):
+		return fmt.Errorf("%w: frame has no zstd magic; WithMagiclessFrames decodes such frames", ErrUnsupportedFrame)
+	default:
+		n, err = d.decompress(out, comp)
+	}
+	if errors.Is(err, errFrameOverflow) {
+		return fmt.Errorf("%w: got more than %d bytes, seek table says %d", ErrSizeMismatch, len(out), len(out))
+	}
+	if err != nil {
+		return err
+	}
+	if n != len(out) {
+		return fmt.Errorf("%w: got %d bytes, seek table says %d", ErrSizeMismatch, n, len(out))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// maxRawBlockSize is the largest block a zstd frame may carry.
+const maxRawBlockSize = 128 * 1024
+
+// rawFrame encodes data as a zstd frame made of raw (stored) blocks. The frame
+// header declares neither a content size nor a checksum, matching what
+// streaming producers emit.
+func rawFrame(data []byte) []byte {
+	// Magic, frame header descriptor (no content size), window descriptor (2 MiB).
+	frame := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00, 0x58}
+
+	for {
+		n := min(len(data), maxRawBlockSize)
+		last := n == len(data)
+
+		header := uint32(n) << 3 // block type 0 (raw)
+		if last {
+			header |= 1
+		}
+		frame = append(frame, byte(header), byte(header>>8), byte(header>>16))
+		frame = append(frame, data[:n]...)
+		data = data[n:]
+
+		if last {
+			return frame
+		}
+	}
+}
+
+// seekTableFrame encodes a seek table for frames of the given sizes.
+func seekTableFrame(compSizes, decompSizes []uint32) []byte {
+	entries := make([]byte, 0, len(compSizes)*8)
+	for i := range compSizes {
+		entries = binary.LittleEndian.AppendUint32(entries, compSizes[i])
+		entries = binary.LittleEndian.AppendUint32(entries, decompSizes[i])
+	}
+
+	table := binary.LittleEndian.AppendUint32(nil, skippableMagicSeekTable)
+	table = binary.LittleEndian.AppendUint32(table, uint32(len(entries)+seekTableFooterSize))
+	table = append(table, entries...)
+	table = binary.LittleEndian.AppendUint32(table, uint32(len(compSizes)))
+	table = append(table, 0) // descriptor: no checksums
+	return binary.LittleEndian.AppendUint32(table, seekableMagic)
+}
+
+// buildArchive assembles a seekable archive with one raw frame per chunk.
+func buildArchive(chunks ...string) []byte {
+	var archive []byte
+	var compSizes, decompSizes []uint32
+	for _, chunk := range chunks {
+		frame := rawFrame([]byte(chunk))
+		archive = append(archive, frame...)
+		compSizes = append(compSizes, uint32(len(frame)))
+		decompSizes = append(decompSizes, uint32(len(chunk)))
+	}
+	return append(archive, seekTableFrame(compSizes, decompSizes)...)
+}
+
+// writeArchive writes data to a temporary file and returns its path.
+func writeArchive(t testing.TB, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "archive.szst")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	return path
+}
+
+// openArchive opens an archive with one raw frame per chunk.
+func openArchive(t testing.TB, chunks []string, opts ...Option) *Reader {
+	t.Helper()
+	return openArchivePath(t, writeArchive(t, buildArchive(chunks...)), opts...)
+}
+
+// openArchivePath opens the archive at path and closes it when the test ends.
+func openArchivePath(t testing.TB, path string, opts ...Option) *Reader {
+	t.Helper()
+	r, err := Open(path, opts...)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
@@ -0,0 +1,119 @@
+package seekable
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RangeHandler returns an http.Handler that serves the decompressed content of
+// archives, with the archive named by the request path without its leading
+// slash. get resolves the name to an open Reader; an error wrapping
+// fs.ErrNotExist is answered with 404 Not Found and any other error with 500.
+// The handler never closes the Readers get returns, so get may hand out
+// shared Readers, for example from a map of archives opened at startup.
+//
+// Responses go through http.ServeContent, which provides single and multipart
+// Range requests, Content-Length, HEAD, and conditional requests. The ETag
+// identifies the archive: it is the stored content hash when the archive has
+// one, and otherwise derived from the archive identity used for frame caching
+// (see WithCacheKey). File-backed archives also send Last-Modified. Content is
+// decoded through a RangeReader, so memory use is bounded by the largest frame
+// regardless of the size of the ranges requested. Only GET and HEAD are
+// allowed.
+func RangeHandler(get func(name string) (*Reader, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := strings.TrimPrefix(req.URL.Path, "/")
+		r, err := get(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			http.NotFound(w, req)
+			return
+		}
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		// Refresh may update the modification time, so it is read through Stat.
+		var modTime time.Time
+		if info, err := r.Stat(); err == nil {
+			modTime = info.ModTime
+		}
+		w.Header().Set("ETag", r.etag())
+		http.ServeContent(w, req, name, modTime, &contentSeeker{r: r})
+	})
+}
+
+// etag returns a strong entity tag for the decompressed content.
+func (r *Reader) etag() string {
+	if hash, err := r.ContentHash(); err == nil {
+		return `"` + hex.EncodeToString(hash) + `"`
+	}
+	// Sub-readers share the identity of their archive, so the window is part
+	// of the tag.
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d/%d", r.id, r.base, r.view.Load().size)))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// contentSeeker is the io.ReadSeeker handed to http.ServeContent. It has its
+// own position, so concurrent requests can share a Reader, and reads through a
+// RangeReader, so each frame is decoded once however small the reads are.
+type contentSeeker struct {
+	r   *Reader
+	off int64
+	rr  io.ReadCloser
+}
+
+func (s *contentSeeker) Read(p []byte) (int, error) {
+	if s.rr == nil {
+		size := s.r.Size()
+		if uint64(s.off) >= size {
+			return 0, io.EOF
+		}
+		rr, err := s.r.RangeReader(uint64(s.off), size)
+		if err != nil {
+			return 0, err
+		}
+		s.rr = rr
+	}
+
+	n, err := s.rr.Read(p)
+	s.off += int64(n)
+	return n, err
+}
+
+func (s *contentSeeker) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = s.off + offset
+	case io.SeekEnd:
+		abs = int64(s.r.Size()) + offset
+	default:
+		return 0, errors.New("seekable: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("seekable: negative position")
+	}
+
+	if abs != s.off && s.rr != nil {
+		s.rr.Close()
+		s.rr = nil
+	}
+	s.off = abs
+	return abs, nil
+}
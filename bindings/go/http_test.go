@@ -0,0 +1,186 @@
+package seekable
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newRangeServer(t *testing.T) (*httptest.Server, *Reader) {
+	t.Helper()
+	r := openArchive(t, testChunks)
+	srv := httptest.NewServer(RangeHandler(func(name string) (*Reader, error) {
+		switch name {
+		case "data.txt":
+			return r, nil
+		case "broken":
+			return nil, errors.New("backend failure")
+		}
+		return nil, fmt.Errorf("archive %q: %w", name, fs.ErrNotExist)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, r
+}
+
+func doRequest(t *testing.T, method, url string, header map[string]string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	return resp, string(body)
+}
+
+func TestRangeHandler(t *testing.T) {
+	srv, _ := newRangeServer(t)
+	url := srv.URL + "/data.txt"
+
+	resp, body := doRequest(t, http.MethodGet, url, nil)
+	if resp.StatusCode != http.StatusOK || body != testContent {
+		t.Fatalf("GET = %d %q", resp.StatusCode, body)
+	}
+	if resp.ContentLength != int64(len(testContent)) {
+		t.Errorf("Content-Length = %d", resp.ContentLength)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+
+	resp, body = doRequest(t, http.MethodHead, url, nil)
+	if resp.StatusCode != http.StatusOK || body != "" || resp.ContentLength != int64(len(testContent)) {
+		t.Errorf("HEAD = %d %q, Content-Length %d", resp.StatusCode, body, resp.ContentLength)
+	}
+
+	// A range spanning three frames.
+	resp, body = doRequest(t, http.MethodGet, url, map[string]string{"Range": "bytes=3-14"})
+	if resp.StatusCode != http.StatusPartialContent || body != testContent[3:15] {
+		t.Errorf("single range = %d %q", resp.StatusCode, body)
+	}
+	if cr := resp.Header.Get("Content-Range"); cr != fmt.Sprintf("bytes 3-14/%d", len(testContent)) {
+		t.Errorf("Content-Range = %q", cr)
+	}
+
+	resp, body = doRequest(t, http.MethodGet, url, map[string]string{"Range": "bytes=-5"})
+	if resp.StatusCode != http.StatusPartialContent || body != "delta" {
+		t.Errorf("suffix range = %d %q", resp.StatusCode, body)
+	}
+
+	resp, _ = doRequest(t, http.MethodGet, url, map[string]string{"Range": "bytes=100-200"})
+	if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("unsatisfiable range = %d", resp.StatusCode)
+	}
+
+	// If-Range with the current ETag honours the range; a stale one does not.
+	resp, body = doRequest(t, http.MethodGet, url, map[string]string{"Range": "bytes=0-4", "If-Range": etag})
+	if resp.StatusCode != http.StatusPartialContent || body != "alpha" {
+		t.Errorf("If-Range match = %d %q", resp.StatusCode, body)
+	}
+	resp, body = doRequest(t, http.MethodGet, url, map[string]string{"Range": "bytes=0-4", "If-Range": `"stale"`})
+	if resp.StatusCode != http.StatusOK || body != testContent {
+		t.Errorf("If-Range mismatch = %d %q", resp.StatusCode, body)
+	}
+
+	resp, _ = doRequest(t, http.MethodGet, url, map[string]string{"If-None-Match": etag})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("If-None-Match = %d", resp.StatusCode)
+	}
+}
+
+func TestRangeHandlerMultipart(t *testing.T) {
+	srv, _ := newRangeServer(t)
+	resp, body := doRequest(t, http.MethodGet, srv.URL+"/data.txt", map[string]string{"Range": "bytes=0-4,12-18"})
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Content-Type = %q (%v)", resp.Header.Get("Content-Type"), err)
+	}
+	mr := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	var parts []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart failed: %v", err)
+		}
+		data, _ := io.ReadAll(part)
+		parts = append(parts, part.Header.Get("Content-Range")+" "+string(data))
+	}
+
+	size := len(testContent)
+	want := []string{fmt.Sprintf("bytes 0-4/%d alpha", size), fmt.Sprintf("bytes 12-18/%d charlie", size)}
+	if strings.Join(parts, "|") != strings.Join(want, "|") {
+		t.Errorf("parts = %q, want %q", parts, want)
+	}
+}
+
+func TestRangeHandlerErrors(t *testing.T) {
+	srv, _ := newRangeServer(t)
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/missing", http.StatusNotFound},
+		{http.MethodGet, "/broken", http.StatusInternalServerError},
+		{http.MethodPost, "/data.txt", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if resp, _ := doRequest(t, tt.method, srv.URL+tt.path, nil); resp.StatusCode != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestRangeHandlerETag(t *testing.T) {
+	r := openArchive(t, testChunks)
+	sub, err := r.SubReader(1, 3)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+	if r.etag() == sub.etag() {
+		t.Error("sub-reader has the same ETag as its archive")
+	}
+
+	hashed := writeTestArchive(t, []byte(testContent), WithContentHash())
+	ra, err := OpenBytes(hashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ra.Close()
+	rb, err := OpenBytes(hashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rb.Close()
+	if ra.etag() != rb.etag() {
+		t.Error("Readers of an archive with a content hash have different ETags")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package seekable
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fileIdentity identifies a file by absolute path, size and modification time,
+// so a replaced or rewritten archive gets a new identity.
+func fileIdentity(path string, info os.FileInfo) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return fmt.Sprintf("path:%s:%d:%d", path, info.Size(), info.ModTime().UnixNano())
+}
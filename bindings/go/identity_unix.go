@@ -0,0 +1,18 @@
+//go:build unix
+
+package seekable
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileIdentity identifies a file by device, inode, size and modification
+// time, so a replaced or rewritten archive gets a new identity.
+func fileIdentity(path string, info os.FileInfo) string {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fmt.Sprintf("file:%d:%d:%d:%d", st.Dev, st.Ino, info.Size(), info.ModTime().UnixNano())
+	}
+	return fmt.Sprintf("path:%s:%d:%d", path, info.Size(), info.ModTime().UnixNano())
+}
@@ -65,6 +65,15 @@ int32_t seekable_read_ranges(const struct SeekableDecoder *decoder,
                              uint8_t **out_buffers,
                              uintptr_t *out_lengths);
 
+/**
+ * Sets an advanced decompression parameter (`ZSTD_dParameter`) on a zstd
+ * decompression context.
+ *
+ * # Safety
+ * `dctx` must be a valid `ZSTD_DCtx` pointer.
+ */
+int32_t seekable_dctx_set_parameter(void *dctx, int param, int value);
+
 /**
  * Closes the decoder and frees resources.
  *
@@ -0,0 +1,121 @@
+package seekable
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zstdCLI returns the path of the reference zstd command line tool, skipping
+// the test if it is not installed.
+func zstdCLI(t *testing.T) string {
+	t.Helper()
+	path, err := exec.LookPath("zstd")
+	if err != nil {
+		t.Skip("zstd CLI not installed")
+	}
+	return path
+}
+
+// interopContent returns content that compresses unevenly across frames.
+func interopContent() []byte {
+	var b strings.Builder
+	for i := 0; i < 5000; i++ {
+		b.WriteString("line ")
+		b.WriteString(strings.Repeat("x", i%37))
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+func TestInteropCLIDecodesWriterOutput(t *testing.T) {
+	cli := zstdCLI(t)
+	content := interopContent()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithFrameSize(16*1024), WithContentHash())
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.AddEntry("all", 0, w.Offset()); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// The CLI decodes every frame, verifying its checksum, and skips the
+	// metadata frames and the seek table.
+	cmd := exec.Command(cli, "-d", "-c", "-q")
+	cmd.Stdin = &buf
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("zstd -d failed: %v: %s", err, stderr.Bytes())
+	}
+	if !bytes.Equal(out, content) {
+		t.Errorf("zstd -d produced %d bytes, want %d", len(out), len(content))
+	}
+}
+
+func TestInteropReaderDecodesCLIFrames(t *testing.T) {
+	cli := zstdCLI(t)
+	content := interopContent()
+	dir := t.TempDir()
+
+	// The reference CLI has no seekable mode, so each chunk is compressed as
+	// its own CLI frame and the seek table is appended here.
+	const chunkSize = 10000
+	var archive []byte
+	var compSizes, decompSizes []uint32
+	for start := 0; start < len(content); start += chunkSize {
+		chunk := content[start:min(start+chunkSize, len(content))]
+		in := filepath.Join(dir, "chunk")
+		if err := os.WriteFile(in, chunk, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		frame, err := exec.Command(cli, "-c", "-q", "-19", "--check", in).Output()
+		if err != nil {
+			t.Fatalf("zstd failed: %v", err)
+		}
+		archive = append(archive, frame...)
+		compSizes = append(compSizes, uint32(len(frame)))
+		decompSizes = append(decompSizes, uint32(len(chunk)))
+	}
+	archive = append(archive, seekTableFrame(compSizes, decompSizes)...)
+
+	for name, opts := range map[string][]Option{
+		"core":  nil,
+		"go":    {WithSizeChecks()},
+		"cache": {WithFrameCache(NewFrameCache(1 << 20))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := openArchivePath(t, writeArchive(t, archive), opts...)
+			all, err := r.DecompressAll()
+			if err != nil {
+				t.Fatalf("DecompressAll failed: %v", err)
+			}
+			if !bytes.Equal(all, content) {
+				t.Error("DecompressAll content does not match")
+			}
+
+			got, err := r.ReadRange(chunkSize-100, 2*chunkSize+100)
+			if err != nil {
+				t.Fatalf("ReadRange failed: %v", err)
+			}
+			if !bytes.Equal(got, content[chunkSize-100:2*chunkSize+100]) {
+				t.Error("ReadRange content does not match")
+			}
+			if err := r.DeepValidate(); err != nil {
+				t.Errorf("DeepValidate failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package seekable
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// lazyFile reads the archive file of a Reader opened with WithLazyFD. It
+// opens the file on demand and closes it once no read has used it for idle.
+type lazyFile struct {
+	path   string
+	open   func(string) (*os.File, error)
+	advice []Advice
+	idle   time.Duration
+	logger *slog.Logger
+
+	mu sync.Mutex
+	// f is the open file, or nil while it is closed.
+	f *os.File
+	// info is the file's state when the archive was opened or last
+	// refreshed; a reopened file must still match it.
+	info os.FileInfo
+	// reads counts the reads in progress, which keep f open.
+	reads int
+	// lastUse is when the last read ended. The idle timer, while pending,
+	// closes f once it has been unused for idle.
+	lastUse time.Time
+	timer   *time.Timer
+	pending bool
+	closed  bool
+}
+
+// newLazyFile returns a lazyFile for path, which was opened as f with the
+// given info, and starts the idle timer for f. Reopens and idle closes are
+// logged to logger, if it is not nil.
+func newLazyFile(path string, f *os.File, info os.FileInfo, open func(string) (*os.File, error), advice []Advice, idle time.Duration, logger *slog.Logger) *lazyFile {
+	l := &lazyFile{path: path, open: open, advice: advice, idle: idle, logger: logger, f: f, info: info, lastUse: time.Now()}
+	l.mu.Lock()
+	l.scheduleClose()
+	l.mu.Unlock()
+	return l
+}
+
+// ReadAt implements io.ReaderAt, opening the file if it is closed.
+func (l *lazyFile) ReadAt(p []byte, off int64) (int, error) {
+	f, err := l.acquire()
+	if err != nil {
+		return 0, err
+	}
+	defer l.releaseRead()
+	return f.ReadAt(p, off)
+}
+
+// acquire returns the open file, opening it if needed, and counts a read in
+// progress. Reads arriving while the file is opened wait for that open.
+func (l *lazyFile) acquire() (*os.File, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, ErrClosed
+	}
+	if l.f == nil {
+		f, err := l.reopen()
+		if err != nil {
+			l.log("seekable: reopening archive file failed", slog.String("error", err.Error()))
+			return nil, err
+		}
+		l.f = f
+		l.log("seekable: reopened archive file")
+	}
+	l.reads++
+	return l.f, nil
+}
+
+// reopen opens the file again, checking that it is the file the archive was
+// opened from.
+func (l *lazyFile) reopen() (*os.File, error) {
+	f, err := l.open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Size() != l.info.Size() || !info.ModTime().Equal(l.info.ModTime()) {
+		f.Close()
+		return nil, fmt.Errorf("%w: file size or modification time differs", ErrSourceChanged)
+	}
+	for _, advice := range l.advice {
+		_ = fadvise(f, advice)
+	}
+	return f, nil
+}
+
+// releaseRead ends a read. The last read to end starts the idle timer, or
+// closes the file if the lazyFile was closed meanwhile.
+func (l *lazyFile) releaseRead() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reads--
+	l.lastUse = time.Now()
+	if l.reads > 0 {
+		return
+	}
+	if l.closed {
+		l.f.Close()
+		l.f = nil
+		return
+	}
+	l.scheduleClose()
+}
+
+// scheduleClose starts the idle timer unless it is already pending. l.mu
+// must be held.
+func (l *lazyFile) scheduleClose() {
+	if l.pending {
+		return
+	}
+	l.pending = true
+	if l.timer == nil {
+		l.timer = time.AfterFunc(l.idle, l.closeIdle)
+	} else {
+		l.timer.Reset(l.idle)
+	}
+}
+
+// closeIdle closes the file if no read has used it for idle, and otherwise
+// waits for the rest of the idle period.
+func (l *lazyFile) closeIdle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = false
+	if l.closed || l.f == nil || l.reads > 0 {
+		return
+	}
+	if wait := l.idle - time.Since(l.lastUse); wait > 0 {
+		l.pending = true
+		l.timer.Reset(wait)
+		return
+	}
+	l.f.Close()
+	l.f = nil
+	l.log("seekable: closed idle archive file", slog.Duration("idle", l.idle))
+}
+
+// log logs msg at debug level with the file's path, if l has a logger.
+func (l *lazyFile) log(msg string, attrs ...slog.Attr) {
+	if l.logger == nil {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, append([]slog.Attr{slog.String("path", l.path)}, attrs...)...)
+}
+
+// stat returns the state of the file reads currently go to: the open file
+// if there is one, since a rename may have put another file at path, and
+// otherwise the file a read would reopen.
+func (l *lazyFile) stat() (os.FileInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f != nil {
+		return l.f.Stat()
+	}
+	return os.Stat(l.path)
+}
+
+// expect makes info the state a reopened file must match, returning the
+// previous one.
+func (l *lazyFile) expect(info os.FileInfo) os.FileInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev := l.info
+	l.info = info
+	return prev
+}
+
+// Close closes the file if it is open. Reads in progress keep their file
+// until they finish; later reads fail with ErrClosed.
+func (l *lazyFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	if l.f == nil || l.reads > 0 {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
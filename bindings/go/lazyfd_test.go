@@ -0,0 +1,165 @@
+package seekable
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fileOpen reports whether the lazily opened file of r holds a descriptor.
+func fileOpen(r *Reader) bool {
+	l := r.h.lazy
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f != nil
+}
+
+// waitClosed waits for the idle timer of r to close its file.
+func waitClosed(t *testing.T, r *Reader) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for fileOpen(r) {
+		if time.Now().After(deadline) {
+			t.Fatal("file still open after the idle period")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWithLazyFD(t *testing.T) {
+	path := writeArchive(t, buildArchive(testChunks...))
+	r := openArchivePath(t, path, WithLazyFD(50*time.Millisecond))
+	if r.h.lazy == nil || r.h.file != nil {
+		t.Fatal("Reader does not open its file lazily")
+	}
+
+	waitClosed(t, r)
+	if got, err := r.ReadRange(6, 12); err != nil || string(got) != "bravo-" {
+		t.Fatalf("ReadRange after the file closed = (%q, %v)", got, err)
+	}
+	if !fileOpen(r) {
+		t.Error("file not reopened by the read")
+	}
+	if _, err := r.SizeErr(); err != nil {
+		t.Errorf("SizeErr failed: %v", err)
+	}
+
+	// Reads keep the file open until they stop for the idle period.
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if _, err := r.ReadRange(0, 5); err != nil {
+			t.Fatalf("ReadRange failed: %v", err)
+		}
+		if !fileOpen(r) {
+			t.Fatal("file closed between reads less than the idle period apart")
+		}
+	}
+	waitClosed(t, r)
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := r.ReadRange(0, 5); !errors.Is(err, ErrClosed) {
+		t.Errorf("ReadRange after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestWithLazyFDConcurrentReads(t *testing.T) {
+	chunks := make([]string, 32)
+	for i := range chunks {
+		chunks[i] = fmt.Sprintf("frame %02d;", i)
+	}
+	r := openArchivePath(t, writeArchive(t, buildArchive(chunks...)), WithLazyFD(time.Microsecond))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				k := (g*7 + i) % len(chunks)
+				got, err := r.ReadRange(uint64(k*9), uint64(k*9+9))
+				if err != nil || string(got) != chunks[k] {
+					t.Errorf("ReadRange of frame %d = (%q, %v)", k, got, err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestWithLazyFDChangedFile(t *testing.T) {
+	path := writeArchive(t, buildArchive(testChunks...))
+	r := openArchivePath(t, path, WithLazyFD(time.Millisecond))
+	waitClosed(t, r)
+
+	if err := os.WriteFile(path, buildArchive(testChunks[:2]...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadRange(0, 5); !errors.Is(err, ErrSourceChanged) {
+		t.Errorf("ReadRange of a replaced file = %v, want ErrSourceChanged", err)
+	}
+	if err := r.Refresh(); !errors.Is(err, ErrSourceChanged) {
+		t.Errorf("Refresh of a shrunk archive = %v, want ErrSourceChanged", err)
+	}
+	// A failed Refresh leaves the old file state expected.
+	waitClosed(t, r)
+	if _, err := r.ReadRange(0, 5); !errors.Is(err, ErrSourceChanged) {
+		t.Errorf("ReadRange after a failed Refresh = %v, want ErrSourceChanged", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadRange(0, 5); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadRange of a removed file = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestWithLazyFDRenamedFile(t *testing.T) {
+	path := writeArchive(t, buildArchive(testChunks...))
+	r := openArchivePath(t, path, WithLazyFD(time.Hour))
+
+	// The open descriptor still refers to the original file, so Refresh must
+	// look at that file rather than the one now at path.
+	next := path + ".next"
+	if err := os.WriteFile(next, buildArchive(append(slices.Clone(testChunks), "-echo")...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(next, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Refresh(); err != nil {
+		t.Fatalf("Refresh after a rename failed: %v", err)
+	}
+	if r.FrameCount() != 4 {
+		t.Errorf("FrameCount() = %d, want 4", r.FrameCount())
+	}
+	if got, err := r.ReadRange(0, r.Size()); err != nil || string(got) != testContent {
+		t.Errorf("ReadRange = (%q, %v)", got, err)
+	}
+}
+
+func TestWithLazyFDLogging(t *testing.T) {
+	var buf lockedBuffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	path := writeArchive(t, buildArchive(testChunks...))
+	r := openArchivePath(t, path, WithLazyFD(time.Millisecond), WithLogger(logger))
+
+	waitClosed(t, r)
+	if _, err := r.ReadRange(0, 5); err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	for _, msg := range []string{"closed idle archive file", "reopened archive file"} {
+		if !strings.Contains(buf.String(), msg) {
+			t.Errorf("log has no %q event:\n%s", msg, buf.String())
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// OpenLegacy opens an archive in the legacy leading-table layout, in which
+// the seek table is the first frame of the file and the data frames follow
+// it. The table itself has the same format as in the current layout, which
+// stores it as the last frame; Open reads only that layout. Anything after
+// the last data frame is trailing data, as reported by TrailingBytes.
+//
+// Legacy archives carry no metadata frames, so they have no content hash or
+// entry index. The core decoder reads only the current layout, so frames are
+// decoded in Go. The Reader otherwise behaves exactly like one from Open.
+func OpenLegacy(path string, opts ...Option) (*Reader, error) {
+	return openFileWith(path, newConfig(opts), true)
+}
+
+// tableFinder returns the function that locates the seek table of archives
+// in the legacy leading-table layout if leading is set, and in the current
+// layout otherwise.
+func tableFinder(leading bool) func(io.ReaderAt, int64) (*seekTable, error) {
+	if leading {
+		return readLeadingSeekTable
+	}
+	return findSeekTable
+}
+
+// readLeadingSeekTable parses the seek table of a legacy archive, which is
+// stored as a skippable frame at offset 0 ahead of the data frames.
+func readLeadingSeekTable(ra io.ReaderAt, size int64) (*seekTable, error) {
+	if size < skippableHeaderSize+seekTableFooterSize {
+		return nil, errInvalidTable("archive too small (%d bytes)", size)
+	}
+
+	var header [skippableHeaderSize]byte
+	if err := readFullAt(ra, header[:], 0); err != nil {
+		return nil, fmt.Errorf("reading seek table header: %w", err)
+	}
+	if magic := binary.LittleEndian.Uint32(header[0:]); magic != skippableMagicSeekTable {
+		return nil, errInvalidTable("no leading seek table (magic %#x)", magic)
+	}
+
+	tableEnd := skippableHeaderSize + int64(binary.LittleEndian.Uint32(header[4:]))
+	if tableEnd > size {
+		return nil, errInvalidTable("%d-byte table does not fit in %d bytes", tableEnd, size)
+	}
+
+	st, err := parseSeekTable(ra, tableEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range st.frames {
+		st.frames[i].compOffset += uint64(tableEnd)
+	}
+	dataEnd := tableEnd
+	if len(st.frames) > 0 {
+		dataEnd = st.dataEnd()
+	}
+	if dataEnd > size {
+		return nil, errInvalidTable("frames span %d bytes, but archive is %d bytes", dataEnd, size)
+	}
+
+	st.start, st.end = dataEnd, dataEnd
+	st.leading = true
+	return st, nil
+}
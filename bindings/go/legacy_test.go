@@ -0,0 +1,89 @@
+package seekable
+
+import (
+	"bytes"
+	"testing"
+)
+
+// buildLegacyArchive assembles an archive in the leading-table layout, with
+// one raw frame per chunk after the seek table.
+func buildLegacyArchive(chunks ...string) []byte {
+	var frames []byte
+	var compSizes, decompSizes []uint32
+	for _, chunk := range chunks {
+		frame := rawFrame([]byte(chunk))
+		frames = append(frames, frame...)
+		compSizes = append(compSizes, uint32(len(frame)))
+		decompSizes = append(decompSizes, uint32(len(chunk)))
+	}
+	return append(seekTableFrame(compSizes, decompSizes), frames...)
+}
+
+func TestOpenLegacy(t *testing.T) {
+	chunks := []string{"legacy-", "leading-", "table"}
+	archive := append(buildLegacyArchive(chunks...), "SIG"...)
+	path := writeArchive(t, archive)
+
+	r, err := OpenLegacy(path)
+	if err != nil {
+		t.Fatalf("OpenLegacy failed: %v", err)
+	}
+	defer r.Close()
+
+	if r.FrameCount() != 3 || r.Size() != 20 {
+		t.Errorf("FrameCount, Size = %d, %d, want 3, 20", r.FrameCount(), r.Size())
+	}
+	all, err := r.DecompressAll()
+	if err != nil || string(all) != "legacy-leading-table" {
+		t.Fatalf("DecompressAll = (%q, %v)", all, err)
+	}
+	buf := make([]byte, 6)
+	if n, err := r.ReadAt(buf, 10); err != nil || string(buf[:n]) != "ding-t" {
+		t.Errorf("ReadAt = (%q, %v)", buf[:n], err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+	if err := r.DeepValidate(); err != nil {
+		t.Errorf("DeepValidate failed: %v", err)
+	}
+	if trailing, err := r.TrailingBytes(); err != nil || string(trailing) != "SIG" {
+		t.Errorf("TrailingBytes = (%q, %v)", trailing, err)
+	}
+
+	if r, err := Open(path); err == nil {
+		r.Close()
+		t.Error("Open accepted a leading-table archive")
+	}
+}
+
+func TestOpenLegacyEmpty(t *testing.T) {
+	r, err := OpenLegacy(writeArchive(t, buildLegacyArchive()))
+	if err != nil {
+		t.Fatalf("OpenLegacy failed: %v", err)
+	}
+	defer r.Close()
+
+	if r.Size() != 0 || r.FrameCount() != 0 {
+		t.Errorf("Size, FrameCount = %d, %d, want 0, 0", r.Size(), r.FrameCount())
+	}
+	if trailing, err := r.TrailingBytes(); err != nil || len(trailing) != 0 {
+		t.Errorf("TrailingBytes = (%q, %v)", trailing, err)
+	}
+}
+
+func TestOpenLegacyInvalid(t *testing.T) {
+	full := buildLegacyArchive("alpha", "bravo")
+	tests := map[string][]byte{
+		"current layout": buildArchive("alpha", "bravo"),
+		"truncated":      full[:len(full)-3],
+		"too small":      full[:10],
+		"not seekable":   bytes.Repeat([]byte{0xAA}, 64),
+	}
+	for name, data := range tests {
+		if r, err := OpenLegacy(writeArchive(t, data)); err == nil {
+			r.Close()
+			t.Errorf("%s: OpenLegacy succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrReadTooLarge is returned by a read that asks for more bytes than
+	// Limits.MaxSingleReadBytes allows.
+	ErrReadTooLarge = errors.New("seekable: read exceeds the size limit")
+
+	// ErrDecodeQuotaExceeded is returned by a read that would decode more
+	// bytes than Limits.MaxTotalDecodedBytes allows over the Reader's life.
+	ErrDecodeQuotaExceeded = errors.New("seekable: decode quota exceeded")
+
+	// ErrFrameTooLarge is returned by the open functions when a frame
+	// decodes to more bytes than Limits.MaxFrameDecodedBytes allows.
+	ErrFrameTooLarge = errors.New("seekable: frame exceeds the size limit")
+
+	// ErrWindowTooLarge is returned for a frame whose window exceeds
+	// Limits.MaxWindowLog, or a DecodeParamWindowLogMax set with
+	// WithDecodeParam.
+	ErrWindowTooLarge = errors.New("seekable: frame window exceeds the limit")
+)
+
+// Limits bounds the work and memory an untrusted archive can demand. Each
+// field that is zero leaves the corresponding setting as it is, so the
+// DefaultMaxExpansionRatio check stays in force unless MaxExpansionRatio
+// replaces it.
+//
+// The limits apply at different points. MaxExpansionRatio and
+// MaxFrameDecodedBytes check the seek table when the archive is opened and
+// when Refresh reads a new one, before anything is decoded.
+// MaxSingleReadBytes rejects a read before it allocates or decodes anything.
+// MaxTotalDecodedBytes charges each frame's size before decoding it, so the
+// read that would cross the quota decodes nothing. MaxWindowLog checks each
+// frame's header before decoding it.
+//
+// Reads built on ReadAt, such as CopyRange and streams, read at most a frame
+// at a time, so a MaxSingleReadBytes below MaxFrameDecodedBytes can make
+// them fail on the largest frames. DecompressAll and OpenFullyDecoded read
+// the whole content at once and are subject to MaxSingleReadBytes.
+type Limits struct {
+	// MaxSingleReadBytes caps the bytes a single ReadAt, ReadRange or
+	// ReadAtv call may return, after clamping to Size(). Larger reads fail
+	// with ErrReadTooLarge.
+	MaxSingleReadBytes uint64
+
+	// MaxTotalDecodedBytes caps the decompressed bytes decoded to serve
+	// reads, counted across the Reader and its sub-readers. Frames served
+	// from a FrameCache, pinned frames and fully decoded archives are not
+	// decoded again and are not counted again. Once the quota is spent,
+	// reads fail with ErrDecodeQuotaExceeded.
+	MaxTotalDecodedBytes uint64
+
+	// MaxFrameDecodedBytes caps the decompressed size of any frame. An
+	// archive with a larger frame fails to open with ErrFrameTooLarge.
+	MaxFrameDecodedBytes uint64
+
+	// MaxExpansionRatio replaces the WithMaxExpansionRatio setting; see
+	// there. Archives beyond it fail to open with ErrSuspiciousArchive.
+	MaxExpansionRatio uint64
+
+	// MaxWindowLog caps the window size, 1<<MaxWindowLog bytes, that a
+	// frame header may declare, which bounds the decoder's memory. Frames
+	// with larger windows fail with ErrWindowTooLarge. Unlike
+	// DecodeParamWindowLogMax, which zstd skips for frames it can decode
+	// straight into the output buffer, it applies to every frame. Setting
+	// it decodes frames in Go.
+	MaxWindowLog int
+}
+
+// WithLimits applies l, configuring every safety limit in one place. See
+// Limits for how they interact.
+func WithLimits(l Limits) Option {
+	return func(c *config) {
+		if l.MaxSingleReadBytes > 0 {
+			c.maxReadBytes = l.MaxSingleReadBytes
+		}
+		if l.MaxTotalDecodedBytes > 0 {
+			c.maxDecodedBytes = l.MaxTotalDecodedBytes
+		}
+		if l.MaxFrameDecodedBytes > 0 {
+			c.maxFrameBytes = l.MaxFrameDecodedBytes
+		}
+		if l.MaxExpansionRatio > 0 {
+			c.maxExpansionRatio = l.MaxExpansionRatio
+		}
+		if l.MaxWindowLog > 0 {
+			c.maxWindowLog = l.MaxWindowLog
+		}
+	}
+}
+
+// checkReadSize returns an error wrapping ErrReadTooLarge if a read of n
+// bytes exceeds the configured limit.
+func (r *Reader) checkReadSize(n uint64) error {
+	if limit := r.cfg.maxReadBytes; limit > 0 && n > limit {
+		return fmt.Errorf("%w: %d bytes requested, limit is %d", ErrReadTooLarge, n, limit)
+	}
+	return nil
+}
+
+// chargeDecode counts n bytes about to be decoded against the decode quota,
+// or returns an error wrapping ErrDecodeQuotaExceeded, counting nothing, if
+// they would exceed it.
+func (r *Reader) chargeDecode(n uint64) error {
+	limit := r.cfg.maxDecodedBytes
+	if limit == 0 {
+		return nil
+	}
+	for {
+		used := r.h.decodedBytes.Load()
+		if used+n > limit {
+			return fmt.Errorf("%w: decoding %d more bytes would exceed %d (%d used)", ErrDecodeQuotaExceeded, n, limit, used)
+		}
+		if r.h.decodedBytes.CompareAndSwap(used, used+n) {
+			return nil
+		}
+	}
+}
+
+// checkTable applies the limits that a seek table must meet, when the archive
+// is opened and again when Refresh reads a new table.
+func (r *Reader) checkTable(st *seekTable) error {
+	if err := st.checkExpansion(r.cfg.maxExpansionRatio); err != nil {
+		return err
+	}
+	return st.checkFrameSizes(r.cfg.maxFrameBytes)
+}
+
+// isLimitError reports whether err comes from one of the configured limits
+// rather than from the frame being read.
+func isLimitError(err error) bool {
+	return errors.Is(err, ErrReadTooLarge) || errors.Is(err, ErrDecodeQuotaExceeded) || errors.Is(err, ErrWindowTooLarge)
+}
+
+// checkFrameSizes returns an error wrapping ErrFrameTooLarge if any frame
+// decodes to more than limit bytes. A limit of 0 disables the check.
+func (st *seekTable) checkFrameSizes(limit uint64) error {
+	if limit == 0 || st.maxDecompSize <= limit {
+		return nil
+	}
+	for i, f := range st.frames {
+		if f.decompSize > limit {
+			return fmt.Errorf("%w: frame %d decodes to %d bytes, limit is %d", ErrFrameTooLarge, i, f.decompSize, limit)
+		}
+	}
+	return nil
+}
+
+// checkWindow returns an error wrapping ErrWindowTooLarge if the header of
+// the compressed frame in comp declares a window larger than the configured
+// limit.
+func (r *Reader) checkWindow(comp []byte) error {
+	limit := r.cfg.maxWindowLog
+	if limit <= 0 || limit >= 64 {
+		return nil
+	}
+	if w, ok := frameWindowSize(comp, r.cfg.magicless); ok && w > 1<<limit {
+		return fmt.Errorf("%w: window of %d bytes, limit is %d", ErrWindowTooLarge, w, uint64(1)<<limit)
+	}
+	return nil
+}
+
+// frameWindowSize returns the window size declared by the header of the
+// zstd frame in comp, which is magicless if noMagic is set. Single-segment
+// frames use their content size as the window. The boolean is false for
+// skippable frames and headers too short to tell.
+func frameWindowSize(comp []byte, noMagic bool) (uint64, bool) {
+	var hdr []byte
+	switch {
+	case noMagic:
+		hdr = comp
+	case len(comp) >= 4 && binary.LittleEndian.Uint32(comp) == zstdFrameMagic:
+		hdr = comp[4:]
+	default:
+		return 0, false
+	}
+	if len(hdr) < 2 {
+		return 0, false
+	}
+
+	fhd := hdr[0]
+	if fhd&0x20 == 0 {
+		wd := hdr[1]
+		base := uint64(1) << (10 + wd>>3)
+		return base + base/8*uint64(wd&7), true
+	}
+
+	pos := 1 + []int{0, 1, 2, 4}[fhd&3]
+	switch fhd >> 6 {
+	case 0:
+		if len(hdr) >= pos+1 {
+			return uint64(hdr[pos]), true
+		}
+	case 1:
+		if len(hdr) >= pos+2 {
+			return uint64(binary.LittleEndian.Uint16(hdr[pos:])) + 256, true
+		}
+	case 2:
+		if len(hdr) >= pos+4 {
+			return uint64(binary.LittleEndian.Uint32(hdr[pos:])), true
+		}
+	case 3:
+		if len(hdr) >= pos+8 {
+			return binary.LittleEndian.Uint64(hdr[pos:]), true
+		}
+	}
+	return 0, false
+}
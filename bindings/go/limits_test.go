@@ -0,0 +1,205 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLimitsSingleRead(t *testing.T) {
+	r := openArchive(t, testChunks, WithLimits(Limits{MaxSingleReadBytes: 10}))
+
+	if got, err := r.ReadRange(0, 10); err != nil || string(got) != testContent[:10] {
+		t.Errorf("ReadRange(0, 10) = (%q, %v)", got, err)
+	}
+	if _, err := r.ReadRange(0, 11); !errors.Is(err, ErrReadTooLarge) {
+		t.Errorf("ReadRange(0, 11) = %v, want ErrReadTooLarge", err)
+	}
+
+	// The limit applies after clamping to Size().
+	buf := make([]byte, 20)
+	if n, err := r.ReadAt(buf, 20); n != 5 || string(buf[:n]) != "delta" {
+		t.Errorf("ReadAt(20) = (%q, %v)", buf[:n], err)
+	}
+	if _, err := r.ReadAt(buf, 0); !errors.Is(err, ErrReadTooLarge) {
+		t.Errorf("ReadAt of 20 bytes = %v, want ErrReadTooLarge", err)
+	}
+	if _, err := r.ReadAtv([][]byte{make([]byte, 6), make([]byte, 6)}, 0); !errors.Is(err, ErrReadTooLarge) {
+		t.Errorf("ReadAtv of 12 bytes = %v, want ErrReadTooLarge", err)
+	}
+	if _, err := r.DecompressAll(); !errors.Is(err, ErrReadTooLarge) {
+		t.Errorf("DecompressAll = %v, want ErrReadTooLarge", err)
+	}
+	if _, _, err := r.DecompressBestEffort(); !errors.Is(err, ErrReadTooLarge) {
+		t.Errorf("DecompressBestEffort = %v, want ErrReadTooLarge", err)
+	}
+
+	// Frame-sized reads still work.
+	var out bytes.Buffer
+	if _, err := r.CopyRange(&out, 0, r.Size()); err != nil || out.String() != testContent {
+		t.Errorf("CopyRange = (%q, %v)", out.String(), err)
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := r.CopyRangeToFile(f, 0, r.Size()); err != nil {
+		t.Errorf("CopyRangeToFile failed: %v", err)
+	}
+}
+
+func TestLimitsTotalDecoded(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"core": nil,
+		"go":   {WithSizeChecks()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts := append(opts, WithLimits(Limits{MaxTotalDecodedBytes: 18}))
+			r := openArchive(t, testChunks, opts...)
+
+			// alpha- and bravo- use 12 of the 18 bytes.
+			if got, err := r.ReadRange(0, 12); err != nil || string(got) != "alpha-bravo-" {
+				t.Fatalf("ReadRange(0, 12) = (%q, %v)", got, err)
+			}
+			// charlie- needs 8 more.
+			if _, err := r.ReadRange(12, 14); !errors.Is(err, ErrDecodeQuotaExceeded) {
+				t.Errorf("ReadRange(12, 14) = %v, want ErrDecodeQuotaExceeded", err)
+			}
+
+			// Sub-readers share the quota.
+			sub, err := r.SubReader(0, 1)
+			if err != nil {
+				t.Fatalf("SubReader failed: %v", err)
+			}
+			defer sub.Close()
+			if _, err := sub.ReadRange(0, 6); err != nil {
+				t.Errorf("sub ReadRange of 6 bytes within the quota failed: %v", err)
+			}
+			if _, err := sub.ReadRange(0, 6); !errors.Is(err, ErrDecodeQuotaExceeded) {
+				t.Errorf("sub ReadRange past the quota = %v, want ErrDecodeQuotaExceeded", err)
+			}
+		})
+	}
+}
+
+func TestLimitsBestEffort(t *testing.T) {
+	r := openArchive(t, testChunks, WithLimits(Limits{MaxTotalDecodedBytes: 12}))
+	if _, failed, err := r.DecompressBestEffort(); !errors.Is(err, ErrDecodeQuotaExceeded) {
+		t.Errorf("DecompressBestEffort past the quota = (%v, %v), want ErrDecodeQuotaExceeded", failed, err)
+	}
+}
+
+func TestLimitsRefresh(t *testing.T) {
+	var data []byte
+	src := &growingReaderAt{data: &data}
+	data = buildArchive(testChunks...)
+	r, err := OpenReader(src, src.Size(), WithLimits(Limits{MaxFrameDecodedBytes: 8}))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	data = buildArchive(append(slices.Clone(testChunks), "-echo-foxtrot")...)
+	if err := r.Refresh(); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("Refresh with a frame over the limit = %v, want ErrFrameTooLarge", err)
+	}
+	if r.FrameCount() != 4 {
+		t.Errorf("FrameCount() = %d after a rejected Refresh, want 4", r.FrameCount())
+	}
+
+	frame := rawFrame([]byte("alpha"))
+	data = append(bytes.Clone(frame), seekTableFrame([]uint32{uint32(len(frame))}, []uint32{5})...)
+	r2, err := OpenReader(src, src.Size())
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r2.Close()
+	data = append(append(bytes.Clone(frame), frame...), seekTableFrame(
+		[]uint32{uint32(len(frame)), uint32(len(frame))}, []uint32{5, 1 << 30})...)
+	if err := r2.Refresh(); !errors.Is(err, ErrSuspiciousArchive) {
+		t.Errorf("Refresh to a bomb = %v, want ErrSuspiciousArchive", err)
+	}
+}
+
+func TestLimitsTotalDecodedCacheHits(t *testing.T) {
+	cache := NewFrameCache(1 << 20)
+	r := openArchive(t, testChunks, WithFrameCache(cache), WithLimits(Limits{MaxTotalDecodedBytes: 6}))
+	for i := 0; i < 3; i++ {
+		if got, err := r.ReadRange(0, 6); err != nil || string(got) != "alpha-" {
+			t.Fatalf("ReadRange #%d = (%q, %v)", i, got, err)
+		}
+	}
+	if _, err := r.ReadRange(6, 7); !errors.Is(err, ErrDecodeQuotaExceeded) {
+		t.Errorf("ReadRange of a new frame = %v, want ErrDecodeQuotaExceeded", err)
+	}
+}
+
+func TestLimitsFrameSize(t *testing.T) {
+	path := writeArchive(t, buildArchive(testChunks...))
+	if _, err := Open(path, WithLimits(Limits{MaxFrameDecodedBytes: 7})); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("Open with frames over the limit = %v, want ErrFrameTooLarge", err)
+	}
+	openArchivePath(t, path, WithLimits(Limits{MaxFrameDecodedBytes: 8}))
+}
+
+func TestLimitsExpansionRatio(t *testing.T) {
+	data := writeTestArchive(t, bytes.Repeat([]byte{0}, 1<<16))
+	if _, err := OpenBytes(data, WithLimits(Limits{MaxExpansionRatio: 10})); !errors.Is(err, ErrSuspiciousArchive) {
+		t.Errorf("OpenBytes with a ratio of 10 = %v, want ErrSuspiciousArchive", err)
+	}
+
+	// Zero fields keep the default check.
+	frame := rawFrame([]byte("alpha"))
+	bomb := append(frame, seekTableFrame([]uint32{uint32(len(frame))}, []uint32{1 << 30})...)
+	if _, err := OpenBytes(bomb, WithLimits(Limits{MaxSingleReadBytes: 1 << 20})); !errors.Is(err, ErrSuspiciousArchive) {
+		t.Errorf("OpenBytes of a bomb with other limits = %v, want ErrSuspiciousArchive", err)
+	}
+}
+
+func TestLimitsWindowLog(t *testing.T) {
+	content := bytes.Repeat(interopContent(), 4)
+	data := writeTestArchive(t, content, WithFrameSize(64*1024))
+
+	r, err := OpenBytes(data, WithLimits(Limits{MaxWindowLog: 12}))
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	if _, err := r.ReadRange(0, 10); !errors.Is(err, ErrWindowTooLarge) {
+		t.Errorf("ReadRange = %v, want ErrWindowTooLarge", err)
+	}
+	if err := r.DeepValidate(); !errors.Is(err, ErrWindowTooLarge) {
+		t.Errorf("DeepValidate = %v, want ErrWindowTooLarge", err)
+	}
+
+	r2, err := OpenBytes(data, WithLimits(Limits{MaxWindowLog: 17}))
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r2.Close()
+	if got, err := r2.DecompressAll(); err != nil || !bytes.Equal(got, content) {
+		t.Errorf("DecompressAll within the window limit = (%d bytes, %v)", len(got), err)
+	}
+	if err := r2.DeepValidate(); err != nil {
+		t.Errorf("DeepValidate within the window limit: %v", err)
+	}
+}
+
+func TestFrameWindowSize(t *testing.T) {
+	// rawFrame declares a 2 MiB window.
+	if w, ok := frameWindowSize(rawFrame([]byte("alpha")), false); !ok || w != 2<<20 {
+		t.Errorf("frameWindowSize(rawFrame) = (%d, %v), want 2 MiB", w, ok)
+	}
+	r := openArchive(t, testChunks, WithLimits(Limits{MaxWindowLog: 20}))
+	if _, err := r.ReadRange(0, 1); !errors.Is(err, ErrWindowTooLarge) {
+		t.Errorf("ReadRange with a 1 MiB window limit = %v, want ErrWindowTooLarge", err)
+	}
+
+	if _, ok := frameWindowSize([]byte{0x50, 0x2a, 0x4d, 0x18, 0, 0, 0, 0}, false); ok {
+		t.Error("frameWindowSize reported a window for a skippable frame")
+	}
+}
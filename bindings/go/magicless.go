@@ -0,0 +1,63 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// zstd's ZSTD_d_format parameter (ZSTD_d_experimentalParam1) and its values.
+// The magicless format decodes frames stored without the 4-byte frame magic.
+const (
+	decodeParamFormat = 1000
+	formatZstd1       = 0
+	formatMagicless   = 1
+)
+
+// ErrUnsupportedFrame is returned for frames the package cannot decode
+// because of how they are encoded. The error names the frame.
+var ErrUnsupportedFrame = errors.New("seekable: unsupported frame format")
+
+// magicless reports whether the compressed frame in comp starts with neither
+// the zstd frame magic nor a skippable frame magic, as frames of producers
+// that omit the magic do. It only picks the decoder and the error for such
+// frames; whether they are decoded as magicless is up to WithMagiclessFrames.
+func magicless(comp []byte) bool {
+	if len(comp) < 4 {
+		return false
+	}
+	m := binary.LittleEndian.Uint32(comp)
+	return m != zstdFrameMagic && m&skippableMagicMask != skippableMagicBase
+}
+
+// firstFrameMagicless reports whether the first non-empty frame of the
+// archive looks magicless. The core decoder cannot decode such frames, so
+// they are left to decodeExact, which decodes them or names the frame in
+// its error.
+func (st *seekTable) firstFrameMagicless(ra io.ReaderAt) (bool, error) {
+	for i, f := range st.frames {
+		if f.compSize < 4 {
+			continue
+		}
+		var magic [4]byte
+		if err := readFullAt(ra, magic[:], int64(f.compOffset)); err != nil {
+			return false, fmt.Errorf("reading frame %d: %w", i, err)
+		}
+		return magicless(magic[:]), nil
+	}
+	return false, nil
+}
+
+// decompressMagicless decodes the magicless frame in comp into dst with d,
+// switching d to the magicless format for the call.
+func decompressMagicless(d *dctx, dst, comp []byte) (int, error) {
+	if err := d.setParameter(decodeParamFormat, formatMagicless); err != nil {
+		return 0, fmt.Errorf("%w: magicless frame: %v", ErrUnsupportedFrame, err)
+	}
+	n, err := d.decompress(dst, comp)
+	if rerr := d.setParameter(decodeParamFormat, formatZstd1); rerr != nil && err == nil {
+		err = rerr
+	}
+	return n, err
+}
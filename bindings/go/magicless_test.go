@@ -0,0 +1,155 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stripMagic rewrites a seekable archive with every frame's magic removed.
+func stripMagic(t testing.TB, data []byte) []byte {
+	t.Helper()
+	r, err := OpenBytes(data)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+
+	var out []byte
+	var compSizes, decompSizes []uint32
+	for _, f := range r.view.Load().table.frames {
+		out = append(out, data[f.compOffset+4:f.compOffset+f.compSize]...)
+		compSizes = append(compSizes, uint32(f.compSize-4))
+		decompSizes = append(decompSizes, uint32(f.decompSize))
+	}
+	return append(out, seekTableFrame(compSizes, decompSizes)...)
+}
+
+func TestMagiclessFixture(t *testing.T) {
+	// The fixture is a Writer archive with each frame's magic stripped.
+	content := strings.Repeat("magicless frames ", 200)
+
+	for name, opts := range map[string][]Option{
+		"core":   nil,
+		"frames": {WithFrameCache(NewFrameCache(1 << 20))},
+		"go":     {WithDecodeParam(DecodeParamWindowLogMax, 27)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := openFixture(t, "magicless.szst", append(opts, WithMagiclessFrames())...)
+			if r.ptr != nil {
+				t.Fatal("magicless archive opened with the core decoder")
+			}
+			if r.FrameCount() != 4 || r.Size() != uint64(len(content)) {
+				t.Fatalf("got %d frames, %d bytes", r.FrameCount(), r.Size())
+			}
+
+			got, err := r.ReadRange(1000, 2100)
+			if err != nil {
+				t.Fatalf("ReadRange failed: %v", err)
+			}
+			if string(got) != content[1000:2100] {
+				t.Error("ReadRange content does not match")
+			}
+
+			all, err := r.DecompressAll()
+			if err != nil {
+				t.Fatalf("DecompressAll failed: %v", err)
+			}
+			if string(all) != content {
+				t.Error("DecompressAll content does not match")
+			}
+		})
+	}
+}
+
+func TestMagiclessArchive(t *testing.T) {
+	content := bytes.Repeat([]byte("no frame magic here "), 1000)
+	data := stripMagic(t, writeTestArchive(t, content, WithFrameSize(4096)))
+
+	r, err := OpenBytes(data, WithMagiclessFrames())
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+
+	if err := r.DeepValidate(); err != nil {
+		t.Errorf("DeepValidate failed: %v", err)
+	}
+
+	sub, err := r.SubReader(2, 4)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+	got, err := sub.DecompressAll()
+	if err != nil {
+		t.Fatalf("SubReader DecompressAll failed: %v", err)
+	}
+	if !bytes.Equal(got, content[2*4096:4*4096]) {
+		t.Error("SubReader content does not match")
+	}
+}
+
+func TestMagiclessNeedsOption(t *testing.T) {
+	data := stripMagic(t, writeTestArchive(t, []byte(testContent)))
+	for _, open := range []func() (*Reader, error){
+		func() (*Reader, error) { return OpenBytes(data) },
+		func() (*Reader, error) { return Open(writeArchive(t, data)) },
+	} {
+		r, err := open()
+		if err != nil {
+			t.Fatalf("open failed: %v", err)
+		}
+		var fe *FrameError
+		_, err = r.ReadRange(0, 5)
+		if !errors.Is(err, ErrUnsupportedFrame) {
+			t.Errorf("ReadRange without WithMagiclessFrames = %v, want ErrUnsupportedFrame", err)
+		}
+		if err := r.DeepValidate(); !errors.As(err, &fe) || !errors.Is(err, ErrUnsupportedFrame) || fe.Frame != 0 {
+			t.Errorf("DeepValidate = %v, want a *FrameError for frame 0 wrapping ErrUnsupportedFrame", err)
+		}
+		r.Close()
+	}
+
+	// The option does not fall back to ordinary frames either.
+	r, err := OpenBytes(writeTestArchive(t, []byte(testContent)), WithMagiclessFrames())
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	if _, err := r.ReadRange(0, 5); err == nil {
+		t.Error("ReadRange of a standard frame as magicless succeeded")
+	}
+}
+
+func TestMagiclessDetection(t *testing.T) {
+	frame := rawFrame([]byte("abc"))
+	if magicless(frame) {
+		t.Error("zstd frame detected as magicless")
+	}
+	if !magicless(frame[4:]) {
+		t.Error("frame without magic not detected as magicless")
+	}
+	if magicless(seekTableFrame(nil, nil)) {
+		t.Error("skippable frame detected as magicless")
+	}
+
+	if r := openHello(t); r.ptr == nil {
+		t.Error("standard archive not opened with the core decoder")
+	}
+}
+
+func TestMagiclessCorruptFrame(t *testing.T) {
+	data := stripMagic(t, writeTestArchive(t, bytes.Repeat([]byte("x"), 100)))
+	data[0] ^= 0xFF
+
+	r, err := OpenBytes(data, WithMagiclessFrames())
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	if _, err := r.DecompressAll(); err == nil {
+		t.Error("DecompressAll of a corrupt magicless frame succeeded")
+	}
+}
@@ -0,0 +1,150 @@
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Metadata frames are skippable frames written between the last data frame
+// and the seek table. They are not listed in the seek table, so readers that
+// do not know about them (including the zstd CLI) skip them. Each payload
+// starts with a 4-byte tag identifying its content.
+const (
+	skippableMagicMetadata = 0x184D2A5B
+	skippableMagicMask     = 0xFFFFFFF0
+	skippableMagicBase     = 0x184D2A50
+
+	metadataTagSize = 4
+
+	// metadataContentHash holds the SHA-256 of the decompressed content.
+	metadataContentHash = "SHA2"
+)
+
+var (
+	// ErrNoContentHash is returned by ContentHash and VerifyContent when the
+	// archive does not store a content hash.
+	ErrNoContentHash = errors.New("seekable: archive has no content hash")
+
+	// ErrContentMismatch is returned by VerifyContent when the decompressed
+	// content does not match the stored hash.
+	ErrContentMismatch = errors.New("seekable: content does not match the stored hash")
+)
+
+// appendMetadataFrame appends a metadata frame holding value under tag to dst.
+func appendMetadataFrame(dst []byte, tag string, value []byte) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, skippableMagicMetadata)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(metadataTagSize+len(value)))
+	dst = append(dst, tag...)
+	return append(dst, value...)
+}
+
+// readMetadata collects the metadata frames in ra from offset from up to the
+// seek table, which is normally the end of the last frame. Other skippable
+// frames are ignored, and anything that is not a skippable frame ends the
+// scan.
+func (st *seekTable) readMetadata(ra io.ReaderAt, from int64) error {
+	var header [skippableHeaderSize]byte
+	for off := from; off+skippableHeaderSize <= st.start; {
+		if err := readFullAt(ra, header[:], off); err != nil {
+			return fmt.Errorf("reading metadata frame: %w", err)
+		}
+
+		magic := binary.LittleEndian.Uint32(header[0:])
+		size := int64(binary.LittleEndian.Uint32(header[4:]))
+		if magic&skippableMagicMask != skippableMagicBase || off+skippableHeaderSize+size > st.start {
+			return nil
+		}
+
+		if magic == skippableMagicMetadata && size >= metadataTagSize {
+			payload := make([]byte, size)
+			if err := readFullAt(ra, payload, off+skippableHeaderSize); err != nil {
+				return fmt.Errorf("reading metadata frame: %w", err)
+			}
+			if st.metadata == nil {
+				st.metadata = make(map[string][]byte)
+			}
+			st.metadata[string(payload[:metadataTagSize])] = payload[metadataTagSize:]
+		}
+
+		off += skippableHeaderSize + size
+	}
+	return nil
+}
+
+// ContentHash returns the SHA-256 of the decompressed content stored in the
+// archive by a Writer created with WithContentHash. It returns
+// ErrNoContentHash if the archive has none.
+//
+// The hash covers the whole archive, so sub-readers never report one.
+func (r *Reader) ContentHash() ([]byte, error) {
+	sum, ok := r.view.Load().table.metadata[metadataContentHash]
+	if !ok {
+		return nil, ErrNoContentHash
+	}
+	return bytes.Clone(sum), nil
+}
+
+// VerifyContent decompresses the whole archive and compares its SHA-256 with
+// the stored content hash. It returns ErrContentMismatch if they differ.
+func (r *Reader) VerifyContent() error {
+	want, err := r.ContentHash()
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err := r.CopyRange(h, 0, r.Size()); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), want) {
+		if l := r.cfg.logger; l != nil {
+			l.LogAttrs(context.Background(), slog.LevelDebug, "seekable: content hash mismatch",
+				slog.String("hash", hex.EncodeToString(h.Sum(nil))),
+				slog.String("want", hex.EncodeToString(want)))
+		}
+		return ErrContentMismatch
+	}
+	return nil
+}
+
+// Fingerprint returns a SHA-256 over the seek table: the checksum flag and,
+// for every frame, its compressed and decompressed sizes and, if the seek
+// table stores them, its checksum. It reads nothing from the source, so it is
+// a cheap identity for deduplicating archives before comparing them with
+// EqualContent.
+//
+// Equal fingerprints are a strong signal of equal content only when the seek
+// tables store checksums and both archives were written with the same
+// compression parameters and frame layout. Without checksums it matches any
+// archives with the same frame sizes. Archives with equal content but
+// different parameters or frame sizes have different fingerprints.
+func (r *Reader) Fingerprint() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h == nil {
+		return nil, ErrClosed
+	}
+	v := r.view.Load()
+
+	h := sha256.New()
+	var buf [20]byte
+	if v.table.hasChecksums {
+		buf[0] = seekTableChecksumFlag
+	}
+	binary.LittleEndian.PutUint64(buf[1:], uint64(len(v.table.frames)))
+	h.Write(buf[:9])
+	for _, f := range v.table.frames {
+		binary.LittleEndian.PutUint64(buf[0:], f.compSize)
+		binary.LittleEndian.PutUint64(buf[8:], f.decompSize)
+		binary.LittleEndian.PutUint32(buf[16:], f.checksum)
+		h.Write(buf[:])
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,493 @@
+package seekable
+
+import (
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Option configures a Reader at open time.
+type Option func(*config)
+
+// config holds the settings applied by Option values.
+type config struct {
+	eofAfterFullRead  bool
+	progress          func(done, total uint64)
+	cache             *FrameCache
+	cacheKey          string
+	decodeParams      []decodeParam
+	pinnedFrames      int
+	logger            *slog.Logger
+	sizeChecks        bool
+	readTimeout       time.Duration
+	advice            []Advice
+	noATime           bool
+	lazyFDIdle        time.Duration
+	collector         Collector
+	knownSize         uint64
+	hasKnownSize      bool
+	strictTable       bool
+	strictReaderAt    bool
+	verifier          func(index uint64, data []byte) error
+	decodeTimeout     time.Duration
+	tableCache        *SeekTableCache
+	maxExpansionRatio uint64
+	noReadahead       bool
+
+	// maxReadBytes, maxDecodedBytes and maxFrameBytes are the WithLimits
+	// limits not held elsewhere; zero means unlimited.
+	maxReadBytes    uint64
+	maxDecodedBytes uint64
+	maxFrameBytes   uint64
+	maxWindowLog    int
+
+	retries      int
+	retryBackoff func(attempt int) time.Duration
+
+	// dictionaries holds the WithDictionaries values; dicts is their digested
+	// form, made when the archive is opened.
+	dictionaries map[uint32][]byte
+	dicts        *dictSet
+
+	magicless bool
+}
+
+type decodeParam struct {
+	param, value int
+}
+
+// decodeInGo reports whether the configuration requires frames to be decoded
+// in Go rather than by the core decoder.
+func (c *config) decodeInGo() bool {
+	return len(c.decodeParams) > 0 || len(c.advice) > 0 || c.noATime || c.lazyFDIdle > 0 || len(c.dictionaries) > 0 || c.maxWindowLog > 0 || c.magicless
+}
+
+func defaultConfig() config {
+	return config{
+		eofAfterFullRead:  true,
+		collector:         nopCollector{},
+		maxExpansionRatio: DefaultMaxExpansionRatio,
+	}
+}
+
+func newConfig(opts []Option) config {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return cfg
+}
+
+// source wraps the compressed source of an archive according to the
+// configuration.
+func (c *config) source(ra io.ReaderAt) io.ReaderAt {
+	if c.readTimeout > 0 {
+		return &timeoutReaderAt{ra: ra, timeout: c.readTimeout}
+	}
+	return ra
+}
+
+// newDCtx returns a decompression context with the configured decode
+// parameters applied.
+func (c *config) newDCtx() (*dctx, error) {
+	d, err := newDCtx()
+	if err != nil {
+		return nil, err
+	}
+	d.dicts = c.dicts
+	d.magicless = c.magicless
+	for _, p := range c.decodeParams {
+		if err := d.setParameter(p.param, p.value); err != nil {
+			d.free()
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// checkDecodeParams verifies that zstd accepts the configured decode
+// parameters.
+func (c *config) checkDecodeParams() error {
+	d, err := c.newDCtx()
+	if err != nil {
+		return err
+	}
+	d.free()
+	return nil
+}
+
+// WithEOFAfterFullRead controls whether ReadAt reports io.EOF in the same call
+// that returns the final bytes of the archive.
+//
+// When enabled (the default), a ReadAt that is cut short by the end of the
+// decompressed content returns the bytes it read together with io.EOF. This is
+// the spec-strict mode: io.ReaderAt requires a non-nil error whenever
+// n < len(p).
+//
+// When disabled, such a short read returns the bytes with a nil error and
+// io.EOF is deferred to the next call, which reads zero bytes because its
+// offset is at or past Size(). This suits callers that stop on the first
+// io.EOF without consuming the data returned alongside it, but it does not
+// satisfy the io.ReaderAt contract.
+func WithEOFAfterFullRead(enabled bool) Option {
+	return func(c *config) {
+		c.eofAfterFullRead = enabled
+	}
+}
+
+// WithStrictReaderAt makes ReadAt return a nil error only for reads that end
+// before Size(). Every read that reaches Size() returns io.EOF, including one
+// that fills p exactly, so callers learn of the end without another call. It
+// takes precedence over WithEOFAfterFullRead(false).
+func WithStrictReaderAt() Option {
+	return func(c *config) {
+		c.strictReaderAt = true
+	}
+}
+
+// WithProgress registers a callback that reports decode progress for
+// WriteTo, CopyRange, DecompressAll, ForEachFrame and ReadAtProgress.
+//
+// The callback runs once after each frame is decoded, with the number of
+// bytes produced so far and the total number of bytes the operation will
+// produce. It is invoked on the calling goroutine and should return quickly.
+func WithProgress(fn func(done, total uint64)) Option {
+	return func(c *config) {
+		c.progress = fn
+	}
+}
+
+// WithFrameCache makes the Reader keep decoded frames in c. Sharing one cache
+// between Readers over the same archive lets them reuse each other's decoded
+// frames instead of holding duplicate copies.
+//
+// File-backed archives are identified by file identity (device, inode, size
+// and modification time on Unix), so Readers opened on the same file share
+// entries and a rewritten file never serves stale frames. Use WithCacheKey to
+// supply the identity explicitly.
+func WithFrameCache(c *FrameCache) Option {
+	return func(cfg *config) {
+		cfg.cache = c
+	}
+}
+
+// WithSeekTableCache makes Open, OpenLegacy and OpenFullyDecoded take the
+// parsed seek table of a file from c when the file is unchanged since it was
+// cached, and add it to c otherwise. The core decoder still reads the seek
+// table itself when Open uses it, so the saving is largest for Readers that
+// decode in Go. It does not apply to OpenReader, whose sources have no file
+// identity.
+func WithSeekTableCache(c *SeekTableCache) Option {
+	return func(cfg *config) {
+		cfg.tableCache = c
+	}
+}
+
+// WithCacheKey sets the archive identity used for FrameCache entries. Readers
+// that use the same key must read the same archive content.
+func WithCacheKey(key string) Option {
+	return func(c *config) {
+		c.cacheKey = key
+	}
+}
+
+// DecodeParamWindowLogMax is the zstd ZSTD_d_windowLogMax parameter, for use
+// with WithDecodeParam. It caps the window size a frame may require.
+const DecodeParamWindowLogMax = 100
+
+// WithDecodeParam sets an advanced zstd decompression parameter
+// (ZSTD_dParameter) on every decompression context the Reader uses.
+//
+// The parameter is passed through to ZSTD_DCtx_setParameter unchanged; zstd
+// itself rejects unknown parameters and out-of-range values, which makes Open
+// fail. Readers with decode parameters decode frames through the bundled
+// libzstd rather than the core decoder, which does not accept parameters.
+func WithDecodeParam(param, value int) Option {
+	return func(c *config) {
+		c.decodeParams = append(c.decodeParams, decodeParam{param: param, value: value})
+	}
+}
+
+// WithDictionaries supplies the dictionaries that frames may have been
+// compressed with, keyed by dictionary ID. Each frame is decoded with the
+// dictionary whose ID its header declares, so archives whose frames use
+// different dictionaries read transparently. Reading a frame whose
+// dictionary is not in dicts fails with an error wrapping
+// ErrMissingDictionary that names the ID.
+//
+// Frames that declare no ID are decoded with the dictionary under key 0, or
+// without one if there is none. This is how frames compressed with a raw
+// content dictionary, which has no ID, are read: supply it under key 0.
+// Frames compressed without a dictionary decode correctly with a raw content
+// default too, so archives can mix the two. Every other dictionary must be a
+// formatted zstd dictionary whose header records its key as the ID.
+//
+// Dictionaries are digested once when the archive is opened and not
+// retained. The core decoder does not accept dictionaries, so Readers given
+// them decode frames in Go.
+func WithDictionaries(dicts map[uint32][]byte) Option {
+	return func(c *config) {
+		c.dictionaries = dicts
+	}
+}
+
+// WithMagiclessFrames declares that the archive's data frames are stored
+// without the 4-byte zstd frame magic, as some producers write them to save
+// space, relying on the seek table to delimit frames. Every frame is then
+// decoded with libzstd's magicless format, in Go rather than by the core
+// decoder. Without it, reading a frame that lacks the magic fails with an
+// error wrapping ErrUnsupportedFrame that names the frame; frames are never
+// decoded as magicless on the strength of their first bytes alone.
+func WithMagiclessFrames() Option {
+	return func(c *config) {
+		c.magicless = true
+	}
+}
+
+// WithPinnedFrames decodes the first k frames when the archive is opened and
+// keeps them resident until Close, so reads of that prefix never pay
+// decompression cost.
+//
+// Pinned frames are held by the Reader itself and are never evicted. When a
+// FrameCache is also configured, they are stored there as well, but their
+// residency does not depend on it.
+func WithPinnedFrames(k int) Option {
+	return func(c *config) {
+		c.pinnedFrames = k
+	}
+}
+
+// WithLogger makes the Reader log internal events to logger at debug level:
+// opening the archive, decoding frames and ranges, frame cache evictions,
+// WithFrameRetry retries and WithLazyFD reopens, with frame indices and sizes
+// attached. Failed checksum, WithFrameVerifier, VerifyContent and
+// VerifySignature checks are logged too, before the error is returned.
+// Without a logger the Reader does no logging work at all.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithSizeChecks makes ReadAt check that every frame it decodes produces
+// exactly the decompressed size recorded in the seek table, and fail with an
+// error wrapping ErrSizeMismatch, naming the frame and both sizes, otherwise.
+// It is cheaper than checksum verification and catches truncated frames and
+// tampered length fields.
+//
+// The core decoder trusts the seek table, so with size checks frames are read
+// from the compressed archive and decoded in Go, one frame at a time.
+// Readers that already decode in Go always check frame sizes.
+func WithSizeChecks() Option {
+	return func(c *config) {
+		c.sizeChecks = true
+	}
+}
+
+// WithFrameVerifier makes the Reader call verify with the index and content
+// of every frame it decodes, after decoding and before the frame is cached or
+// used. If verify returns an error, the read fails with a *FrameError wrapping
+// it. Use it to check frames against checksums kept outside the seek table,
+// such as CRC32C values stored by another system.
+//
+// Frames are verified once per decode: pinned frames when the archive is
+// opened, and cached frames when they enter the cache, not on every hit. As
+// with WithCollector, indices are relative to the Reader, and reads are
+// decoded one frame at a time so that verify sees whole frames. verify must
+// not modify data.
+func WithFrameVerifier(verify func(index uint64, data []byte) error) Option {
+	return func(c *config) {
+		c.verifier = verify
+	}
+}
+
+// WithReadTimeout bounds each read from the compressed source to d. A read
+// that takes longer fails with an error wrapping os.ErrDeadlineExceeded, which
+// ReadAt and every other method reading the source return; the stalled read is
+// abandoned, and only retried with WithFrameRetry. An abandoned read keeps a
+// goroutine until the source returns, so once 16 of them are outstanding,
+// further reads of the source fail at once with os.ErrDeadlineExceeded until
+// one finishes.
+//
+// The timeout applies to the reads the package makes from Go: every read of a
+// source opened with OpenReader or OpenBytes, and the frame fetches of
+// file-backed Readers that decode in Go. Ranges decoded by the core decoder
+// read the file directly and are not bounded. Zero, the default, disables the
+// timeout.
+func WithReadTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.readTimeout = d
+	}
+}
+
+// WithFrameRetry retries a failed read of a frame's compressed bytes up to
+// attempts more times before the read fails, waiting backoff(n) before the
+// nth retry; a nil backoff retries at once. It is meant for remote sources,
+// where a fetch can fail transiently.
+//
+// Only errors from the source are retried, including WithReadTimeout
+// timeouts. A source that ends early, with io.EOF or io.ErrUnexpectedEOF, a
+// canceled context and everything that goes wrong once the bytes are read,
+// such as corrupt frames and checksum mismatches, fail at once. The retries
+// apply to frames read from Go, as WithReadTimeout does; the core decoder
+// reads the file itself. Reads of the seek table at open are not retried.
+// A read made with ReadAtContext stops retrying, even mid-backoff, once its
+// context is done.
+func WithFrameRetry(attempts int, backoff func(attempt int) time.Duration) Option {
+	return func(c *config) {
+		c.retries = attempts
+		c.retryBackoff = backoff
+	}
+}
+
+// WithDecodeTimeout bounds the time spent decoding a single frame to d,
+// separately from WithReadTimeout, which bounds reads from the source. A frame
+// that takes longer fails with an error wrapping os.ErrDeadlineExceeded, which
+// guards against frames crafted to decode slowly. For frames decoded in Go,
+// the time includes reading the frame from the source.
+//
+// A decode cannot be interrupted, so a timed-out decode is abandoned: it runs
+// to completion in the background on its own buffers, keeping its
+// SetDecodeConcurrency slot and the decoder alive until it finishes, even
+// across Close. Readers with a decode timeout decode reads one frame at a
+// time. An abandoned decode in the core decoder keeps it busy, so after the
+// first such timeout the Reader decodes frames in Go instead. Zero, the
+// default, disables the timeout.
+func WithDecodeTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.decodeTimeout = d
+	}
+}
+
+// Advice is an access pattern hint for WithFadvise.
+type Advice int
+
+// Access pattern hints, matching the posix_fadvise advice values.
+const (
+	AdviceNormal Advice = iota
+	AdviceRandom
+	AdviceSequential
+	AdviceWillNeed
+	AdviceDontNeed
+)
+
+// WithFadvise hints the kernel about how a file-backed archive will be read,
+// using posix_fadvise on the whole file: AdviceRandom disables readahead,
+// AdviceSequential increases it, AdviceWillNeed starts reading the file into
+// the page cache and AdviceDontNeed drops it from there. Several hints may be
+// given by repeating the option; they are applied in order.
+//
+// The hints apply to the Reader's own file descriptor, so Readers given them
+// read and decode frames in Go rather than through the core decoder, which
+// opens the file separately. Hints are best effort: failures are ignored, and
+// on systems other than Linux the option has no effect. It does not apply to
+// OpenReader.
+func WithFadvise(advice Advice) Option {
+	return func(c *config) {
+		c.advice = append(c.advice, advice)
+	}
+}
+
+// WithNoReadahead declares that the archive is read at random, so no reading
+// ahead pays off. Streams from NewStream then decode only what is read,
+// ignoring WithStreamPipelineDepth, and file-backed archives get
+// WithFadvise(AdviceRandom), which disables the kernel's readahead on Linux;
+// like WithFadvise, that makes the Reader decode frames in Go. Later
+// WithFadvise hints still apply after it.
+func WithNoReadahead() Option {
+	return func(c *config) {
+		c.noReadahead = true
+		c.advice = append(c.advice, AdviceRandom)
+	}
+}
+
+// WithNoATime opens file-backed archives with O_NOATIME, so reads do not
+// update the file's access time. Linux only permits this for the file's owner
+// (or a privileged process); otherwise, and on other systems, the file is
+// opened normally. Like WithFadvise, it makes the Reader decode frames in Go.
+func WithNoATime() Option {
+	return func(c *config) {
+		c.noATime = true
+	}
+}
+
+// WithLazyFD makes a file-backed archive hold its file descriptor only while
+// it is being read: the file is closed once no read has used it for idle, and
+// opened again by the next read, so many rarely read archives need few
+// descriptors. The seek table is kept, so reopening only opens the file.
+// Concurrent reads share one reopen. A file that has changed size or
+// modification time since the archive was opened fails the read with
+// ErrSourceChanged rather than being read. While the file is open, Refresh
+// looks at the open file, even if another file has been renamed into its
+// place. With WithLogger, reopens and idle closes are logged.
+//
+// Like WithFadvise, it makes the Reader decode frames in Go, since the core
+// decoder keeps its own descriptor. It does not apply to OpenReader, and a
+// non-positive idle disables it.
+func WithLazyFD(idle time.Duration) Option {
+	return func(c *config) {
+		c.lazyFDIdle = idle
+	}
+}
+
+// WithCollector makes the Reader report frame decodes and frame cache hits
+// and misses to c as they happen. Frame indices are relative to the Reader, so
+// a sub-reader reports indices within its window.
+//
+// To report every frame decode, a Reader with a collector decodes reads one
+// frame at a time rather than handing whole ranges to the core decoder.
+// Passing nil restores the default, which discards events.
+func WithCollector(c Collector) Option {
+	return func(cfg *config) {
+		if c == nil {
+			c = nopCollector{}
+		}
+		cfg.collector = c
+	}
+}
+
+// WithKnownSize declares the decompressed size of the archive, as recorded in
+// external metadata. Open and OpenReader fail if the seek table describes a
+// different size, which catches an archive that does not match its metadata
+// before any of it is read.
+//
+// The seek table is still read in full: it is the only record of where frames
+// start, and the decompressed size comes from the same read, so a known size
+// saves no reads of the source.
+func WithKnownSize(size uint64) Option {
+	return func(c *config) {
+		c.knownSize = size
+		c.hasKnownSize = true
+	}
+}
+
+// WithStrictSeekTable makes Open and OpenReader run Validate on the seek
+// table and fail with its error, wrapping ErrInvalidArchive, if the table
+// describes an impossible layout. Use it for archives from untrusted sources.
+func WithStrictSeekTable() Option {
+	return func(c *config) {
+		c.strictTable = true
+	}
+}
+
+// DefaultMaxExpansionRatio is the expansion ratio WithMaxExpansionRatio
+// allows unless configured otherwise.
+const DefaultMaxExpansionRatio = 1000
+
+// WithMaxExpansionRatio makes the open functions fail with
+// ErrSuspiciousArchive if the seek table declares more than ratio times as
+// many decompressed bytes as its frames occupy in the source. A seek table
+// that lies about sizes this way is typical of decompression bombs, and
+// rejecting it at open is cheaper than failing deep in a decode.
+//
+// The default is DefaultMaxExpansionRatio, which ordinary data stays well
+// below. Highly repetitive content, such as long runs of zeros, can
+// legitimately compress further; pass a larger ratio for such archives, or 0
+// to disable the check.
+func WithMaxExpansionRatio(ratio uint64) Option {
+	return func(c *config) {
+		c.maxExpansionRatio = ratio
+	}
+}
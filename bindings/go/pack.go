@@ -0,0 +1,137 @@
+package seekable
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrUnsafePath is returned by Unpack for entries whose names would place
+// them outside the destination directory.
+var ErrUnsafePath = errors.New("seekable: unsafe entry path")
+
+// Pack compresses the regular files under root into a seekable archive
+// written to dst, with an entry index naming each file by its slash-separated
+// path relative to root and recording its permission bits. Readers list the
+// files with Entries and read them with ReadEntry.
+//
+// Files are stored in lexical path order, each starting in a new frame, so
+// ReadEntry decodes only the frames of the file it reads. Directories are
+// implied by the paths; symbolic links and other non-regular files are
+// skipped. The Writer options apply as for NewWriter, except that
+// WithFrameAlignment cannot be used, since files end frames early. If root is
+// a regular file, the archive holds just that file, named by its base name.
+//
+// If walking root or reading a file fails, Pack returns the error without
+// writing the seek table, so the partial output does not open as an archive.
+// Pack does not close dst.
+func Pack(dst io.Writer, root string, opts ...WriterOption) error {
+	if newWriterConfig(opts).alignment != 0 {
+		return errors.New("seekable: Pack does not support WithFrameAlignment")
+	}
+
+	w, err := NewWriter(dst, opts...)
+	if err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			rel = filepath.Base(path)
+		}
+		if err := w.packFile(path, filepath.ToSlash(rel)); err != nil {
+			return fmt.Errorf("seekable: packing %s: %w", rel, err)
+		}
+		return nil
+	})
+	if err != nil {
+		w.abort()
+		return err
+	}
+	return w.Close()
+}
+
+// packFile appends the file at path to the archive as the entry name.
+func (w *Writer) packFile(path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	start := w.Offset()
+	if _, err := w.ReadFrom(f); err != nil {
+		return err
+	}
+	if err := w.endFrame(); err != nil {
+		return err
+	}
+	return w.addEntry(indexEntry{name: name, start: start, end: w.Offset(), mode: uint32(info.Mode().Perm())})
+}
+
+// Unpack extracts the entries in r's entry index into destDir, each at its
+// slash-separated name relative to destDir, creating directories as needed.
+// Files are created with the entry's permission bits, subject to the umask,
+// or 0644 when none were recorded; existing files are overwritten. An archive
+// without an index has nothing to extract.
+//
+// Every name is checked before anything is written: absolute names, names
+// with ".." or empty elements, and names that are not local paths on the
+// current system fail with an error wrapping ErrUnsafePath. Content is copied
+// with CopyRange, so memory use is bounded by the largest frame rather than
+// by the size of a file.
+func Unpack(r *Reader, destDir string) error {
+	entries, err := r.Entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !fs.ValidPath(e.Name) || e.Name == "." || !filepath.IsLocal(filepath.FromSlash(e.Name)) {
+			return fmt.Errorf("%w: %q", ErrUnsafePath, e.Name)
+		}
+	}
+
+	for _, e := range entries {
+		if err := r.unpackEntry(destDir, e); err != nil {
+			return fmt.Errorf("seekable: unpacking %s: %w", e.Name, err)
+		}
+	}
+	return nil
+}
+
+// unpackEntry writes the entry e to its path under destDir.
+func (r *Reader) unpackEntry(destDir string, e EntryInfo) error {
+	path := filepath.Join(destDir, filepath.FromSlash(e.Name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	perm := e.Mode.Perm()
+	if perm == 0 {
+		perm = 0o644
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := r.CopyRange(f, e.Offset, e.Offset+e.Size); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
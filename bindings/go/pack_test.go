@@ -0,0 +1,234 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestPackRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	files := []struct {
+		name    string
+		content []byte
+		mode    fs.FileMode
+	}{
+		{"a.txt", bytes.Repeat([]byte("alpha "), 500), 0o644},
+		{"bin/run.sh", []byte("#!/bin/sh\necho run\n"), 0o755},
+		{"dir/sub/b.txt", bytes.Repeat([]byte("bravo "), 2000), 0o600},
+		{"dir/empty", nil, 0o644},
+	}
+	for _, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(f.name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, f.content, f.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, f.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a.txt", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Pack(&buf, root, WithFrameSize(1024)); err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+
+	r, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+
+	want := []string{"a.txt", "bin/run.sh", "dir/empty", "dir/sub/b.txt"}
+	if got := r.ListEntries(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ListEntries() = %q, want %q", got, want)
+	}
+
+	infos, err := r.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	byName := make(map[string]EntryInfo)
+	for _, info := range infos {
+		byName[info.Name] = info
+	}
+
+	for _, f := range files {
+		info := byName[f.name]
+		if info.Size != uint64(len(f.content)) {
+			t.Errorf("%s: Size = %d, want %d", f.name, info.Size, len(f.content))
+		}
+		if info.Mode != f.mode {
+			t.Errorf("%s: Mode = %v, want %v", f.name, info.Mode, f.mode)
+		}
+		if !r.IsFrameBoundary(info.Offset) {
+			t.Errorf("%s: offset %d is not a frame boundary", f.name, info.Offset)
+		}
+
+		got, err := r.ReadEntry(f.name)
+		if err != nil {
+			t.Fatalf("ReadEntry(%q) failed: %v", f.name, err)
+		}
+		if !bytes.Equal(got, f.content) {
+			t.Errorf("ReadEntry(%q) returned wrong content", f.name)
+		}
+	}
+}
+
+func TestPackRejectsAlignment(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Pack(&buf, t.TempDir(), WithFrameAlignment(4096)); err == nil {
+		t.Fatal("Pack with WithFrameAlignment succeeded")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Pack wrote %d bytes before failing", buf.Len())
+	}
+}
+
+func TestPackMissingRoot(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Pack(&buf, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("Pack of a missing directory succeeded")
+	}
+}
+
+func TestPackSingleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte(testContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Pack(&buf, path); err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	r, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	if got := r.ListEntries(); !reflect.DeepEqual(got, []string{"notes.txt"}) {
+		t.Errorf("ListEntries() = %q, want [notes.txt]", got)
+	}
+}
+
+func TestPackReadError(t *testing.T) {
+	// Reading /proc/self/mem at offset 0 fails, though it is a regular file.
+	const path = "/proc/self/mem"
+	if runtime.GOOS != "linux" {
+		t.Skip("needs " + path)
+	}
+	var buf bytes.Buffer
+	if err := Pack(&buf, path); err == nil {
+		t.Fatal("Pack of an unreadable file succeeded")
+	}
+	if _, err := OpenBytes(buf.Bytes()); err == nil {
+		t.Error("partial output of a failed Pack opens as an archive")
+	}
+}
+
+func TestUnpackRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]fs.FileMode{
+		"top.txt":       0o644,
+		"tools/run.sh":  0o755,
+		"deep/a/b/c.md": 0o600,
+		"deep/empty":    0o644,
+	}
+	for name, mode := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		content := bytes.Repeat([]byte(name+"\n"), len(name)*50)
+		if name == "deep/empty" {
+			content = nil
+		}
+		if err := os.WriteFile(path, content, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Pack(&buf, src, WithFrameSize(256)); err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	r, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+
+	dst := t.TempDir()
+	if err := Unpack(r, dst); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+
+	for name, mode := range files {
+		want, err := os.ReadFile(filepath.Join(src, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dst, filepath.FromSlash(name))
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading unpacked %s: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: unpacked content differs", name)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s: mode = %v, want %v", name, info.Mode().Perm(), mode)
+		}
+	}
+}
+
+func TestUnpackRejectsUnsafePaths(t *testing.T) {
+	for _, name := range []string{"../evil", "a/../../evil", "/etc/evil", "a//b", "."} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf)
+		if err != nil {
+			t.Fatalf("NewWriter failed: %v", err)
+		}
+		if _, err := w.Write([]byte("ok")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if err := w.AddEntry("safe", 0, 2); err != nil {
+			t.Fatalf("AddEntry failed: %v", err)
+		}
+		if err := w.AddEntry(name, 0, 2); err != nil {
+			t.Fatalf("AddEntry(%q) failed: %v", name, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		r, err := OpenBytes(buf.Bytes())
+		if err != nil {
+			t.Fatalf("OpenBytes failed: %v", err)
+		}
+		dst := t.TempDir()
+		if err := Unpack(r, dst); !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("Unpack with entry %q: error = %v, want ErrUnsafePath", name, err)
+		}
+		r.Close()
+
+		if des, _ := os.ReadDir(dst); len(des) != 0 {
+			t.Errorf("Unpack with entry %q wrote %d files before failing", name, len(des))
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package seekable
+
+import "sync"
+
+// decodeSlots caps the number of frame decodes running at once across all
+// Readers. A nil channel means decodes are not limited.
+var decodeSlots struct {
+	mu  sync.RWMutex
+	sem chan struct{}
+}
+
+// SetDecodeConcurrency caps the number of decodes that may run concurrently
+// across every Reader in the process, including DeepValidate workers. Each
+// decode of a frame or, on the core decoder, of a range holds one slot; reads
+// block until a slot is free and still return only once their data is
+// decoded. A value of n <= 0 removes the cap, which is the default.
+//
+// Changing the cap does not affect decodes already running.
+func SetDecodeConcurrency(n int) {
+	decodeSlots.mu.Lock()
+	defer decodeSlots.mu.Unlock()
+
+	if n <= 0 {
+		decodeSlots.sem = nil
+		return
+	}
+	decodeSlots.sem = make(chan struct{}, n)
+}
+
+// acquireDecode blocks until a decode slot is free and returns the function
+// that releases it.
+func acquireDecode() (release func()) {
+	decodeSlots.mu.RLock()
+	sem := decodeSlots.sem
+	decodeSlots.mu.RUnlock()
+
+	if sem == nil {
+		return func() {}
+	}
+	sem <- struct{}{}
+	return func() { <-sem }
+}
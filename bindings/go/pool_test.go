@@ -0,0 +1,67 @@
+package seekable
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetDecodeConcurrency(t *testing.T) {
+	SetDecodeConcurrency(2)
+	t.Cleanup(func() { SetDecodeConcurrency(0) })
+
+	a, b := acquireDecode(), acquireDecode()
+
+	acquired := make(chan func())
+	go func() { acquired <- acquireDecode() }()
+
+	select {
+	case <-acquired:
+		t.Fatal("Third decode slot acquired while the cap is 2")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	a()
+	select {
+	case release := <-acquired:
+		release()
+	case <-time.After(time.Second):
+		t.Fatal("Decode slot not handed over after release")
+	}
+	b()
+}
+
+func TestDecodeConcurrencyReads(t *testing.T) {
+	SetDecodeConcurrency(1)
+	t.Cleanup(func() { SetDecodeConcurrency(0) })
+
+	content := []byte(strings.Repeat("capped decode ", 1000))
+	data := writeTestArchive(t, content, WithFrameSize(512))
+	path := writeArchive(t, data)
+
+	readers := []*Reader{
+		openArchivePath(t, path),
+		openArchivePath(t, path, WithFrameCache(NewFrameCache(1<<20))),
+		openArchivePath(t, path, WithDecodeParam(DecodeParamWindowLogMax, 27)),
+	}
+
+	var wg sync.WaitGroup
+	for _, r := range readers {
+		r := r
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if got, err := r.DecompressAll(); err != nil || string(got) != string(content) {
+				t.Errorf("DecompressAll = (%d bytes, %v)", len(got), err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := r.DeepValidate(WithParallelism(4)); err != nil {
+				t.Errorf("DeepValidate failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,87 @@
+package seekable
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RangeReader returns a reader over the decompressed bytes in [start, end).
+//
+// Frames are decoded lazily as the reader is consumed, and only one decoded
+// frame is held at a time. Closing the reader releases it; the Reader itself
+// stays open.
+func (r *Reader) RangeReader(start, end uint64) (io.ReadCloser, error) {
+	if start > end {
+		return nil, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
+	}
+
+	if end > r.Size() {
+		return nil, fmt.Errorf("range end (%d) exceeds size (%d)", end, r.Size())
+	}
+
+	return &rangeReader{r: r, off: start, end: end}, nil
+}
+
+var errRangeReaderClosed = errors.New("seekable: range reader is closed")
+
+// rangeReader decodes a range one frame at a time.
+type rangeReader struct {
+	r        *Reader
+	off, end uint64
+
+	// pending holds the decoded bytes not yet returned; buf backs it.
+	pending []byte
+	buf     []byte
+	closed  bool
+}
+
+func (rr *rangeReader) Read(p []byte) (int, error) {
+	if rr.closed {
+		return 0, errRangeReaderClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if len(rr.pending) == 0 {
+		if rr.off >= rr.end {
+			return 0, io.EOF
+		}
+		if err := rr.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, rr.pending)
+	rr.pending = rr.pending[n:]
+	return n, nil
+}
+
+// fill decodes the part of the next frame that lies in the range.
+func (rr *rangeReader) fill() error {
+	table := rr.r.view.Load().table
+	f := table.frames[table.frameIndex(rr.off)]
+	chunkEnd := min(f.decompOffset+f.decompSize, rr.end)
+
+	n := chunkEnd - rr.off
+	if uint64(cap(rr.buf)) < n {
+		rr.buf = make([]byte, n)
+	}
+	chunk := rr.buf[:n]
+	if err := rr.r.readChunk(chunk, rr.off); err != nil {
+		return err
+	}
+
+	rr.pending = chunk
+	rr.off = chunkEnd
+	return nil
+}
+
+// Close releases the decoded frame. Reads after Close fail.
+func (rr *rangeReader) Close() error {
+	rr.closed = true
+	rr.pending = nil
+	rr.buf = nil
+	return nil
+}
@@ -0,0 +1,70 @@
+package seekable
+
+import (
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRangeReader(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	tests := []struct {
+		name       string
+		start, end uint64
+	}{
+		{"within one frame", 1, 4},
+		{"across frames", 4, 22},
+		{"everything", 0, uint64(len(testContent))},
+		{"empty", 7, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, err := r.RangeReader(tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("RangeReader failed: %v", err)
+			}
+			defer rc.Close()
+
+			if err := iotest.TestReader(rc, []byte(testContent[tt.start:tt.end])); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRangeReaderClose(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	rc, err := r.RangeReader(0, uint64(len(testContent)))
+	if err != nil {
+		t.Fatalf("RangeReader failed: %v", err)
+	}
+
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(rc, buf); err != nil || string(buf) != "alp" {
+		t.Fatalf("ReadFull = (%q, %v)", buf, err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := rc.Read(buf); err == nil {
+		t.Error("Expected Read after Close to fail")
+	}
+
+	// The Reader is still usable.
+	if got, err := r.ReadRange(0, 5); err != nil || string(got) != "alpha" {
+		t.Errorf("ReadRange = (%q, %v)", got, err)
+	}
+}
+
+func TestRangeReaderInvalid(t *testing.T) {
+	r := openArchive(t, testChunks)
+	if _, err := r.RangeReader(5, 4); err == nil {
+		t.Error("Expected error for start > end")
+	}
+	if _, err := r.RangeReader(0, 26); err == nil {
+		t.Error("Expected error for end past size")
+	}
+}
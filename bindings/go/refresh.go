@@ -0,0 +1,113 @@
+package seekable
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Refresh re-reads the seek table to pick up frames appended to the archive
+// since it was opened, updating Size and FrameCount. It is meant for tailing
+// an archive whose producer keeps appending frames and rewriting the seek
+// table behind them.
+//
+// The frames the Reader already knows must be unchanged: if the new table
+// drops or alters any of them, Refresh fails with an error wrapping
+// ErrSourceChanged and the Reader keeps its current view. Frames are compared
+// by their seek table entries, including checksums when the table has them.
+// Reads of existing frames therefore stay valid across a refresh, and pinned
+// and cached frames are kept. If the archive has not grown, Refresh changes
+// nothing.
+//
+// The new size of the source is taken from the file for file-backed archives
+// and from a Size method, as on *bytes.Reader and *io.SectionReader, for
+// sources given to OpenReader; other sources cannot be refreshed. The core
+// decoder reads the seek table only once, so a Reader that has grown decodes
+// frames in Go from then on. Sub-readers cover a fixed window and cannot be
+// refreshed, nor can archives opened with OpenWithSidecar, whose seek table
+// is not in the source; open the new table instead. Refresh waits for reads
+// in progress to finish. Accessors such as Size and FrameBoundaries may run
+// alongside it and see the seek table from before or after the refresh.
+func (r *Reader) Refresh() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.h == nil || r.src == nil {
+		return ErrClosed
+	}
+	if r.sub {
+		return errors.New("seekable: cannot refresh a sub-reader")
+	}
+	cur := r.view.Load().table
+	if cur.sidecar {
+		return errors.New("seekable: cannot refresh an archive with a sidecar seek table")
+	}
+
+	srcSize, modTime := r.srcSize, r.modTime
+	refreshed := false
+	if info, ok, err := r.h.stat(); ok {
+		if err != nil {
+			return err
+		}
+		srcSize, modTime = info.Size(), info.ModTime()
+		// A lazily opened file must be accepted in its new state to read the
+		// new table, and in its old state again if the refresh fails.
+		if l := r.h.lazy; l != nil {
+			prev := l.expect(info)
+			defer func() {
+				if !refreshed {
+					l.expect(prev)
+				}
+			}()
+		}
+	} else {
+		src := r.src
+		if t, ok := src.(*timeoutReaderAt); ok {
+			src = t.ra
+		}
+		sized, ok := src.(interface{ Size() int64 })
+		if !ok {
+			return errors.New("seekable: cannot refresh a source of unknown size")
+		}
+		srcSize = sized.Size()
+	}
+
+	table, err := tableFinder(cur.leading)(r.src, srcSize)
+	if err != nil {
+		return fmt.Errorf("refreshing seek table: %w", err)
+	}
+	if err := cur.extendedBy(table); err != nil {
+		return err
+	}
+	if err := r.checkTable(table); err != nil {
+		return err
+	}
+
+	if len(table.frames) == len(cur.frames) {
+		return nil
+	}
+
+	r.setTable(table)
+	r.srcSize, r.modTime = srcSize, modTime
+	// The core decoder and a fully decoded copy only cover the old frames.
+	r.ptr = nil
+	r.decoded = nil
+	refreshed = true
+	return nil
+}
+
+// extendedBy checks that next describes the frames of st unchanged, possibly
+// followed by more.
+func (st *seekTable) extendedBy(next *seekTable) error {
+	if len(next.frames) < len(st.frames) {
+		return fmt.Errorf("%w: seek table shrank from %d to %d frames", ErrSourceChanged, len(st.frames), len(next.frames))
+	}
+	if next.hasChecksums != st.hasChecksums {
+		return fmt.Errorf("%w: seek table checksums were added or removed", ErrSourceChanged)
+	}
+	for i, f := range st.frames {
+		if next.frames[i] != f {
+			return fmt.Errorf("%w: frame %d changed", ErrSourceChanged, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshFile(t *testing.T) {
+	path := writeArchive(t, buildArchive(testChunks[:2]...))
+	for name, opts := range map[string][]Option{
+		"core": nil,
+		"go":   {WithSizeChecks()},
+		"lazy": {WithLazyFD(time.Millisecond)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := os.WriteFile(path, buildArchive(testChunks[:2]...), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			r := openArchivePath(t, path, opts...)
+			if err := r.Refresh(); err != nil {
+				t.Fatalf("Refresh of an unchanged archive failed: %v", err)
+			}
+			if r.FrameCount() != 2 {
+				t.Fatalf("FrameCount() = %d, want 2", r.FrameCount())
+			}
+
+			// The producer appends frames over the old seek table.
+			if err := os.WriteFile(path, buildArchive(testChunks...), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if r.Size() != 12 {
+				t.Errorf("Size() = %d before Refresh, want 12", r.Size())
+			}
+			if err := r.Refresh(); err != nil {
+				t.Fatalf("Refresh failed: %v", err)
+			}
+			if r.Size() != uint64(len(testContent)) || r.FrameCount() != 4 {
+				t.Errorf("Size, FrameCount = %d, %d after Refresh", r.Size(), r.FrameCount())
+			}
+			if got, err := r.DecompressAll(); err != nil || string(got) != testContent {
+				t.Errorf("DecompressAll = (%q, %v)", got, err)
+			}
+			if _, err := r.SizeErr(); err != nil {
+				t.Errorf("SizeErr after Refresh: %v", err)
+			}
+		})
+	}
+}
+
+func TestRefreshReader(t *testing.T) {
+	var data []byte
+	src := &growingReaderAt{data: &data}
+	data = buildArchive(testChunks[:3]...)
+
+	r, err := OpenReader(src, src.Size())
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+	sub, err := r.SubReader(0, 1)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+
+	data = buildArchive(append(slices.Clone(testChunks), "-echo")...)
+	if err := r.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if got, err := r.ReadRange(0, r.Size()); err != nil || string(got) != testContent+"-echo" {
+		t.Errorf("ReadRange = (%q, %v)", got, err)
+	}
+	if err := sub.Refresh(); err == nil {
+		t.Error("Refresh of a sub-reader succeeded")
+	}
+
+	// Rewriting an existing frame is not an append.
+	data = buildArchive("alpha--", "bravo-", "charlie-", "delta", "-echo", "-foxtrot")
+	if err := r.Refresh(); !errors.Is(err, ErrSourceChanged) {
+		t.Errorf("Refresh after a rewrite: error = %v, want ErrSourceChanged", err)
+	}
+	data = buildArchive(testChunks[:2]...)
+	if err := r.Refresh(); !errors.Is(err, ErrSourceChanged) {
+		t.Errorf("Refresh after truncation: error = %v, want ErrSourceChanged", err)
+	}
+	if r.FrameCount() != 5 {
+		t.Errorf("FrameCount() = %d after failed refreshes, want 5", r.FrameCount())
+	}
+}
+
+func TestRefreshUnknownSize(t *testing.T) {
+	data := buildArchive(testChunks...)
+	r, err := OpenReader(struct{ io.ReaderAt }{bytes.NewReader(data)}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+	if err := r.Refresh(); err == nil {
+		t.Error("Refresh of a source without a size succeeded")
+	}
+
+	r.Close()
+	if err := r.Refresh(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Refresh after Close: error = %v, want ErrClosed", err)
+	}
+}
+
+// growingReaderAt reads from a buffer that the test replaces as it grows.
+type growingReaderAt struct {
+	data *[]byte
+}
+
+func (g *growingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return bytes.NewReader(*g.data).ReadAt(p, off)
+}
+
+func (g *growingReaderAt) Size() int64 {
+	return int64(len(*g.data))
+}
+
+func TestRefreshConcurrentAccessors(t *testing.T) {
+	chunks := make([]string, 16)
+	for i := range chunks {
+		chunks[i] = strings.Repeat(string(rune('a'+i)), 10+i)
+	}
+	src := &swappingReaderAt{}
+	src.store(buildArchive(chunks[:1]...))
+
+	r, err := OpenReader(src, src.Size())
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+	out, err := os.CreateTemp(t.TempDir(), "copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	done := make(chan error)
+	go func() {
+		for k := 2; k <= len(chunks); k++ {
+			src.store(buildArchive(chunks[:k]...))
+			for i := 0; i < 2; i++ {
+				// The second refresh finds nothing new.
+				if err := r.Refresh(); err != nil {
+					done <- err
+					return
+				}
+			}
+		}
+		done <- nil
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Refresh failed: %v", err)
+			}
+			if r.FrameCount() != uint64(len(chunks)) {
+				t.Errorf("FrameCount() = %d, want %d", r.FrameCount(), len(chunks))
+			}
+			return
+		default:
+		}
+
+		size := r.Size()
+		if b := r.FrameBoundaries(); b[len(b)-1] < size {
+			t.Fatalf("FrameBoundaries end at %d, before Size() %d", b[len(b)-1], size)
+		}
+		r.FrameCount()
+		r.MaxFrameDecompressedSize()
+		r.IsFrameBoundary(10)
+		if _, err := r.ResolveOffsets([]uint64{0, size - 1}); err != nil {
+			t.Fatalf("ResolveOffsets failed: %v", err)
+		}
+		if _, _, err := r.CompressedRangeFor(0, size); err != nil {
+			t.Fatalf("CompressedRangeFor failed: %v", err)
+		}
+		if _, err := r.ReadFrame(0); err != nil {
+			t.Fatalf("ReadFrame failed: %v", err)
+		}
+		if _, err := r.ReadFrames(0, 1); err != nil {
+			t.Fatalf("ReadFrames failed: %v", err)
+		}
+		if _, _, err := r.FrameChecksum(0); err != nil {
+			t.Fatalf("FrameChecksum failed: %v", err)
+		}
+		r.ContentHash()
+		r.ListEntries()
+		r.Entries()
+		r.ReadEntry("missing")
+		if _, err := r.Stat(); err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		rr, err := r.RangeReader(0, size)
+		if err != nil {
+			t.Fatalf("RangeReader failed: %v", err)
+		}
+		if _, err := io.ReadAll(rr); err != nil {
+			t.Fatalf("reading the RangeReader failed: %v", err)
+		}
+		rr.Close()
+		s, err := r.NewStream(0, size)
+		if err != nil {
+			t.Fatalf("NewStream failed: %v", err)
+		}
+		if _, err := io.ReadAll(s); err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+		s.Close()
+		if _, err := r.CopyRangeToFile(out, 0, size); err != nil {
+			t.Fatalf("CopyRangeToFile failed: %v", err)
+		}
+	}
+}
+
+// swappingReaderAt reads from a buffer that may be replaced while it is read.
+type swappingReaderAt struct {
+	data atomic.Pointer[[]byte]
+}
+
+func (s *swappingReaderAt) store(data []byte) {
+	s.data.Store(&data)
+}
+
+func (s *swappingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return bytes.NewReader(*s.data.Load()).ReadAt(p, off)
+}
+
+func (s *swappingReaderAt) Size() int64 {
+	return int64(len(*s.data.Load()))
+}
@@ -5,9 +5,19 @@ package seekable
 */
 import "C"
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -16,65 +26,730 @@ func Version() string {
 	return "0.1.1"
 }
 
+// ErrClosed is returned by reads and other operations on a closed Reader.
+var ErrClosed = errors.New("seekable: reader is closed")
+
+// ErrSuspiciousArchive is returned by the open functions when the seek table
+// declares more decompressed content than WithMaxExpansionRatio allows for the
+// size of its frames.
+var ErrSuspiciousArchive = errors.New("seekable: suspicious archive")
+
+// ErrOutOfRange is returned by the frame-level methods for a frame index at
+// or past FrameCount(), including any index on an archive with no frames.
+var ErrOutOfRange = errors.New("seekable: frame index out of range")
+
 // Reader provides random access to seekable zstd archives.
+//
+// An archive may hold no frames at all. Such an archive has a Size and
+// FrameCount of 0, every ReadAt returns io.EOF, ReadRange accepts only the
+// empty range [0, 0), and DecompressAll returns an empty slice.
+//
+// The seek table is the source of truth for the layout of an archive: Size,
+// frame boundaries and all offset math come from it, and frame headers are
+// never consulted. Frames written by streaming producers, which do not declare
+// their content size, are therefore read like any other. An archive whose
+// frames decode to a different size than the seek table records is corrupt;
+// reads from it may fail or return misplaced data.
+//
+// ReadAt, and the methods built on it, may be called concurrently with each
+// other and with Close. Close waits for reads in progress to finish, and
+// reads that start after it fail with ErrClosed, so the decoder is never freed
+// while a read uses it. The cursor methods (Read, Seek and WriteTo) are not
+// safe for concurrent use.
 type Reader struct {
+	// mu is held shared by reads while they use the decoder and source, and
+	// exclusively by Close while it releases them.
+	mu sync.RWMutex
+
 	ptr *C.SeekableDecoder
+	cfg config
+
+	// view holds the seek table and the sizes derived from it. Refresh swaps
+	// in a new view, so methods that do not hold mu load it once and work
+	// from that snapshot.
+	view atomic.Pointer[tableView]
+
+	// id identifies the archive content for frame caching.
+	id string
+	// modTime is the modification time of a file-backed archive.
+	modTime time.Time
+
+	// pinned holds the decoded frames kept resident by WithPinnedFrames.
+	pinned [][]byte
+
+	// pos is the cursor used by Read, Seek, WriteTo and DecodeInto.
+	pos int64
+
+	// pending holds decoded bytes starting at pendingOff that DecodeInto
+	// has not returned yet, backed by pendingBuf. It is valid only while the
+	// cursor is at pendingOff.
+	pending    []byte
+	pendingOff int64
+	pendingBuf []byte
+
+	// decoded holds the whole decompressed content of Readers opened with
+	// OpenFullyDecoded. ReadAt serves reads from it directly.
+	decoded []byte
+
+	// src holds the compressed archive. Frames are read from it when they are
+	// decoded in Go rather than by the core decoder (ptr is nil in that case).
+	src     io.ReaderAt
+	srcSize int64
+
+	// h owns the decoder and file, which sub-readers share.
+	h *handles
+
+	// sub is set on readers created by SubReader. base and frameBase locate
+	// the window in the archive: the decompressed offset and index of its
+	// first frame.
+	sub       bool
+	base      uint64
+	frameBase int
+}
+
+// tableView is a Reader's seek table together with its decompressed size and
+// frame count. These are read once at open, and again by Refresh, so Size,
+// FrameCount and ReadAt do not call into the core decoder.
+type tableView struct {
+	table      *seekTable
+	size       uint64
+	frameCount uint64
 }
 
-// Open opens a seekable zstd archive for reading.
-func Open(path string) (*Reader, error) {
+// setTable makes table the Reader's seek table.
+func (r *Reader) setTable(table *seekTable) {
+	r.view.Store(&tableView{table: table, size: table.size(), frameCount: uint64(len(table.frames))})
+}
+
+// Open opens a seekable zstd archive file for reading.
+//
+// The archive may be followed by trailing data, such as a signature block
+// appended after the seek table; see TrailingBytes. An archive that is still
+// being written, with frames but no seek table, fails with ErrNotFinalized.
+//
+// A file that cannot be opened or read fails with the *fs.PathError from the
+// OS, so a missing file matches fs.ErrNotExist and an unreadable one
+// fs.ErrPermission. A file that is not a valid archive fails with an error
+// wrapping ErrInvalidArchive.
+func Open(path string, opts ...Option) (*Reader, error) {
+	cfg := newConfig(opts)
+	if cfg.decodeInGo() {
+		return openFile(path, cfg)
+	}
+
+	// The core decoder takes the path as a NUL-terminated UTF-8 string, which
+	// it converts to the platform's encoding (UTF-16 on Windows). Paths it
+	// cannot represent, such as non-UTF-8 names on Unix, are opened in Go,
+	// which passes them to the OS unchanged and rejects embedded NULs.
+	if !utf8.ValidString(path) || strings.IndexByte(path, 0) >= 0 {
+		return openFile(path, cfg)
+	}
+
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
 	ptr := C.seekable_open(cPath)
 	if ptr == nil {
-		errStr := C.seekable_last_error()
-		if errStr == nil {
-			return nil, errors.New("unknown error")
+		openErr := errors.New("unknown error")
+		if errStr := C.seekable_last_error(); errStr != nil {
+			openErr = errors.New(C.GoString(errStr))
+		}
+
+		// The core decoder expects the seek table at the very end of the
+		// file. Archives followed by trailing data are decoded in Go.
+		r, err := openFile(path, cfg)
+		if err == nil {
+			if r.view.Load().table.end < r.srcSize {
+				return r, nil
+			}
+			r.Close()
+		}
+		// The core decoder reports every failure as a message, so the Go
+		// error tells a file that cannot be read from one that is not an
+		// archive.
+		if err != nil && (errors.Is(err, ErrNotFinalized) || !errors.Is(err, ErrInvalidArchive)) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, openErr)
+	}
+
+	f, table, info, err := loadSeekTable(path, cfg.tableCache)
+	if err != nil {
+		C.seekable_close(ptr)
+		return nil, err
+	}
+
+	// Archives of magicless frames parse, but only decode in Go.
+	if ml, err := table.firstFrameMagicless(f); ml || err != nil {
+		C.seekable_close(ptr)
+		f.Close()
+		if err != nil {
+			return nil, err
 		}
-		return nil, errors.New(C.GoString(errStr))
+		return openFile(path, cfg)
 	}
 
-	return &Reader{ptr: ptr}, nil
+	r := &Reader{ptr: ptr, cfg: cfg, src: cfg.source(f), srcSize: info.Size(), h: &handles{refs: 1, ptr: ptr, file: f}}
+	r.setTable(table)
+	r.setFileInfo(path, info)
+	return r.finishOpen()
+}
+
+// loadSeekTable opens the archive at path and parses its seek table. The file
+// stays open so frames can also be read in Go, for example by DeepValidate.
+func loadSeekTable(path string, cache *SeekTableCache) (*os.File, *seekTable, os.FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, nil, err
+	}
+
+	table, err := cache.load(path, info, false, func() (*seekTable, error) {
+		return readSeekTable(f, info.Size())
+	})
+	if err != nil {
+		f.Close()
+		return nil, nil, nil, err
+	}
+	return f, table, info, nil
+}
+
+// finishOpen applies the open-time options that need a usable Reader. The
+// Reader is closed if any of them fails.
+func (r *Reader) finishOpen() (*Reader, error) {
+	if len(r.cfg.dictionaries) > 0 {
+		dicts, err := newDictSet(r.cfg.dictionaries)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		r.cfg.dicts = dicts
+		r.h.dicts = dicts
+	}
+
+	if r.ptr != nil {
+		v := *r.view.Load()
+		v.size = uint64(C.seekable_size(r.ptr))
+		v.frameCount = uint64(C.seekable_frame_count(r.ptr))
+		r.view.Store(&v)
+	}
+
+	table := r.view.Load().table
+	if r.cfg.strictTable {
+		if err := r.Validate(); err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+	if r.cfg.hasKnownSize && table.size() != r.cfg.knownSize {
+		r.Close()
+		return nil, fmt.Errorf("seekable: archive holds %d bytes, known size is %d", table.size(), r.cfg.knownSize)
+	}
+	if err := r.checkTable(table); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	if err := r.pinFrames(r.cfg.pinnedFrames); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	if l := r.cfg.logger; l != nil {
+		l.LogAttrs(context.Background(), slog.LevelDebug, "seekable: opened archive",
+			slog.String("archive", r.id),
+			slog.Int("frames", len(table.frames)),
+			slog.Uint64("size", table.size()),
+			slog.Bool("core_decoder", r.ptr != nil))
+	}
+	return r, nil
+}
+
+// setFileInfo records the metadata of a file-backed archive.
+func (r *Reader) setFileInfo(path string, info os.FileInfo) {
+	r.modTime = info.ModTime()
+	r.setIdentity(fileIdentity(path, info))
+}
+
+// setIdentity records the archive identity, unless WithCacheKey supplied one.
+func (r *Reader) setIdentity(id string) {
+	if r.cfg.cacheKey != "" {
+		id = "key:" + r.cfg.cacheKey
+	}
+	r.id = id
+}
+
+// openFile opens an archive whose frames are decoded in Go rather than by
+// the core decoder. The seek table may be followed by trailing data.
+func openFile(path string, cfg config) (*Reader, error) {
+	return openFileWith(path, cfg, false)
+}
+
+// openFileWith is openFile for archives in the layout chosen by leading; see
+// tableFinder.
+func openFileWith(path string, cfg config, leading bool) (*Reader, error) {
+	open := os.Open
+	if cfg.noATime {
+		open = openNoATime
+	}
+	f, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, advice := range cfg.advice {
+		_ = fadvise(f, advice)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	src := cfg.source(f)
+	table, err := cfg.tableCache.load(path, info, leading, func() (*seekTable, error) {
+		return tableFinder(leading)(src, info.Size())
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := cfg.checkDecodeParams(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	h := &handles{refs: 1, file: f}
+	if cfg.lazyFDIdle > 0 {
+		lf := newLazyFile(path, f, info, open, cfg.advice, cfg.lazyFDIdle, cfg.logger)
+		h.file, h.closer, h.lazy = nil, lf, lf
+		src = cfg.source(lf)
+	}
+
+	r := &Reader{cfg: cfg, src: src, srcSize: info.Size(), h: h}
+	r.setTable(table)
+	r.setFileInfo(path, info)
+	return r.finishOpen()
+}
+
+// TrailingBytes returns the data stored after the seek table, such as an
+// application trailer or signature block. It returns an empty slice if the
+// seek table ends the archive, and ErrClosed after Close.
+func (r *Reader) TrailingBytes() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h == nil {
+		return nil, ErrClosed
+	}
+	v := r.view.Load()
+	if r.src == nil || v.table.end >= r.srcSize {
+		return []byte{}, nil
+	}
+
+	buf := make([]byte, r.srcSize-v.table.end)
+	if err := readFullAt(r.src, buf, v.table.end); err != nil {
+		return nil, fmt.Errorf("reading trailing data: %w", err)
+	}
+	return buf, nil
 }
 
 // Size returns the decompressed size in bytes.
 func (r *Reader) Size() uint64 {
-	return uint64(C.seekable_size(r.ptr))
+	return r.view.Load().size
+}
+
+// Size64 returns the decompressed size as an int64, the type io.SectionReader
+// and io.Seeker use for sizes and offsets, capped at math.MaxInt64 for the
+// (theoretical) archives larger than that.
+func (r *Reader) Size64() int64 {
+	return int64(min(r.view.Load().size, math.MaxInt64))
+}
+
+// AvailableAt returns the number of bytes available from offset off to the
+// end of the decompressed content, which is how many bytes a ReadAt at off
+// returns at most. It is 0 for off at or past Size(), and for negative off,
+// where ReadAt fails.
+func (r *Reader) AvailableAt(off int64) uint64 {
+	v := r.view.Load()
+	if off < 0 || uint64(off) >= v.size {
+		return 0
+	}
+	return v.size - uint64(off)
+}
+
+// ErrSourceChanged is returned by SizeErr when the compressed source no
+// longer holds the seek table the Reader was opened with.
+var ErrSourceChanged = errors.New("seekable: source changed since open")
+
+// SizeErr is like Size, but checks that the size can still be trusted. It
+// fails with an error wrapping ErrSourceChanged if the source no longer holds
+// the archive the Reader was opened with: the seek table footer is read again
+// and compared with the one read at open, and for file-backed archives the
+// file's size and modification time are compared as well. It returns
+// ErrClosed after Close, and the read error if the source cannot be read.
+//
+// The footer records the frame count, so for sources other than files a
+// rewrite that keeps the frame count goes unnoticed. Archives opened with
+// OpenWithSidecar keep their seek table outside the source, so only the file
+// checks apply to them. Size remains the cheap accessor: it never fails and
+// returns the size recorded at open.
+func (r *Reader) SizeErr() (uint64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h == nil || r.src == nil {
+		return 0, ErrClosed
+	}
+	v := r.view.Load()
+
+	if info, ok, err := r.h.stat(); ok {
+		if err != nil {
+			return 0, err
+		}
+		if info.Size() != r.srcSize || !info.ModTime().Equal(r.modTime) {
+			return 0, fmt.Errorf("%w: file size or modification time differs", ErrSourceChanged)
+		}
+	}
+
+	if v.table.sidecar {
+		return v.size, nil
+	}
+	var footer [seekTableFooterSize]byte
+	if err := readFullAt(r.src, footer[:], v.table.footerOff); err != nil {
+		return 0, fmt.Errorf("reading seek table footer: %w", err)
+	}
+	if footer != v.table.footer {
+		return 0, fmt.Errorf("%w: seek table footer at offset %d differs", ErrSourceChanged, v.table.footerOff)
+	}
+	return v.size, nil
 }
 
 // FrameCount returns the number of compressed frames.
 func (r *Reader) FrameCount() uint64 {
-	return uint64(C.seekable_frame_count(r.ptr))
+	return r.view.Load().frameCount
+}
+
+// MaxFrameDecompressedSize returns the largest decompressed size of any single
+// frame, or 0 for an empty archive. A buffer of this size can hold any frame.
+// It is computed from the seek table when the archive is opened.
+func (r *Reader) MaxFrameDecompressedSize() uint64 {
+	return r.view.Load().table.maxDecompSize
+}
+
+// FrameBoundaries returns the decompressed offset at which each frame starts,
+// followed by Size(). The result has FrameCount()+1 elements, so frame i spans
+// [b[i], b[i+1]). It is derived from the seek table and decodes nothing.
+func (r *Reader) FrameBoundaries() []uint64 {
+	v := r.view.Load()
+	b := make([]uint64, len(v.table.frames)+1)
+	for i, f := range v.table.frames {
+		b[i] = f.decompOffset
+	}
+	b[len(v.table.frames)] = v.table.size()
+	return b
+}
+
+// IsFrameBoundary reports whether the decompressed offset off is one of the
+// FrameBoundaries: the start of a frame, or Size(). Reads that start and end
+// on frame boundaries decode no more than they return.
+func (r *Reader) IsFrameBoundary(off uint64) bool {
+	v := r.view.Load()
+	if off == v.table.size() {
+		return true
+	}
+	i := v.table.frameIndex(off)
+	return i < len(v.table.frames) && v.table.frames[i].decompOffset == off
+}
+
+// ResolveOffsets returns the index of the frame holding each decompressed
+// offset in offsets, in the same order, so records can be grouped by frame
+// before they are read. The offsets are resolved in ascending order, each
+// search resuming from the previous frame, which makes a large batch cheaper
+// than a search of the whole seek table per offset. It decodes nothing and
+// fails if any offset is at or past Size().
+func (r *Reader) ResolveOffsets(offsets []uint64) ([]uint64, error) {
+	v := r.view.Load()
+	size := v.table.size()
+	order := make([]int, len(offsets))
+	for i, off := range offsets {
+		if off >= size {
+			return nil, fmt.Errorf("offset (%d) out of range (size %d)", off, size)
+		}
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return offsets[order[a]] < offsets[order[b]] })
+
+	frames := v.table.frames
+	indices := make([]uint64, len(offsets))
+	f := 0
+	for _, i := range order {
+		off := offsets[i]
+		f += sort.Search(len(frames)-f, func(j int) bool {
+			e := frames[f+j]
+			return e.decompOffset+e.decompSize > off
+		})
+		indices[i] = uint64(f)
+	}
+	return indices, nil
+}
+
+// ArchiveInfo describes an open archive.
+type ArchiveInfo struct {
+	// Size is the decompressed size in bytes.
+	Size uint64
+	// CompressedSize is the size of the archive in bytes, including the seek
+	// table but excluding any trailing data.
+	CompressedSize uint64
+	// FrameCount is the number of frames.
+	FrameCount uint64
+	// HasChecksums reports whether the seek table stores frame checksums.
+	HasChecksums bool
+	// ModTime is the modification time of a file-backed archive.
+	ModTime time.Time
+}
+
+// Stat returns metadata about the archive. It does not decode any frames.
+func (r *Reader) Stat() (*ArchiveInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h == nil {
+		return nil, ErrClosed
+	}
+	v := r.view.Load()
+
+	return &ArchiveInfo{
+		Size:           v.size,
+		CompressedSize: uint64(v.table.end),
+		FrameCount:     r.FrameCount(),
+		HasChecksums:   v.table.hasChecksums,
+		ModTime:        r.modTime,
+	}, nil
+}
+
+// CompressedRangeFor returns the span of compressed bytes, as an offset and
+// length into the archive, that holds the frames covering the decompressed
+// range [start, end).
+//
+// Fetching exactly that span (for example with a single HTTP Range request)
+// is enough to decode the range; each frame in it decodes independently.
+func (r *Reader) CompressedRangeFor(start, end uint64) (coff, clen uint64, err error) {
+	v := r.view.Load()
+	if start >= end {
+		return 0, 0, fmt.Errorf("invalid range: start (%d) >= end (%d)", start, end)
+	}
+
+	if end > v.size {
+		return 0, 0, fmt.Errorf("range end (%d) exceeds size (%d)", end, v.size)
+	}
+
+	first := v.table.frames[v.table.frameIndex(start)]
+	last := v.table.frames[v.table.frameIndex(end-1)]
+	coff = first.compOffset
+	return coff, last.compOffset + last.compSize - coff, nil
+}
+
+// ReadCompressedFrame returns a copy of the raw compressed bytes of frame
+// index, exactly as stored in the archive. The result is a complete zstd frame
+// that any zstd decoder can decompress on its own.
+func (r *Reader) ReadCompressedFrame(index uint64) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h == nil {
+		return nil, ErrClosed
+	}
+	if err := r.checkFrameIndex(index); err != nil {
+		return nil, err
+	}
+	return r.readCompressed(context.Background(), int(index), nil)
+}
+
+// ReadFrame returns the decompressed content of frame index. An empty frame
+// yields an empty, non-nil slice. An index at or past FrameCount() returns
+// ErrOutOfRange.
+func (r *Reader) ReadFrame(index uint64) ([]byte, error) {
+	if err := r.checkFrameIndex(index); err != nil {
+		return nil, err
+	}
+	f := r.view.Load().table.frames[index]
+	return r.ReadRange(f.decompOffset, f.decompOffset+f.decompSize)
+}
+
+// checkFrameIndex returns an error wrapping ErrOutOfRange unless index names
+// a frame of r.
+func (r *Reader) checkFrameIndex(index uint64) error {
+	v := r.view.Load()
+	if index >= uint64(len(v.table.frames)) {
+		return fmt.Errorf("%w: frame index (%d) out of range (%d frames)", ErrOutOfRange, index, len(v.table.frames))
+	}
+	return nil
+}
+
+// ReadFrames returns the decompressed content of frames [first, last)
+// concatenated in one buffer. first == last yields an empty, non-nil slice
+// and no error for any first up to FrameCount(), so ReadFrames(0, 0)
+// succeeds on an archive with no frames. first > last is rejected, and last
+// past FrameCount() returns ErrOutOfRange.
+func (r *Reader) ReadFrames(first, last uint64) ([]byte, error) {
+	v := r.view.Load()
+	count := uint64(len(v.table.frames))
+	if first > last {
+		return nil, fmt.Errorf("invalid frame range: first (%d) > last (%d)", first, last)
+	}
+	if last > count {
+		return nil, fmt.Errorf("%w: frame range end (%d) exceeds frame count (%d)", ErrOutOfRange, last, count)
+	}
+	if first == last {
+		return []byte{}, nil
+	}
+
+	start := v.table.frames[first].decompOffset
+	end := v.table.frames[last-1].decompOffset + v.table.frames[last-1].decompSize
+	return r.ReadRange(start, end)
+}
+
+// FrameChecksum returns the checksum the seek table stores for frame index:
+// the low 32 bits of the XXH64 of its decompressed content. The boolean is
+// false if the seek table has no checksums. Nothing is read or verified, so
+// comparing checksums across copies of an archive is cheap.
+func (r *Reader) FrameChecksum(index uint64) (uint32, bool, error) {
+	v := r.view.Load()
+	if err := r.checkFrameIndex(index); err != nil {
+		return 0, false, err
+	}
+	if !v.table.hasChecksums {
+		return 0, false, nil
+	}
+	return v.table.frames[index].checksum, true, nil
 }
 
 // ReadRange reads decompressed bytes in the range [start, end).
+//
+// An empty range (start == end) within the archive yields an empty, non-nil
+// slice and no error. Ranges with start > end or end > Size() are rejected.
 func (r *Reader) ReadRange(start, end uint64) ([]byte, error) {
-	if start >= end {
-		return nil, fmt.Errorf("invalid range: start (%d) >= end (%d)", start, end)
+	return r.ReadRangeContext(context.Background(), start, end)
+}
+
+// ReadRangeContext is like ReadRange but stops between frames once ctx is
+// done and returns an error wrapping ctx.Err(). As with ReadAtContext, a frame
+// whose decoding has started is decoded in full. No partial content is
+// returned on failure; the buffer is dropped.
+func (r *Reader) ReadRangeContext(ctx context.Context, start, end uint64) ([]byte, error) {
+	if start > end {
+		return nil, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
 	}
 
 	if end > r.Size() {
 		return nil, fmt.Errorf("range end (%d) exceeds size (%d)", end, r.Size())
 	}
 
+	if start == end {
+		return []byte{}, nil
+	}
+
 	size := end - start
+	if err := r.checkReadSize(size); err != nil {
+		return nil, err
+	}
 	buf := make([]byte, size)
 
-	n, err := r.ReadAt(buf, int64(start))
-	if err != nil {
-		return nil, err
+	n, err := r.ReadAtContext(ctx, buf, int64(start))
+	if n == len(buf) {
+		return buf, nil
 	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, err
+}
 
-	if n != len(buf) {
-		return buf[:n], nil
+// ReadRangeAligned is like ReadRange, for consumers such as SIMD parsers that
+// need aligned, padded input. The returned slice has length end-start and its
+// first byte is at an address that is a multiple of align, which must be a
+// power of two. Its capacity is at least end-start+pad, and the pad bytes
+// after the content are zero, so the slice may be resliced up to
+// [:end-start+pad] to read past the end of the content.
+//
+// Go's allocator makes no alignment promise for byte slices, so the buffer is
+// over-allocated by align bytes and the slice starts at the first aligned
+// address in it. Heap objects are never moved, so the alignment holds for as
+// long as the slice is in use. This holds even for an empty range, whose
+// result is an empty slice that still points into an aligned buffer.
+func (r *Reader) ReadRangeAligned(start, end uint64, align, pad int) ([]byte, error) {
+	if align <= 0 || align&(align-1) != 0 {
+		return nil, fmt.Errorf("invalid alignment %d: must be a power of two", align)
+	}
+	if pad < 0 {
+		return nil, fmt.Errorf("invalid padding %d", pad)
+	}
+	if start > end {
+		return nil, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
 	}
 
-	return buf, nil
+	if end > r.Size() {
+		return nil, fmt.Errorf("range end (%d) exceeds size (%d)", end, r.Size())
+	}
+
+	n := int(end - start)
+	buf := make([]byte, n+pad+align)
+	skip := int(-uintptr(unsafe.Pointer(&buf[0])) & uintptr(align-1))
+	out := buf[skip : skip+n : skip+n+pad]
+
+	if n > 0 {
+		if err := r.readChunk(out, start); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Tail returns the last n decompressed bytes.
+//
+// If n exceeds Size(), the whole content is returned. Only the frames that
+// overlap the requested tail are decompressed.
+func (r *Reader) Tail(n uint64) ([]byte, error) {
+	size := r.Size()
+	if n > size {
+		n = size
+	}
+	if n == 0 {
+		return []byte{}, nil
+	}
+	return r.ReadRange(size-n, size)
 }
 
 // ReadAt implements io.ReaderAt.
+//
+// A read that ends before Size() fills p and returns a nil error. A read cut
+// short by Size() returns the bytes up to Size() with io.EOF, unless EOF is
+// deferred with WithEOFAfterFullRead(false). A read that fills p and ends
+// exactly at Size() returns a nil error, or io.EOF with WithStrictReaderAt.
+// A read starting at or past Size() returns 0, io.EOF.
+//
+// Ranges are decoded one frame at a time, so the longest uninterruptible
+// step of a read, including any call into the core decoder, is decoding a
+// single frame. ReadAtContext uses this to stop between frames.
 func (r *Reader) ReadAt(p []byte, off int64) (n int, err error) {
+	return r.ReadAtContext(context.Background(), p, off)
+}
+
+// ReadAtContext is like ReadAt but stops between frames once ctx is done,
+// returning the bytes read so far and an error wrapping ctx.Err(). A frame
+// whose decoding has started is decoded in full, so cancellation takes effect
+// within one frame's decode time.
+func (r *Reader) ReadAtContext(ctx context.Context, p []byte, off int64) (n int, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h == nil {
+		return 0, ErrClosed
+	}
+
 	if off < 0 {
 		return 0, errors.New("seekable: negative offset")
 	}
@@ -100,44 +775,208 @@ func (r *Reader) ReadAt(p []byte, off int64) (n int, err error) {
 	if end <= start {
 		return 0, io.EOF
 	}
+	if err := r.checkReadSize(end - start); err != nil {
+		return 0, err
+	}
 
-	cLen := C.uintptr_t(len(p))
-
-	res := C.seekable_read_range(
-		r.ptr,
-		C.uint64_t(start),
-		C.uint64_t(end),
-		(*C.uint8_t)(unsafe.Pointer(&p[0])),
-		&cLen,
-	)
+	var bytesRead int
+	if r.decoded != nil {
+		bytesRead = copy(p, r.decoded[start:end])
+	} else if r.readsByFrame() {
+		n, err := r.readFrames(ctx, p[:end-start], start)
+		if err != nil {
+			return n, fmt.Errorf("read failed: %w", err)
+		}
+		bytesRead = n
+	} else {
+		n, err := r.readCore(ctx, p[:end-start], start)
+		if err != nil {
+			return n, fmt.Errorf("read failed: %w", err)
+		}
+		bytesRead = n
 
-	if res < 0 {
-		errStr := C.seekable_last_error()
-		if errStr == nil {
-			return 0, errors.New("read failed: unknown error")
+		if l := r.cfg.logger; l != nil {
+			l.LogAttrs(context.Background(), slog.LevelDebug, "seekable: decoded range",
+				slog.Uint64("start", start),
+				slog.Uint64("end", end),
+				slog.Int("size", bytesRead))
 		}
-		return 0, fmt.Errorf("read failed: %s", C.GoString(errStr))
 	}
 
-	bytesRead := int(cLen)
+	atEOF := start+uint64(bytesRead) == r.Size()
+	switch {
+	case bytesRead < len(p) && !atEOF:
+		// The decoder produced less than the seek table promised.
+		return bytesRead, fmt.Errorf("read failed: %w", io.ErrUnexpectedEOF)
+	case atEOF && r.cfg.strictReaderAt:
+		return bytesRead, io.EOF
+	case bytesRead < len(p) && r.cfg.eofAfterFullRead:
+		return bytesRead, io.EOF
+	}
+
+	return bytesRead, nil
+}
 
-	if bytesRead < len(p) {
-		// Short read usually implies EOF in ReadAt semantics if we hit the end
-		if start+uint64(bytesRead) == r.Size() {
-			return bytesRead, io.EOF
+// ReadAtv reads the decompressed bytes starting at off into bufs in order,
+// as if they were one buffer of their combined length, and returns the total
+// number of bytes read. Each frame is decoded once even when it spans several
+// buffers, and runs of whole frames that fit in one buffer are read straight
+// into it. The error follows the ReadAt policy for the combined length.
+func (r *Reader) ReadAtv(bufs [][]byte, off int64) (int, error) {
+	v := r.view.Load()
+	r.mu.RLock()
+	closed := r.h == nil
+	r.mu.RUnlock()
+	if closed {
+		return 0, ErrClosed
+	}
+
+	if off < 0 {
+		return 0, errors.New("seekable: negative offset")
+	}
+
+	total := 0
+	for _, b := range bufs {
+		total += len(b)
+	}
+	if total == 0 {
+		return 0, nil
+	}
+
+	size := v.size
+	start := uint64(off)
+	if start >= size {
+		return 0, io.EOF
+	}
+	end := min(start+uint64(total), size)
+	if err := r.checkReadSize(end - start); err != nil {
+		return 0, err
+	}
+
+	frames := v.table.frames
+	n := 0
+	bi, bo := 0, 0
+	var scratch []byte
+	for pos := start; pos < end; {
+		for bo == len(bufs[bi]) {
+			bi, bo = bi+1, 0
 		}
+		room := bufs[bi][bo:]
+
+		// Read the whole frames that fit in the rest of this buffer directly.
+		i := v.table.frameIndex(pos)
+		chunkEnd := pos
+		for ; i < len(frames) && chunkEnd < end; i++ {
+			frameEnd := min(frames[i].decompOffset+frames[i].decompSize, end)
+			if frameEnd-pos > uint64(len(room)) {
+				break
+			}
+			chunkEnd = frameEnd
+		}
+		if chunkEnd > pos {
+			k := int(chunkEnd - pos)
+			if err := r.readChunk(room[:k], pos); err != nil {
+				return n, err
+			}
+			n, bo, pos = n+k, bo+k, chunkEnd
+			continue
+		}
+
+		// Frame i spans buffers, so decode it once and scatter it.
+		chunkEnd = min(frames[i].decompOffset+frames[i].decompSize, end)
+		k := int(chunkEnd - pos)
+		if cap(scratch) < k {
+			scratch = make([]byte, k)
+		}
+		chunk := scratch[:k]
+		if err := r.readChunk(chunk, pos); err != nil {
+			return n, err
+		}
+		for len(chunk) > 0 {
+			for bo == len(bufs[bi]) {
+				bi, bo = bi+1, 0
+			}
+			c := copy(bufs[bi][bo:], chunk)
+			chunk = chunk[c:]
+			bo += c
+		}
+		n, pos = n+k, chunkEnd
 	}
 
-	return bytesRead, nil
+	switch {
+	case end == size && r.cfg.strictReaderAt:
+		return n, io.EOF
+	case n < total && r.cfg.eofAfterFullRead:
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// ReadAtLeast reads at least min bytes starting at off into p, with the
+// semantics of io.ReadAtLeast: it returns io.ErrShortBuffer if len(p) < min,
+// io.EOF if no bytes were read because off is at or past Size(), and
+// io.ErrUnexpectedEOF if the content ends after fewer than min bytes. The
+// error is nil if and only if n >= min.
+//
+// Beyond min, it fills p only as far as the end of the frame holding byte
+// off+min-1, so it never decodes a frame the first min bytes do not need.
+func (r *Reader) ReadAtLeast(p []byte, off int64, min int) (int, error) {
+	v := r.view.Load()
+	if len(p) < min {
+		return 0, io.ErrShortBuffer
+	}
+	if off < 0 {
+		return 0, errors.New("seekable: negative offset")
+	}
+	if min <= 0 {
+		return 0, nil
+	}
+
+	size := v.size
+	start := uint64(off)
+	if start >= size {
+		return 0, io.EOF
+	}
+
+	want := size - start
+	if last := start + uint64(min) - 1; last < size {
+		f := v.table.frames[v.table.frameIndex(last)]
+		want = f.decompOffset + f.decompSize - start
+	}
+	if want > uint64(len(p)) {
+		want = uint64(len(p))
+	}
+
+	n, err := r.ReadAt(p[:want], off)
+	if n >= min {
+		return n, nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
 }
 
 // Close releases resources. Safe to call multiple times.
+//
+// The decoder is shared with any sub-readers created by SubReader and is only
+// freed once all of them are closed as well.
 func (r *Reader) Close() error {
-	if r.ptr != nil {
-		C.seekable_close(r.ptr)
-		r.ptr = nil
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := r.h
+	if h == nil {
+		return nil
 	}
-	return nil
+
+	r.h = nil
+	r.ptr = nil
+	r.src = nil
+	r.pinned = nil
+	r.decoded = nil
+	r.pending, r.pendingBuf = nil, nil
+	return h.release()
 }
 
 // Ensure Reader implements io.Closer and io.ReaderAt
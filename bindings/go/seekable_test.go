@@ -1,10 +1,24 @@
 package seekable
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 func TestOpen(t *testing.T) {
@@ -79,3 +93,1403 @@ func TestOpen(t *testing.T) {
 		t.Errorf("Expected n=0 at EOF, got %d", n)
 	}
 }
+
+// openHello opens the shared "Hello World" fixture with the given options.
+func openHello(t testing.TB, opts ...Option) *Reader {
+	t.Helper()
+	return openFixture(t, "hello.szst", opts...)
+}
+
+// openFixture opens a shared fixture from tests/fixtures.
+func openFixture(t testing.TB, name string, opts ...Option) *Reader {
+	t.Helper()
+	wd, _ := os.Getwd()
+	fixturePath := filepath.Join(wd, "../../tests/fixtures", name)
+
+	r, err := Open(fixturePath, opts...)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", fixturePath, err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestReadAtEOFAfterFullRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr error
+	}{
+		{"default is spec-strict", nil, io.EOF},
+		{"enabled", []Option{WithEOFAfterFullRead(true)}, io.EOF},
+		{"deferred", []Option{WithEOFAfterFullRead(false)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := openHello(t, tt.opts...)
+
+			// Short read that reaches the end of the content.
+			buf := make([]byte, 8)
+			n, err := r.ReadAt(buf, 6)
+			if n != 5 {
+				t.Errorf("Expected n=5, got %d", n)
+			}
+			if err != tt.wantErr {
+				t.Errorf("Expected err=%v, got %v", tt.wantErr, err)
+			}
+			if string(buf[:n]) != "World" {
+				t.Errorf("Expected 'World', got '%s'", string(buf[:n]))
+			}
+
+			// A full read ending exactly at Size() reports EOF only with
+			// WithStrictReaderAt.
+			n, err = r.ReadAt(buf[:5], 6)
+			if n != 5 || err != nil {
+				t.Errorf("Expected (5, nil), got (%d, %v)", n, err)
+			}
+
+			// The next read starting at Size() always reports EOF.
+			n, err = r.ReadAt(buf, 11)
+			if n != 0 || err != io.EOF {
+				t.Errorf("Expected (0, EOF), got (%d, %v)", n, err)
+			}
+		})
+	}
+}
+
+func TestReadAtBoundaries(t *testing.T) {
+	modes := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"deferred", []Option{WithEOFAfterFullRead(false)}},
+		{"strict", []Option{WithStrictReaderAt()}},
+		{"strict deferred", []Option{WithStrictReaderAt(), WithEOFAfterFullRead(false)}},
+	}
+
+	// want holds the expected error for each mode, in the order above.
+	tests := []struct {
+		name  string
+		off   int64
+		len   int
+		wantN int
+		want  [4]error
+	}{
+		{"within frame", 2, 2, 2, [4]error{nil, nil, nil, nil}},
+		{"across frames before end", 3, 6, 6, [4]error{nil, nil, nil, nil}},
+		{"last byte exactly", 9, 1, 1, [4]error{nil, nil, io.EOF, io.EOF}},
+		{"whole content exactly", 0, 10, 10, [4]error{nil, nil, io.EOF, io.EOF}},
+		{"one past end", 0, 11, 10, [4]error{io.EOF, nil, io.EOF, io.EOF}},
+		{"short in last frame", 8, 5, 2, [4]error{io.EOF, nil, io.EOF, io.EOF}},
+		{"at size", 10, 1, 0, [4]error{io.EOF, io.EOF, io.EOF, io.EOF}},
+		{"past size", 20, 4, 0, [4]error{io.EOF, io.EOF, io.EOF, io.EOF}},
+		{"empty at size", 10, 0, 0, [4]error{nil, nil, nil, nil}},
+		{"empty past size", 20, 0, 0, [4]error{nil, nil, nil, nil}},
+	}
+
+	const content = "abcdefghij"
+	for i, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			r := openArchive(t, []string{"abcd", "efgh", "ij"}, mode.opts...)
+			for _, tt := range tests {
+				buf := make([]byte, tt.len)
+				n, err := r.ReadAt(buf, tt.off)
+				if n != tt.wantN || err != tt.want[i] {
+					t.Errorf("%s: ReadAt(len %d, off %d) = (%d, %v), want (%d, %v)",
+						tt.name, tt.len, tt.off, n, err, tt.wantN, tt.want[i])
+				}
+				if n > 0 && string(buf[:n]) != content[tt.off:tt.off+int64(n)] {
+					t.Errorf("%s: read %q", tt.name, buf[:n])
+				}
+			}
+
+			// Whole-range helpers are unaffected by the EOF policy.
+			if got, err := r.ReadRange(6, 10); err != nil || string(got) != "ghij" {
+				t.Errorf("ReadRange(6, 10) = %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestTail(t *testing.T) {
+	r := openHello(t)
+
+	tests := []struct {
+		name string
+		n    uint64
+		want string
+	}{
+		{"zero", 0, ""},
+		{"suffix", 5, "World"},
+		{"exact size", 11, "Hello World"},
+		{"larger than size", 100, "Hello World"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Tail(tt.n)
+			if err != nil {
+				t.Fatalf("Tail(%d) failed: %v", tt.n, err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Tail(%d) = '%s', want '%s'", tt.n, string(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestTrailingBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		trailer string
+	}{
+		{"signature block", "SIGNATURE-BLOCK"},
+		{"larger than scan window", strings.Repeat("x", 200*1024) + "SIGNATURE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append(buildArchive(testChunks...), tt.trailer...)
+
+			r, err := Open(writeArchive(t, data))
+			if err != nil {
+				t.Fatalf("Open with trailing data failed: %v", err)
+			}
+			defer r.Close()
+
+			if r.Size() != uint64(len(testContent)) {
+				t.Errorf("Expected size %d, got %d", len(testContent), r.Size())
+			}
+			if r.FrameCount() != uint64(len(testChunks)) {
+				t.Errorf("Expected %d frames, got %d", len(testChunks), r.FrameCount())
+			}
+
+			got, err := r.ReadRange(4, 15)
+			if err != nil {
+				t.Fatalf("ReadRange(4, 15) failed: %v", err)
+			}
+			if string(got) != testContent[4:15] {
+				t.Errorf("Expected '%s', got '%s'", testContent[4:15], string(got))
+			}
+
+			all, err := r.DecompressAll()
+			if err != nil {
+				t.Fatalf("DecompressAll failed: %v", err)
+			}
+			if string(all) != testContent {
+				t.Errorf("Expected '%s', got '%s'", testContent, string(all))
+			}
+
+			tb, err := r.TrailingBytes()
+			if err != nil {
+				t.Fatalf("TrailingBytes failed: %v", err)
+			}
+			if string(tb) != tt.trailer {
+				t.Errorf("Expected %d trailing bytes, got %d", len(tt.trailer), len(tb))
+			}
+		})
+	}
+}
+
+func TestTrailingBytesScanLimit(t *testing.T) {
+	data := append(buildArchive(testChunks...), make([]byte, maxTrailingData+1)...)
+	if _, err := OpenBytes(data); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("OpenBytes with %d trailing bytes = %v, want ErrInvalidArchive", maxTrailingData+1, err)
+	}
+
+	// A trailer just within the limit is still found.
+	data = data[:len(data)-maxTrailingData/2]
+	r, err := OpenBytes(data)
+	if err != nil {
+		t.Fatalf("OpenBytes with %d trailing bytes failed: %v", maxTrailingData/2+1, err)
+	}
+	defer r.Close()
+	if r.Size() != uint64(len(testContent)) {
+		t.Errorf("Size() = %d, want %d", r.Size(), len(testContent))
+	}
+}
+
+func TestTrailingBytesNone(t *testing.T) {
+	r := openHello(t)
+
+	tb, err := r.TrailingBytes()
+	if err != nil {
+		t.Fatalf("TrailingBytes failed: %v", err)
+	}
+	if len(tb) != 0 {
+		t.Errorf("Expected no trailing bytes, got %q", tb)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	if _, err := Open(writeArchive(t, []byte("definitely not an archive"))); err == nil {
+		t.Error("Expected error opening invalid archive")
+	}
+}
+
+func TestOpenTooManyFrames(t *testing.T) {
+	// A footer claiming one frame more than the format allows.
+	data := binary.LittleEndian.AppendUint32(nil, skippableMagicSeekTable)
+	data = binary.LittleEndian.AppendUint32(data, seekTableFooterSize)
+	data = binary.LittleEndian.AppendUint32(data, maxFrames+1)
+	data = append(data, 0)
+	data = binary.LittleEndian.AppendUint32(data, seekableMagic)
+
+	if _, err := OpenBytes(data); !errors.Is(err, ErrTooManyFrames) {
+		t.Errorf("OpenBytes() = %v, want ErrTooManyFrames", err)
+	}
+}
+
+func TestWithDecodeParam(t *testing.T) {
+	path := writeArchive(t, buildArchive(testChunks...))
+
+	// The test frames declare a 2 MiB window (window log 21).
+	r := openArchivePath(t, path, WithDecodeParam(DecodeParamWindowLogMax, 21))
+	got, err := r.ReadRange(0, uint64(len(testContent)))
+	if err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if string(got) != testContent {
+		t.Errorf("Expected '%s', got '%s'", testContent, string(got))
+	}
+
+	limited := openArchivePath(t, path, WithDecodeParam(DecodeParamWindowLogMax, 10))
+	if _, err := limited.ReadRange(0, 5); err == nil {
+		t.Error("Expected window log limit to reject frame")
+	}
+
+	if _, err := Open(path, WithDecodeParam(DecodeParamWindowLogMax, 1000)); err == nil {
+		t.Error("Expected out-of-range parameter to be rejected")
+	}
+	if _, err := Open(path, WithDecodeParam(99999, 1)); err == nil {
+		t.Error("Expected unknown parameter to be rejected")
+	}
+}
+
+func TestStat(t *testing.T) {
+	path := writeArchive(t, buildArchive(testChunks...))
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+
+	r := openArchivePath(t, path)
+	info, err := r.Stat()
+	if err != nil {
+		t.Fatalf("Reader.Stat failed: %v", err)
+	}
+
+	if info.Size != uint64(len(testContent)) {
+		t.Errorf("Expected size %d, got %d", len(testContent), info.Size)
+	}
+	if info.CompressedSize != uint64(fi.Size()) {
+		t.Errorf("Expected compressed size %d, got %d", fi.Size(), info.CompressedSize)
+	}
+	if info.FrameCount != uint64(len(testChunks)) {
+		t.Errorf("Expected %d frames, got %d", len(testChunks), info.FrameCount)
+	}
+	if info.HasChecksums {
+		t.Error("Expected no checksums")
+	}
+	if !info.ModTime.Equal(fi.ModTime()) {
+		t.Errorf("Expected mtime %v, got %v", fi.ModTime(), info.ModTime)
+	}
+
+	r.Close()
+	if _, err := r.Stat(); err == nil {
+		t.Error("Expected error from Stat after Close")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	content := []byte(strings.Repeat("fingerprint ", 500))
+	fingerprint := func(data []byte) []byte {
+		t.Helper()
+		r, err := OpenBytes(data)
+		if err != nil {
+			t.Fatalf("OpenBytes failed: %v", err)
+		}
+		defer r.Close()
+		fp, err := r.Fingerprint()
+		if err != nil {
+			t.Fatalf("Fingerprint failed: %v", err)
+		}
+		return fp
+	}
+
+	a := fingerprint(writeTestArchive(t, content, WithFrameSize(1000)))
+	if b := fingerprint(writeTestArchive(t, content, WithFrameSize(1000))); !bytes.Equal(a, b) {
+		t.Error("Equal archives have different fingerprints")
+	}
+
+	changed := bytes.Clone(content)
+	changed[1500] = 'F'
+	if b := fingerprint(writeTestArchive(t, changed, WithFrameSize(1000))); bytes.Equal(a, b) {
+		t.Error("Archives with different content have equal fingerprints")
+	}
+	if b := fingerprint(writeTestArchive(t, content, WithFrameSize(2000))); bytes.Equal(a, b) {
+		t.Error("Archives with different frame sizes have equal fingerprints")
+	}
+
+	r := openArchive(t, testChunks)
+	r.Close()
+	if _, err := r.Fingerprint(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Fingerprint after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestCompressedRangeFor(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	// Raw frames add a 6-byte frame header and a 3-byte block header.
+	frameSize := func(chunk string) uint64 { return uint64(len(chunk)) + 9 }
+	f0, f1, f2, f3 := frameSize(testChunks[0]), frameSize(testChunks[1]), frameSize(testChunks[2]), frameSize(testChunks[3])
+
+	tests := []struct {
+		name       string
+		start, end uint64
+		coff, clen uint64
+		wantErr    bool
+	}{
+		{"first frame", 0, 6, 0, f0, false},
+		{"within second frame", 7, 9, f0, f1, false},
+		{"across frames", 4, 15, 0, f0 + f1 + f2, false},
+		{"last frame", 20, 25, f0 + f1 + f2, f3, false},
+		{"everything", 0, 25, 0, f0 + f1 + f2 + f3, false},
+		{"empty", 5, 5, 0, 0, true},
+		{"past end", 0, 26, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coff, clen, err := r.CompressedRangeFor(tt.start, tt.end)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CompressedRangeFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if coff != tt.coff || clen != tt.clen {
+				t.Errorf("CompressedRangeFor() = (%d, %d), want (%d, %d)", coff, clen, tt.coff, tt.clen)
+			}
+		})
+	}
+}
+
+func TestCompressedRangeForDecodes(t *testing.T) {
+	data := buildArchive(testChunks...)
+	r, err := OpenReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	coff, clen, err := r.CompressedRangeFor(8, 15)
+	if err != nil {
+		t.Fatalf("CompressedRangeFor failed: %v", err)
+	}
+
+	// The fetched span holds whole frames: decode them independently.
+	d, err := newDCtx()
+	if err != nil {
+		t.Fatalf("newDCtx failed: %v", err)
+	}
+	defer d.free()
+
+	var got []byte
+	span := data[coff : coff+clen]
+	for _, f := range r.view.Load().table.frames[1:3] {
+		out := make([]byte, f.decompSize)
+		start := f.compOffset - coff
+		if _, err := d.decompress(out, span[start:start+f.compSize]); err != nil {
+			t.Fatalf("decompress failed: %v", err)
+		}
+		got = append(got, out...)
+	}
+	if string(got) != "bravo-charlie-" {
+		t.Errorf("Expected 'bravo-charlie-', got '%s'", string(got))
+	}
+}
+
+func TestReadRangeEmpty(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	for _, off := range []uint64{0, 6, 13, uint64(len(testContent))} {
+		got, err := r.ReadRange(off, off)
+		if err != nil {
+			t.Errorf("ReadRange(%d, %d) failed: %v", off, off, err)
+		}
+		if got == nil || len(got) != 0 {
+			t.Errorf("ReadRange(%d, %d) = %#v, want empty non-nil slice", off, off, got)
+		}
+	}
+
+	if _, err := r.ReadRange(26, 26); err == nil {
+		t.Error("Expected error for empty range past the end")
+	}
+	if _, err := r.ReadRange(5, 4); err == nil {
+		t.Error("Expected error for start > end")
+	}
+}
+
+func TestMaxFrameDecompressedSize(t *testing.T) {
+	r := openArchive(t, testChunks)
+	if got := r.MaxFrameDecompressedSize(); got != uint64(len("charlie-")) {
+		t.Errorf("Expected %d, got %d", len("charlie-"), got)
+	}
+
+	sub, err := r.SubReader(0, 2)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+	if got := sub.MaxFrameDecompressedSize(); got != uint64(len("alpha-")) {
+		t.Errorf("Expected %d for sub-reader, got %d", len("alpha-"), got)
+	}
+}
+
+func TestFrameBoundaries(t *testing.T) {
+	r := openArchive(t, testChunks)
+	want := []uint64{0, 6, 12, 20, 25}
+	if got := r.FrameBoundaries(); !slices.Equal(got, want) {
+		t.Errorf("FrameBoundaries() = %v, want %v", got, want)
+	}
+
+	empty := openFixture(t, "empty.szst")
+	if got := empty.FrameBoundaries(); !slices.Equal(got, []uint64{0}) {
+		t.Errorf("FrameBoundaries() on empty archive = %v, want [0]", got)
+	}
+}
+
+func TestReadFrames(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	tests := []struct {
+		first, last uint64
+		want        string
+	}{
+		{0, 4, testContent},
+		{1, 3, "bravo-charlie-"},
+		{3, 4, "delta"},
+		{2, 2, ""},
+		{4, 4, ""},
+	}
+	for _, tt := range tests {
+		got, err := r.ReadFrames(tt.first, tt.last)
+		if err != nil {
+			t.Fatalf("ReadFrames(%d, %d) failed: %v", tt.first, tt.last, err)
+		}
+		if got == nil || string(got) != tt.want {
+			t.Errorf("ReadFrames(%d, %d) = %q, want %q", tt.first, tt.last, got, tt.want)
+		}
+	}
+
+	if _, err := r.ReadFrames(3, 1); err == nil {
+		t.Error("ReadFrames with first > last succeeded")
+	}
+	if _, err := r.ReadFrames(2, 5); err == nil {
+		t.Error("ReadFrames past the last frame succeeded")
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	r := openArchive(t, []string{"alpha-", "", "charlie"})
+
+	for i, want := range []string{"alpha-", "", "charlie"} {
+		got, err := r.ReadFrame(uint64(i))
+		if err != nil {
+			t.Fatalf("ReadFrame(%d) failed: %v", i, err)
+		}
+		if got == nil || string(got) != want {
+			t.Errorf("ReadFrame(%d) = %q, want %q", i, got, want)
+		}
+	}
+
+	if _, err := r.ReadFrame(3); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("ReadFrame(3) = %v, want ErrOutOfRange", err)
+	}
+	if _, err := r.ReadCompressedFrame(3); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("ReadCompressedFrame(3) = %v, want ErrOutOfRange", err)
+	}
+	if _, _, err := r.FrameChecksum(3); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("FrameChecksum(3) = %v, want ErrOutOfRange", err)
+	}
+	if _, err := r.ReadFrames(1, 4); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("ReadFrames(1, 4) = %v, want ErrOutOfRange", err)
+	}
+	if _, err := r.ReadFrames(2, 1); err == nil || errors.Is(err, ErrOutOfRange) {
+		t.Errorf("ReadFrames(2, 1) = %v, want an invalid range error", err)
+	}
+}
+
+func TestFrameMethodsOnEmptyArchive(t *testing.T) {
+	r := openArchive(t, nil)
+	if r.FrameCount() != 0 {
+		t.Fatalf("FrameCount() = %d, want 0", r.FrameCount())
+	}
+
+	if _, err := r.ReadFrame(0); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("ReadFrame(0) = %v, want ErrOutOfRange", err)
+	}
+	if got, err := r.ReadFrames(0, 0); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("ReadFrames(0, 0) = (%v, %v), want an empty slice", got, err)
+	}
+	if _, err := r.ReadFrames(0, 1); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("ReadFrames(0, 1) = %v, want ErrOutOfRange", err)
+	}
+	if _, err := r.ReadFrames(1, 0); err == nil {
+		t.Error("ReadFrames(1, 0) succeeded")
+	}
+}
+
+func TestIsFrameBoundary(t *testing.T) {
+	r := openArchive(t, []string{"alpha-", "", "bravo-", "delta"})
+	for off := uint64(0); off <= r.Size()+1; off++ {
+		want := slices.Contains(r.FrameBoundaries(), off)
+		if got := r.IsFrameBoundary(off); got != want {
+			t.Errorf("IsFrameBoundary(%d) = %v, want %v", off, got, want)
+		}
+	}
+
+	if !openFixture(t, "empty.szst").IsFrameBoundary(0) {
+		t.Error("IsFrameBoundary(0) on empty archive = false")
+	}
+}
+
+func TestReadRangeAligned(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	for _, align := range []int{1, 16, 32, 64, 4096} {
+		for _, rng := range [][2]uint64{{0, 25}, {3, 14}, {7, 7}} {
+			got, err := r.ReadRangeAligned(rng[0], rng[1], align, 64)
+			if err != nil {
+				t.Fatalf("ReadRangeAligned(%d, %d, %d, 64) failed: %v", rng[0], rng[1], align, err)
+			}
+			if string(got) != testContent[rng[0]:rng[1]] {
+				t.Errorf("ReadRangeAligned(%d, %d, %d, 64) = %q", rng[0], rng[1], align, got)
+			}
+			if p := uintptr(unsafe.Pointer(unsafe.SliceData(got))); p%uintptr(align) != 0 {
+				t.Errorf("ReadRangeAligned with align %d returned address %#x", align, p)
+			}
+			padded := got[:cap(got)]
+			if len(padded) < len(got)+64 {
+				t.Fatalf("capacity %d, want at least %d", cap(got), len(got)+64)
+			}
+			for i, b := range padded[len(got):] {
+				if b != 0 {
+					t.Fatalf("padding byte %d = %#x", i, b)
+				}
+			}
+		}
+	}
+
+	for _, align := range []int{0, -8, 24} {
+		if _, err := r.ReadRangeAligned(0, 5, align, 0); err == nil {
+			t.Errorf("Expected error for alignment %d", align)
+		}
+	}
+	if _, err := r.ReadRangeAligned(0, 5, 16, -1); err == nil {
+		t.Error("Expected error for negative padding")
+	}
+	if _, err := r.ReadRangeAligned(0, 26, 16, 0); err == nil {
+		t.Error("Expected error for range past end")
+	}
+}
+
+func TestReadAtv(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"core": nil,
+		"go":   {WithSizeChecks()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := openArchive(t, testChunks, opts...)
+
+			tests := []struct {
+				off   int64
+				sizes []int
+			}{
+				{0, []int{25}},
+				{0, []int{3, 0, 10, 12}},
+				{4, []int{1, 1, 1, 1, 1, 1, 1, 1, 13}},
+				{2, []int{4, 6, 8}}, // whole frames after the first buffer
+				{5, []int{0, 20}},
+			}
+			for _, tt := range tests {
+				bufs := make([][]byte, len(tt.sizes))
+				want := 0
+				for i, size := range tt.sizes {
+					bufs[i] = make([]byte, size)
+					want += size
+				}
+				n, err := r.ReadAtv(bufs, tt.off)
+				if n != want || err != nil {
+					t.Errorf("ReadAtv(%v, %d) = (%d, %v), want (%d, nil)", tt.sizes, tt.off, n, err, want)
+				}
+				if got := string(bytes.Join(bufs, nil)); got != testContent[tt.off:tt.off+int64(want)] {
+					t.Errorf("ReadAtv(%v, %d) read %q", tt.sizes, tt.off, got)
+				}
+			}
+
+			// A read past the end returns what there is, with io.EOF.
+			bufs := [][]byte{make([]byte, 4), make([]byte, 10)}
+			if n, err := r.ReadAtv(bufs, 15); n != 10 || err != io.EOF {
+				t.Errorf("ReadAtv past the end = (%d, %v), want (10, EOF)", n, err)
+			}
+			if string(bufs[0])+string(bufs[1][:6]) != testContent[15:] {
+				t.Errorf("ReadAtv past the end read %q %q", bufs[0], bufs[1])
+			}
+			if n, err := r.ReadAtv(bufs, 25); n != 0 || err != io.EOF {
+				t.Errorf("ReadAtv at Size() = (%d, %v), want (0, EOF)", n, err)
+			}
+			if n, err := r.ReadAtv(nil, 3); n != 0 || err != nil {
+				t.Errorf("ReadAtv(nil) = (%d, %v)", n, err)
+			}
+			if _, err := r.ReadAtv(bufs, -1); err == nil {
+				t.Error("Expected error for negative offset")
+			}
+		})
+	}
+}
+
+func TestReadAtvDecodesEachFrameOnce(t *testing.T) {
+	c := &recordingCollector{}
+	r := openArchive(t, testChunks, WithCollector(c))
+
+	// Every frame spans two buffers.
+	bufs := [][]byte{make([]byte, 3), make([]byte, 6), make([]byte, 6), make([]byte, 8), make([]byte, 2)}
+	if n, err := r.ReadAtv(bufs, 0); n != 25 || err != nil {
+		t.Fatalf("ReadAtv = (%d, %v)", n, err)
+	}
+	if !slices.Equal(c.decoded, []uint64{0, 1, 2, 3}) {
+		t.Errorf("decoded frames %v, want each once", c.decoded)
+	}
+
+	r.Close()
+	if _, err := r.ReadAtv(bufs, 0); !errors.Is(err, ErrClosed) {
+		t.Errorf("ReadAtv after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestResolveOffsets(t *testing.T) {
+	r := openArchive(t, []string{"alpha-", "", "bravo-", "charlie-", "delta"})
+
+	offsets := []uint64{24, 0, 6, 5, 11, 12, 6, 19, 20}
+	got, err := r.ResolveOffsets(offsets)
+	if err != nil {
+		t.Fatalf("ResolveOffsets failed: %v", err)
+	}
+	for i, off := range offsets {
+		if want := uint64(r.view.Load().table.frameIndex(off)); got[i] != want {
+			t.Errorf("ResolveOffsets(...)[%d] for offset %d = %d, want %d", i, off, got[i], want)
+		}
+	}
+	if want := []uint64{4, 0, 2, 0, 2, 3, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("ResolveOffsets = %v, want %v", got, want)
+	}
+
+	if got, err := r.ResolveOffsets(nil); err != nil || len(got) != 0 {
+		t.Errorf("ResolveOffsets(nil) = (%v, %v)", got, err)
+	}
+	if _, err := r.ResolveOffsets([]uint64{3, r.Size()}); err == nil {
+		t.Error("Expected error for an offset at Size()")
+	}
+}
+
+func TestUnknownContentSize(t *testing.T) {
+	// The fixture's frames were compressed with --no-content-size, so only the
+	// seek table records their sizes.
+	content := "streamed frame one: " + strings.Repeat("a", 200) +
+		strings.Repeat("frame two has no declared size either ", 10) +
+		"and a short third"
+
+	wd, _ := os.Getwd()
+	path := filepath.Join(wd, "../../tests/fixtures/unknown-size.szst")
+
+	for name, opts := range map[string][]Option{
+		"core":   nil,
+		"frames": {WithFrameCache(NewFrameCache(1 << 20))},
+		"go":     {WithDecodeParam(DecodeParamWindowLogMax, 27)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := openArchivePath(t, path, opts...)
+			if r.Size() != uint64(len(content)) {
+				t.Fatalf("Expected size %d, got %d", len(content), r.Size())
+			}
+			if got := r.FrameBoundaries(); !slices.Equal(got, []uint64{0, 220, 600, 617}) {
+				t.Errorf("FrameBoundaries() = %v", got)
+			}
+
+			buf := make([]byte, 100)
+			n, err := r.ReadAt(buf, 510)
+			if err != nil {
+				t.Fatalf("ReadAt failed: %v", err)
+			}
+			if string(buf[:n]) != content[510:610] {
+				t.Errorf("ReadAt(510) = '%s', want '%s'", buf[:n], content[510:610])
+			}
+
+			all, err := r.DecompressAll()
+			if err != nil {
+				t.Fatalf("DecompressAll failed: %v", err)
+			}
+			if string(all) != content {
+				t.Error("DecompressAll content does not match")
+			}
+		})
+	}
+}
+
+func TestWithSizeChecks(t *testing.T) {
+	frames := [][]byte{rawFrame([]byte("alpha-")), rawFrame([]byte("bravo-")), rawFrame([]byte("charlie"))}
+	compSizes := []uint32{uint32(len(frames[0])), uint32(len(frames[1])), uint32(len(frames[2]))}
+
+	tests := []struct {
+		name        string
+		decompSizes []uint32
+	}{
+		{"larger than recorded", []uint32{6, 4, 7}},
+		{"smaller than recorded", []uint32{6, 9, 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Join(frames, nil)
+			data = append(data, seekTableFrame(compSizes, tt.decompSizes)...)
+			r := openArchivePath(t, writeArchive(t, data), WithSizeChecks())
+
+			_, err := r.ReadRange(0, r.Size())
+			if !errors.Is(err, ErrSizeMismatch) {
+				t.Fatalf("ReadRange() = %v, want ErrSizeMismatch", err)
+			}
+			if !strings.Contains(err.Error(), "frame 1") {
+				t.Errorf("Error does not name the frame: %v", err)
+			}
+
+			// Frames before the bad one still read.
+			got, err := r.ReadRange(0, 6)
+			if err != nil || string(got) != "alpha-" {
+				t.Errorf("ReadRange(0, 6) = (%q, %v)", got, err)
+			}
+		})
+	}
+}
+
+func TestReadCompressedFrame(t *testing.T) {
+	data := buildArchive(testChunks...)
+	r := openArchivePath(t, writeArchive(t, data))
+
+	frame, err := r.ReadCompressedFrame(2)
+	if err != nil {
+		t.Fatalf("ReadCompressedFrame(2) failed: %v", err)
+	}
+	if !bytes.Equal(frame, rawFrame([]byte("charlie-"))) {
+		t.Errorf("ReadCompressedFrame(2) = %x", frame)
+	}
+
+	// The frame decodes on its own.
+	d, err := newDCtx()
+	if err != nil {
+		t.Fatalf("newDCtx failed: %v", err)
+	}
+	defer d.free()
+	out := make([]byte, 8)
+	if _, err := d.decompress(out, frame); err != nil || string(out) != "charlie-" {
+		t.Errorf("decompress = (%q, %v)", out, err)
+	}
+
+	if _, err := r.ReadCompressedFrame(4); err == nil {
+		t.Error("Expected error for out-of-range frame")
+	}
+}
+
+func TestEmptyArchive(t *testing.T) {
+	wd, _ := os.Getwd()
+	data, err := os.ReadFile(filepath.Join(wd, "../../tests/fixtures/empty.szst"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	fromReader, err := OpenReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer fromReader.Close()
+
+	readers := map[string]*Reader{
+		"file":   openFixture(t, "empty.szst"),
+		"reader": fromReader,
+	}
+
+	for name, r := range readers {
+		t.Run(name, func(t *testing.T) {
+			if r.Size() != 0 {
+				t.Errorf("Expected size 0, got %d", r.Size())
+			}
+			if r.FrameCount() != 0 {
+				t.Errorf("Expected 0 frames, got %d", r.FrameCount())
+			}
+			if r.MaxFrameDecompressedSize() != 0 {
+				t.Errorf("Expected max frame size 0, got %d", r.MaxFrameDecompressedSize())
+			}
+
+			buf := make([]byte, 4)
+			if n, err := r.ReadAt(buf, 0); n != 0 || err != io.EOF {
+				t.Errorf("ReadAt(0) = (%d, %v), want (0, EOF)", n, err)
+			}
+			if n, err := r.ReadAt(buf[:0], 0); n != 0 || err != nil {
+				t.Errorf("ReadAt(empty buffer) = (%d, %v), want (0, nil)", n, err)
+			}
+
+			if _, err := r.ReadRange(0, 1); err == nil {
+				t.Error("Expected ReadRange(0, 1) to fail")
+			}
+			if got, err := r.ReadRange(0, 0); err != nil || got == nil || len(got) != 0 {
+				t.Errorf("ReadRange(0, 0) = (%#v, %v), want empty slice", got, err)
+			}
+
+			all, err := r.DecompressAll()
+			if err != nil {
+				t.Fatalf("DecompressAll failed: %v", err)
+			}
+			if all == nil || len(all) != 0 {
+				t.Errorf("Expected empty non-nil slice, got %#v", all)
+			}
+
+			tail, err := r.Tail(10)
+			if err != nil || len(tail) != 0 {
+				t.Errorf("Tail(10) = (%q, %v), want empty", tail, err)
+			}
+
+			var out bytes.Buffer
+			if n, err := r.WriteTo(&out); n != 0 || err != nil {
+				t.Errorf("WriteTo = (%d, %v), want (0, nil)", n, err)
+			}
+		})
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	r := openArchive(t, testChunks, WithLogger(logger), WithFrameCache(NewFrameCache(12)))
+	if _, err := r.DecompressAll(); err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+
+	out := logs.String()
+	for _, want := range []string{
+		"seekable: opened archive",
+		"seekable: decoded frame",
+		"frame=3",
+		"seekable: frame cache eviction",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected log output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestWithLoggerFailures(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	checksums := make([]uint32, len(testChunks))
+	for i, c := range testChunks {
+		checksums[i] = frameChecksum([]byte(c))
+	}
+	checksums[2]++
+	r := openArchivePath(t, writeArchive(t, checksummedArchive(testChunks, checksums)), WithLogger(logger))
+	if err := r.DeepValidate(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("DeepValidate = %v, want ErrChecksumMismatch", err)
+	}
+
+	errBad := errors.New("bad frame")
+	v := openArchive(t, testChunks, WithLogger(logger), WithFrameVerifier(func(uint64, []byte) error { return errBad }))
+	if _, err := v.ReadRange(0, 5); !errors.Is(err, errBad) {
+		t.Fatalf("ReadRange = %v, want the verifier error", err)
+	}
+
+	out := logs.String()
+	for _, want := range []string{
+		"seekable: frame checksum mismatch",
+		"frame=2",
+		"seekable: frame verification failed",
+		"error=\"bad frame\"",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected log output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestWithLoggerCoreRanges(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	r := openHello(t, WithLogger(logger))
+	if _, err := r.ReadRange(0, 5); err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if !strings.Contains(logs.String(), "seekable: decoded range") {
+		t.Errorf("Expected range decode to be logged, got:\n%s", logs.String())
+	}
+}
+
+func TestReadAtLeast(t *testing.T) {
+	r := openArchive(t, []string{"abcd", "efgh", "ij"})
+
+	tests := []struct {
+		name    string
+		bufLen  int
+		off     int64
+		min     int
+		want    string
+		wantErr error
+	}{
+		{"within frame", 8, 0, 2, "abcd", nil},
+		{"up to frame end", 8, 1, 4, "bcdefgh", nil},
+		{"buffer limits", 3, 2, 3, "cde", nil},
+		{"exact end", 8, 8, 2, "ij", nil},
+		{"min zero", 4, 0, 0, "", nil},
+		{"short content", 8, 6, 5, "ghij", io.ErrUnexpectedEOF},
+		{"at end", 4, 10, 1, "", io.EOF},
+		{"short buffer", 2, 0, 3, "", io.ErrShortBuffer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := make([]byte, tt.bufLen)
+			n, err := r.ReadAtLeast(p, tt.off, tt.min)
+			if err != tt.wantErr {
+				t.Fatalf("ReadAtLeast error = %v, want %v", err, tt.wantErr)
+			}
+			if got := string(p[:n]); got != tt.want {
+				t.Errorf("ReadAtLeast = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := r.ReadAtLeast(make([]byte, 4), -1, 1); err == nil {
+		t.Error("ReadAtLeast with negative offset succeeded")
+	}
+}
+
+func TestSizeAndFrameCountCached(t *testing.T) {
+	r, err := Open(writeArchive(t, buildArchive(testChunks...)))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if r.ptr == nil {
+		t.Fatal("archive not opened with the core decoder")
+	}
+	if r.Size() != uint64(len(testContent)) || r.FrameCount() != 4 {
+		t.Errorf("Size() = %d, FrameCount() = %d", r.Size(), r.FrameCount())
+	}
+
+	// The accessors keep working once the decoder is freed.
+	r.Close()
+	if r.Size() != uint64(len(testContent)) || r.FrameCount() != 4 {
+		t.Errorf("after Close: Size() = %d, FrameCount() = %d", r.Size(), r.FrameCount())
+	}
+}
+
+// BenchmarkReadAt measures small random-access reads through the core
+// decoder, where per-call overhead outside decoding dominates.
+func BenchmarkReadAt(b *testing.B) {
+	content := []byte(strings.Repeat("benchmark read ", 10000))
+	r := openArchivePath(b, writeArchive(b, writeTestArchive(b, content, WithFrameSize(4096))))
+	p := make([]byte, 64)
+	size := int64(r.Size()) - int64(len(p))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		off := int64(i*4099) % size
+		if _, err := r.ReadAt(p, off); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// countdownCtx is a context that reports cancellation after Err has been
+// called n times.
+type countdownCtx struct {
+	context.Context
+	n int
+}
+
+func (c *countdownCtx) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestReadAtContext(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"core": nil,
+		"go":   {WithSizeChecks()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := openArchive(t, testChunks, opts...)
+			p := make([]byte, len(testContent))
+
+			n, err := r.ReadAtContext(context.Background(), p, 0)
+			if err != nil || string(p[:n]) != testContent {
+				t.Fatalf("ReadAtContext = (%q, %v)", p[:n], err)
+			}
+
+			// Cancelled before the third frame: the first two are returned.
+			ctx := &countdownCtx{Context: context.Background(), n: 2}
+			n, err = r.ReadAtContext(ctx, p, 1)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("ReadAtContext error = %v, want context.Canceled", err)
+			}
+			if got := string(p[:n]); got != "lpha-bravo-" {
+				t.Errorf("ReadAtContext read %q before cancellation", got)
+			}
+
+			cancelled, cancel := context.WithCancel(context.Background())
+			cancel()
+			if n, err := r.ReadAtContext(cancelled, p, 0); n != 0 || !errors.Is(err, context.Canceled) {
+				t.Errorf("ReadAtContext with a cancelled context = (%d, %v)", n, err)
+			}
+		})
+	}
+}
+
+func TestReadRangeContext(t *testing.T) {
+	r := openArchive(t, testChunks)
+	size := r.Size()
+
+	got, err := r.ReadRangeContext(context.Background(), 1, size)
+	if err != nil || string(got) != testContent[1:] {
+		t.Fatalf("ReadRangeContext = (%q, %v)", got, err)
+	}
+
+	// Cancelled partway: nothing is returned, not even the decoded frames.
+	ctx := &countdownCtx{Context: context.Background(), n: 2}
+	got, err = r.ReadRangeContext(ctx, 0, size)
+	if !errors.Is(err, context.Canceled) || got != nil {
+		t.Errorf("cancelled ReadRangeContext = (%q, %v), want (nil, context.Canceled)", got, err)
+	}
+
+	deadline, cancel := context.WithTimeout(context.Background(), -1)
+	defer cancel()
+	if _, err := r.ReadRangeContext(deadline, 0, size); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ReadRangeContext past its deadline: error = %v", err)
+	}
+
+	if got, err := r.ReadRangeContext(deadline, 3, 3); err != nil || len(got) != 0 {
+		t.Errorf("empty ReadRangeContext = (%q, %v)", got, err)
+	}
+	if _, err := r.ReadRangeContext(context.Background(), 2, 1); err == nil {
+		t.Error("ReadRangeContext with start > end succeeded")
+	}
+}
+
+func TestMethodsAfterClose(t *testing.T) {
+	r := openArchive(t, testChunks)
+	other := openArchive(t, testChunks)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	calls := map[string]func() error{
+		"Stat":                func() error { _, err := r.Stat(); return err },
+		"ReadCompressedFrame": func() error { _, err := r.ReadCompressedFrame(0); return err },
+		"Transcode":           func() error { _, err := r.Transcode(io.Discard); return err },
+		"DeepValidate":        func() error { return r.DeepValidate() },
+		"TrailingBytes":       func() error { _, err := r.TrailingBytes(); return err },
+		"DiffFrames":          func() error { _, err := DiffFrames(other, r); return err },
+		"ReadAtProgress": func() error {
+			_, err := r.ReadAtProgress(context.Background(), make([]byte, 5), 0)
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s after Close = %v, want ErrClosed", name, err)
+		}
+	}
+	if s := r.Stats(); s != (Stats{}) {
+		t.Errorf("Stats() after Close = %+v, want zero", s)
+	}
+}
+
+func TestConcurrentReadAtAndClose(t *testing.T) {
+	content := []byte(strings.Repeat("concurrent close ", 20000))
+	data := writeTestArchive(t, content, WithFrameSize(4096))
+	path := writeArchive(t, data)
+
+	for name, opts := range map[string][]Option{
+		"core": nil,
+		"go":   {WithSizeChecks()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for round := 0; round < 10; round++ {
+				r, err := Open(path, opts...)
+				if err != nil {
+					t.Fatalf("Open failed: %v", err)
+				}
+				sub, err := r.SubReader(1, r.FrameCount())
+				if err != nil {
+					t.Fatalf("SubReader failed: %v", err)
+				}
+
+				var wg sync.WaitGroup
+				errs := make(chan error, 8)
+				for g := 0; g < 8; g++ {
+					g := g
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						p := make([]byte, 10000)
+						for i := 0; ; i++ {
+							off := int64((g*7919 + i*4099) % (len(content) - len(p)))
+							n, err := r.ReadAt(p, off)
+							if errors.Is(err, ErrClosed) {
+								return
+							}
+							if err != nil || !bytes.Equal(p[:n], content[off:off+int64(n)]) {
+								errs <- fmt.Errorf("ReadAt(%d) = (%d, %v) or wrong content", off, n, err)
+								return
+							}
+						}
+					}()
+				}
+
+				time.Sleep(time.Millisecond)
+				if err := r.Close(); err != nil {
+					t.Fatalf("Close failed: %v", err)
+				}
+				wg.Wait()
+				close(errs)
+				for err := range errs {
+					t.Error(err)
+				}
+
+				if _, err := r.ReadAt(make([]byte, 1), 0); !errors.Is(err, ErrClosed) {
+					t.Errorf("ReadAt after Close: error = %v, want ErrClosed", err)
+				}
+				// The sub-reader keeps the shared decoder alive.
+				if got, err := sub.ReadRange(0, 100); err != nil || !bytes.Equal(got, content[4096:4196]) {
+					t.Errorf("sub-reader ReadRange after parent Close = (%q, %v)", got, err)
+				}
+				sub.Close()
+			}
+		})
+	}
+}
+
+func TestSizeErr(t *testing.T) {
+	data := buildArchive(testChunks...)
+	r, err := OpenBytes(data)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	if size, err := r.SizeErr(); err != nil || size != uint64(len(testContent)) {
+		t.Errorf("SizeErr = (%d, %v)", size, err)
+	}
+
+	// A different frame count in the footer.
+	data[len(data)-seekTableFooterSize]++
+	if _, err := r.SizeErr(); !errors.Is(err, ErrSourceChanged) {
+		t.Errorf("SizeErr after footer change: error = %v, want ErrSourceChanged", err)
+	}
+	if r.Size() != uint64(len(testContent)) {
+		t.Errorf("Size() = %d after footer change", r.Size())
+	}
+
+	r.Close()
+	if _, err := r.SizeErr(); !errors.Is(err, ErrClosed) {
+		t.Errorf("SizeErr after Close: error = %v, want ErrClosed", err)
+	}
+}
+
+func TestSizeErrFileRewritten(t *testing.T) {
+	path := writeArchive(t, buildArchive(testChunks...))
+	r := openArchivePath(t, path)
+	sub, err := r.SubReader(1, 3)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+
+	if size, err := sub.SizeErr(); err != nil || size != uint64(len("bravo-charlie-")) {
+		t.Errorf("sub-reader SizeErr = (%d, %v)", size, err)
+	}
+
+	// Rewrite the file in place with an archive of the same frame count.
+	if err := os.WriteFile(path, buildArchive("one-", "two-", "three-", "four"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	for _, rd := range []*Reader{r, sub} {
+		if _, err := rd.SizeErr(); !errors.Is(err, ErrSourceChanged) {
+			t.Errorf("SizeErr after rewrite: error = %v, want ErrSourceChanged", err)
+		}
+	}
+}
+
+func TestAvailableAt(t *testing.T) {
+	r := openHello(t)
+	size := int64(r.Size())
+	for _, tt := range []struct {
+		off  int64
+		want uint64
+	}{
+		{0, uint64(size)}, {6, uint64(size - 6)}, {size - 1, 1}, {size, 0}, {size + 10, 0}, {-1, 0},
+	} {
+		if got := r.AvailableAt(tt.off); got != tt.want {
+			t.Errorf("AvailableAt(%d) = %d, want %d", tt.off, got, tt.want)
+		}
+		if tt.off < 0 {
+			continue
+		}
+		// A buffer sized by AvailableAt is filled exactly.
+		if tt.want > 0 {
+			p := make([]byte, tt.want)
+			if n, err := r.ReadAt(p, tt.off); n != len(p) || err != nil {
+				t.Errorf("ReadAt(%d) with %d bytes = (%d, %v)", tt.off, len(p), n, err)
+			}
+		}
+	}
+}
+
+func TestSize64(t *testing.T) {
+	r := openHello(t)
+	if got := r.Size64(); got != 11 {
+		t.Errorf("Size64() = %d, want 11", got)
+	}
+	if sr := io.NewSectionReader(r, 0, r.Size64()); sr.Size() != 11 {
+		t.Errorf("SectionReader Size() = %d, want 11", sr.Size())
+	}
+
+	huge := &Reader{}
+	huge.view.Store(&tableView{size: math.MaxUint64})
+	if got := huge.Size64(); got != math.MaxInt64 {
+		t.Errorf("Size64() of a huge archive = %d, want math.MaxInt64", got)
+	}
+}
+
+func TestOpenUnicodePath(t *testing.T) {
+	dir := t.TempDir()
+	data := buildArchive(testChunks...)
+	for _, name := range []string{"données.szst", "日本語 アーカイブ.szst", "archive-🗜️.szst", "Ωmega/ñested.szst"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := Open(path)
+		if err != nil {
+			t.Errorf("Open(%q) failed: %v", name, err)
+			continue
+		}
+		if r.ptr == nil {
+			t.Errorf("Open(%q) did not use the core decoder", name)
+		}
+		if got, err := r.DecompressAll(); err != nil || string(got) != testContent {
+			t.Errorf("DecompressAll of %q = (%q, %v)", name, got, err)
+		}
+		r.Close()
+	}
+}
+
+func TestOpenNonUTF8Path(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arch\xff\xfeive.szst")
+	if err := os.WriteFile(path, buildArchive(testChunks...), 0o644); err != nil {
+		t.Skipf("file system rejects non-UTF-8 names: %v", err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+	if got, err := r.DecompressAll(); err != nil || string(got) != testContent {
+		t.Errorf("DecompressAll = (%q, %v)", got, err)
+	}
+}
+
+func TestOpenPathWithNUL(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "archive")
+	if err := os.WriteFile(path, buildArchive(testChunks...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A C string would end at the NUL and open the file above.
+	if r, err := Open(path + "\x00.szst"); err == nil {
+		r.Close()
+		t.Error("Open of a path with a NUL byte succeeded")
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.szst")
+
+	garbage := filepath.Join(dir, "garbage.szst")
+	if err := os.WriteFile(garbage, bytes.Repeat([]byte("not an archive "), 10), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A footer with the seekable magic that describes an impossible table.
+	badTable := filepath.Join(dir, "bad-table.szst")
+	table := seekTableFrame([]uint32{100}, []uint32{5})
+	if err := os.WriteFile(badTable, table, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, opts := range map[string][]Option{
+		"core": nil,
+		"go":   {WithDecodeParam(DecodeParamWindowLogMax, 27)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Open(missing, opts...)
+			if !errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrInvalidArchive) {
+				t.Errorf("Open(missing) = %v, want fs.ErrNotExist", err)
+			}
+			for _, path := range []string{garbage, badTable} {
+				_, err := Open(path, opts...)
+				if !errors.Is(err, ErrInvalidArchive) || errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("Open(%s) = %v, want ErrInvalidArchive", filepath.Base(path), err)
+				}
+			}
+			if _, err := Open(dir, opts...); err == nil || errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Open(directory) = %v", err)
+			}
+		})
+	}
+
+	for _, data := range [][]byte{[]byte("not an archive"), table, nil} {
+		if _, err := OpenBytes(data); !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("OpenBytes(%q) = %v, want ErrInvalidArchive", data, err)
+		}
+	}
+}
+
+func TestOpenPermissionError(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced")
+	}
+	path := writeArchive(t, buildArchive(testChunks...))
+	if err := os.Chmod(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	for name, opts := range map[string][]Option{
+		"core": nil,
+		"go":   {WithDecodeParam(DecodeParamWindowLogMax, 27)},
+	} {
+		if _, err := Open(path, opts...); !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("%s: Open = %v, want fs.ErrPermission", name, err)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package seekable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Seekable format constants.
+// See https://github.com/facebook/zstd/blob/dev/contrib/seekable_format/zstd_seekable_compression_format.md
+const (
+	skippableMagicSeekTable = 0x184D2A5E
+	seekableMagic           = 0x8F92EAB1
+
+	skippableHeaderSize = 8
+	seekTableFooterSize = 9
+
+	seekTableChecksumFlag = 0x80
+	seekTableReservedBits = 0x7C
+
+	// maxFrames is the largest frame count the format allows
+	// (ZSTD_SEEKABLE_MAXFRAMES in the reference implementation). A seek
+	// table at the limit takes 1.5 GiB and its parsed form about 5 GiB, so
+	// archives this large are impractical well before the limit.
+	maxFrames = 0x8000000
+)
+
+// zstdFrameMagic starts every zstd frame.
+const zstdFrameMagic = 0xFD2FB528
+
+// ErrNotFinalized is returned when opening an archive that has no seek table
+// yet: it is empty or holds zstd frames without a seek table, such as the
+// output of a Writer that has not been closed. It wraps ErrInvalidArchive.
+var ErrNotFinalized = fmt.Errorf("%w: archive is not finalized (no seek table)", ErrInvalidArchive)
+
+// ErrTooManyFrames is returned for seek tables that claim more frames than the
+// format allows, and by a Writer asked to write more.
+var ErrTooManyFrames = errors.New("seekable: too many frames")
+
+// frameEntry describes one frame of the archive as recorded in the seek table.
+type frameEntry struct {
+	compOffset   uint64
+	compSize     uint64
+	decompOffset uint64
+	decompSize   uint64
+	checksum     uint32
+}
+
+// seekTable is the parsed seek table of an archive.
+type seekTable struct {
+	frames       []frameEntry
+	hasChecksums bool
+
+	// maxDecompSize is the largest decompressed size of any frame.
+	maxDecompSize uint64
+
+	// metadata holds the payloads of the metadata frames stored between the
+	// last frame and the seek table, keyed by tag.
+	metadata map[string][]byte
+
+	// entries is the parsed entry index and entryIndex maps entry names to
+	// positions in it.
+	entries    []indexEntry
+	entryIndex map[string]int
+
+	// leading is set for tables in the legacy layout read by OpenLegacy.
+	leading bool
+
+	// sidecar is set for tables read by OpenWithSidecar, which are not
+	// stored in the source.
+	sidecar bool
+
+	// footer holds the seek table footer as read at footerOff, so changes to
+	// the source after open can be detected.
+	footer    [seekTableFooterSize]byte
+	footerOff int64
+
+	// start is the offset of the seek table's skippable frame header.
+	start int64
+
+	// end is the offset just past the seek table footer. Anything between
+	// end and the size of the source is trailing data.
+	//
+	// A leading seek table (see OpenLegacy) precedes the frames, and a
+	// sidecar table is stored elsewhere, so start and end are both set to
+	// the end of the last frame, where trailing data begins.
+	end int64
+}
+
+// readSeekTable parses the seek table whose footer ends at offset size, which
+// is normally the compressed size of the archive.
+func readSeekTable(ra io.ReaderAt, size int64) (*seekTable, error) {
+	st, err := parseSeekTable(ra, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if end := st.dataEnd(); end > st.start {
+		return nil, errInvalidTable("frames span %d bytes, but seek table starts at %d", end, st.start)
+	}
+
+	if err := st.readMetadata(ra, st.dataEnd()); err != nil {
+		return nil, err
+	}
+	if err := st.parseIndex(); err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// parseSeekTable parses the seek table frame ending at offset size, with the
+// first frame at compressed offset 0.
+func parseSeekTable(ra io.ReaderAt, size int64) (*seekTable, error) {
+	if size < skippableHeaderSize+seekTableFooterSize {
+		return nil, errInvalidTable("archive too small (%d bytes)", size)
+	}
+
+	var footer [seekTableFooterSize]byte
+	if err := readFullAt(ra, footer[:], size-seekTableFooterSize); err != nil {
+		return nil, fmt.Errorf("reading seek table footer: %w", err)
+	}
+
+	if magic := binary.LittleEndian.Uint32(footer[5:]); magic != seekableMagic {
+		return nil, errInvalidTable("bad footer magic %#x", magic)
+	}
+
+	numFrames := uint64(binary.LittleEndian.Uint32(footer[0:]))
+	if numFrames > maxFrames {
+		return nil, errInvalidTable("%w: %d frames, at most %d allowed", ErrTooManyFrames, numFrames, maxFrames)
+	}
+	descriptor := footer[4]
+	if descriptor&seekTableReservedBits != 0 {
+		return nil, errInvalidTable("reserved descriptor bits set (%#x)", descriptor)
+	}
+
+	hasChecksums := descriptor&seekTableChecksumFlag != 0
+	entrySize := uint64(8)
+	if hasChecksums {
+		entrySize = 12
+	}
+
+	tableSize := numFrames*entrySize + seekTableFooterSize
+	if tableSize+skippableHeaderSize > uint64(size) {
+		return nil, errInvalidTable("%d frames do not fit in %d bytes", numFrames, size)
+	}
+
+	tableStart := size - int64(tableSize) - skippableHeaderSize
+	buf := make([]byte, tableSize-seekTableFooterSize+skippableHeaderSize)
+	if err := readFullAt(ra, buf, tableStart); err != nil {
+		return nil, fmt.Errorf("reading seek table: %w", err)
+	}
+
+	if magic := binary.LittleEndian.Uint32(buf[0:]); magic != skippableMagicSeekTable {
+		return nil, errInvalidTable("bad skippable frame magic %#x", magic)
+	}
+	if frameSize := uint64(binary.LittleEndian.Uint32(buf[4:])); frameSize != tableSize {
+		return nil, errInvalidTable("frame size %d, expected %d", frameSize, tableSize)
+	}
+
+	st := &seekTable{
+		frames:       make([]frameEntry, numFrames),
+		hasChecksums: hasChecksums,
+		footer:       footer,
+		footerOff:    size - seekTableFooterSize,
+		start:        tableStart,
+		end:          size,
+	}
+
+	var compOffset, decompOffset uint64
+	entries := buf[skippableHeaderSize:]
+	for i := range st.frames {
+		e := entries[uint64(i)*entrySize:]
+		f := frameEntry{
+			compOffset:   compOffset,
+			compSize:     uint64(binary.LittleEndian.Uint32(e[0:])),
+			decompOffset: decompOffset,
+			decompSize:   uint64(binary.LittleEndian.Uint32(e[4:])),
+		}
+		if hasChecksums {
+			f.checksum = binary.LittleEndian.Uint32(e[8:])
+		}
+		st.frames[i] = f
+		st.maxDecompSize = max(st.maxDecompSize, f.decompSize)
+		compOffset += f.compSize
+		decompOffset += f.decompSize
+	}
+
+	return st, nil
+}
+
+// errInvalidTable returns an error wrapping ErrInvalidArchive that describes
+// a malformed seek table.
+func errInvalidTable(format string, args ...any) error {
+	return fmt.Errorf("%w: bad seek table: %w", ErrInvalidArchive, fmt.Errorf(format, args...))
+}
+
+// maxTrailingData bounds the trailing data findSeekTable scans past, so that a
+// large source without a seek table is not read end to end looking for one.
+const maxTrailingData = 16 << 20
+
+// findSeekTable locates the seek table of an archive that may be followed by
+// trailing data. If the table does not end the source, the last
+// maxTrailingData bytes of the source are scanned backwards for a footer
+// whose table is consistent with its position.
+func findSeekTable(ra io.ReaderAt, size int64) (*seekTable, error) {
+	st, err := readSeekTable(ra, size)
+	if err == nil || errors.Is(err, ErrTooManyFrames) {
+		return st, err
+	}
+
+	const window = 64 * 1024
+	var magic [4]byte
+	binary.LittleEndian.PutUint32(magic[:], seekableMagic)
+
+	buf := make([]byte, window+len(magic)-1)
+	limited := false
+	for hi := size; hi > skippableHeaderSize+seekTableFooterSize; {
+		if size-hi >= maxTrailingData {
+			limited = true
+			break
+		}
+		lo := max(hi-window, 0)
+		// Overlap windows so a magic number spanning two of them is found.
+		chunk := buf[:min(hi+int64(len(magic))-1, size)-lo]
+		if rerr := readFullAt(ra, chunk, lo); rerr != nil {
+			return nil, fmt.Errorf("scanning for seek table: %w", rerr)
+		}
+
+		for i := bytes.LastIndex(chunk, magic[:]); i >= 0; i = bytes.LastIndex(chunk[:i+len(magic)-1], magic[:]) {
+			end := lo + int64(i) + int64(len(magic))
+			if end < size {
+				if st, serr := readSeekTable(ra, end); serr == nil {
+					return st, nil
+				}
+			}
+		}
+
+		hi = lo
+	}
+
+	if unfinalized(ra, size) {
+		return nil, ErrNotFinalized
+	}
+	if limited {
+		return nil, errInvalidTable("no footer in the last %d bytes", maxTrailingData)
+	}
+	return nil, err
+}
+
+// unfinalized reports whether a source without a seek table looks like an
+// archive still being written: it is empty or starts with a zstd or
+// skippable frame.
+func unfinalized(ra io.ReaderAt, size int64) bool {
+	if size == 0 {
+		return true
+	}
+
+	var magic [4]byte
+	if size < int64(len(magic)) || readFullAt(ra, magic[:], 0) != nil {
+		return false
+	}
+	m := binary.LittleEndian.Uint32(magic[:])
+	return m == zstdFrameMagic || m&skippableMagicMask == skippableMagicBase
+}
+
+// size returns the total decompressed size described by the table.
+func (st *seekTable) size() uint64 {
+	if len(st.frames) == 0 {
+		return 0
+	}
+	last := st.frames[len(st.frames)-1]
+	return last.decompOffset + last.decompSize
+}
+
+// dataEnd returns the offset just past the last frame.
+func (st *seekTable) dataEnd() int64 {
+	if len(st.frames) == 0 {
+		return 0
+	}
+	last := st.frames[len(st.frames)-1]
+	return int64(last.compOffset + last.compSize)
+}
+
+// checkExpansion returns ErrSuspiciousArchive if the table declares more
+// than ratio decompressed bytes per compressed byte of its frames. A ratio of
+// 0 disables the check.
+func (st *seekTable) checkExpansion(ratio uint64) error {
+	if ratio == 0 {
+		return nil
+	}
+	size, comp := st.size(), uint64(st.dataEnd())
+	if comp > 0 && ratio > math.MaxUint64/comp {
+		return nil
+	}
+	if size > comp*ratio {
+		return fmt.Errorf("%w: %d bytes of frames declare %d decompressed bytes, more than %dx", ErrSuspiciousArchive, comp, size, ratio)
+	}
+	return nil
+}
+
+// frameIndex returns the index of the frame containing the decompressed
+// offset off. The result is len(st.frames) if off is at or past the end.
+func (st *seekTable) frameIndex(off uint64) int {
+	return sort.Search(len(st.frames), func(i int) bool {
+		f := st.frames[i]
+		return f.decompOffset+f.decompSize > off
+	})
+}
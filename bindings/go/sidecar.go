@@ -0,0 +1,59 @@
+package seekable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// OpenWithSidecar opens an archive whose seek table is stored apart from its
+// frames, as written with WithSidecarSeekTable. framesRA holds the compressed
+// frames, framesSize bytes of them, and seekTable the metadata frames and seek
+// table from the sidecar. The table must describe exactly framesSize bytes of
+// frames; otherwise it fails with an error wrapping ErrInvalidArchive.
+//
+// Frames are decoded in Go, as with OpenReader, and the same ownership rules
+// apply to framesRA. seekTable is parsed at open and not retained.
+func OpenWithSidecar(framesRA io.ReaderAt, framesSize int64, seekTable []byte, opts ...Option) (*Reader, error) {
+	cfg := newConfig(opts)
+	ra := cfg.source(framesRA)
+
+	table, err := readSidecarTable(seekTable, framesSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.checkDecodeParams(); err != nil {
+		return nil, err
+	}
+
+	r := &Reader{cfg: cfg, src: ra, srcSize: framesSize, h: &handles{refs: 1}}
+	r.setTable(table)
+	r.setIdentity(fmt.Sprintf("reader:%d", readerIDs.Add(1)))
+	return r.finishOpen()
+}
+
+// readSidecarTable parses a sidecar holding metadata frames followed by a seek
+// table for framesSize bytes of frames.
+func readSidecarTable(sidecar []byte, framesSize int64) (*seekTable, error) {
+	ra := bytes.NewReader(sidecar)
+	st, err := parseSeekTable(ra, int64(len(sidecar)))
+	if err != nil {
+		return nil, err
+	}
+	if end := st.dataEnd(); end != framesSize {
+		return nil, errInvalidTable("frames span %d bytes, but the frame data is %d bytes", end, framesSize)
+	}
+
+	// The metadata frames precede the table in the sidecar.
+	if err := st.readMetadata(ra, 0); err != nil {
+		return nil, err
+	}
+	if err := st.parseIndex(); err != nil {
+		return nil, err
+	}
+
+	st.start, st.end = framesSize, framesSize
+	st.sidecar = true
+	return st, nil
+}
@@ -0,0 +1,141 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSidecarSeekTable(t *testing.T) {
+	content := interopContent()
+
+	var frames, sidecar bytes.Buffer
+	w, err := NewWriter(&frames, WithFrameSize(16*1024), WithContentHash(), WithSidecarSeekTable(&sidecar))
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.AddEntry("head", 0, 100); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// The frames alone are a plain zstd stream.
+	if got := plainDecode(t, frames.Bytes()); !bytes.Equal(got, content) {
+		t.Errorf("frames decode to %d bytes, want %d", len(got), len(content))
+	}
+	if _, err := OpenBytes(frames.Bytes()); !errors.Is(err, ErrNotFinalized) {
+		t.Errorf("OpenBytes(frames) = %v, want ErrNotFinalized", err)
+	}
+
+	r, err := OpenWithSidecar(bytes.NewReader(frames.Bytes()), int64(frames.Len()), sidecar.Bytes())
+	if err != nil {
+		t.Fatalf("OpenWithSidecar failed: %v", err)
+	}
+	defer r.Close()
+
+	if got, err := r.DecompressAll(); err != nil || !bytes.Equal(got, content) {
+		t.Errorf("DecompressAll = (%d bytes, %v)", len(got), err)
+	}
+	if err := r.VerifyContent(); err != nil {
+		t.Errorf("VerifyContent failed: %v", err)
+	}
+	if got, err := r.ReadEntry("head"); err != nil || !bytes.Equal(got, content[:100]) {
+		t.Errorf("ReadEntry = (%q, %v)", got, err)
+	}
+	if err := r.DeepValidate(); err != nil {
+		t.Errorf("DeepValidate failed: %v", err)
+	}
+	if trailing, err := r.TrailingBytes(); err != nil || len(trailing) != 0 {
+		t.Errorf("TrailingBytes = (%q, %v), want none", trailing, err)
+	}
+	if size, err := r.SizeErr(); err != nil || size != uint64(len(content)) {
+		t.Errorf("SizeErr = (%d, %v)", size, err)
+	}
+	if err := r.Refresh(); err == nil {
+		t.Error("Expected Refresh of a sidecar archive to fail")
+	}
+
+	// Appending the sidecar to the frames gives an ordinary archive.
+	whole := append(bytes.Clone(frames.Bytes()), sidecar.Bytes()...)
+	if !bytes.Equal(whole, writeTestArchiveWithEntry(t, content)) {
+		t.Error("frames and sidecar differ from the appended archive")
+	}
+}
+
+// writeTestArchiveWithEntry writes content as TestSidecarSeekTable does, with
+// the seek table appended.
+func writeTestArchiveWithEntry(t testing.TB, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithFrameSize(16*1024), WithContentHash())
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	w.Write(content)
+	if err := w.AddEntry("head", 0, 100); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenWithSidecarValidatesLength(t *testing.T) {
+	var frames, sidecar bytes.Buffer
+	w, err := NewWriter(&frames, WithSidecarSeekTable(&sidecar))
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	w.Write([]byte(testContent))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	n := int64(frames.Len())
+	for _, size := range []int64{n - 1, n + 1, 0} {
+		if _, err := OpenWithSidecar(bytes.NewReader(frames.Bytes()), size, sidecar.Bytes()); !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("OpenWithSidecar with %d of %d bytes = %v, want ErrInvalidArchive", size, n, err)
+		}
+	}
+	for _, table := range [][]byte{nil, []byte("not a seek table"), frames.Bytes()} {
+		if _, err := OpenWithSidecar(bytes.NewReader(frames.Bytes()), n, table); !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("OpenWithSidecar with a %d-byte bad table = %v, want ErrInvalidArchive", len(table), err)
+		}
+	}
+}
+
+func TestWriterAtSidecarSeekTable(t *testing.T) {
+	f := writerAtFile(t)
+	var sidecar bytes.Buffer
+	w, err := NewWriterAt(f, WithSidecarSeekTable(&sidecar))
+	if err != nil {
+		t.Fatalf("NewWriterAt failed: %v", err)
+	}
+	for i, chunk := range []string{"bravo-", "alpha-"} {
+		if err := w.AddAt(uint64(1-i), []byte(chunk)); err != nil {
+			t.Fatalf("AddAt failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenWithSidecar(f, info.Size(), sidecar.Bytes())
+	if err != nil {
+		t.Fatalf("OpenWithSidecar failed: %v", err)
+	}
+	defer r.Close()
+	if got, err := r.DecompressAll(); err != nil || string(got) != "alpha-bravo-" {
+		t.Errorf("DecompressAll = (%q, %v)", got, err)
+	}
+}
@@ -0,0 +1,170 @@
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"log/slog"
+)
+
+// metadataSignature holds an ed25519 signature of the archive, 64 bytes. Its
+// frame must be the last metadata frame, immediately before the seek table.
+//
+// What is signed is the signed message: signatureContext followed by the
+// SHA-256 of the archive's canonical bytes, which are the bytes from the
+// start of the archive to the end of the seek table footer, leaving out the
+// signature frame itself and any trailing data after the seek table. The
+// canonical bytes therefore cover every data frame, every other metadata
+// frame and the seek table.
+const metadataSignature = "SIG1"
+
+// signatureContext starts every signed message, so that a signature of an
+// archive cannot be mistaken for a signature of anything else.
+const signatureContext = "seekable-zstd signature v1\x00"
+
+// signatureFrameSize is the size of a signature metadata frame.
+const signatureFrameSize = skippableHeaderSize + metadataTagSize + ed25519.SignatureSize
+
+var (
+	// ErrNoSignature is returned by VerifySignature when the archive does not
+	// store a signature.
+	ErrNoSignature = errors.New("seekable: archive has no signature")
+
+	// ErrSignatureMismatch is returned by VerifySignature when the stored
+	// signature does not verify against the archive and public key.
+	ErrSignatureMismatch = errors.New("seekable: signature does not verify")
+)
+
+// WithSignature makes the Writer sign the archive with key on Close, storing
+// the signature in a metadata frame just before the seek table. The signature
+// covers the compressed archive; see VerifySignature for what is signed. It
+// cannot be combined with WithSidecarSeekTable, and NewWriterAt rejects it.
+func WithSignature(key ed25519.PrivateKey) WriterOption {
+	return func(c *writerConfig) {
+		c.signer = key
+	}
+}
+
+// signedMessage returns the message signed for an archive whose canonical
+// bytes have the SHA-256 sum.
+func signedMessage(sum []byte) []byte {
+	return append([]byte(signatureContext), sum...)
+}
+
+// appendSignature appends to dst the signature frame for an archive whose
+// canonical bytes are the bytes hashed into h so far followed by table, the
+// seek table written after the frame.
+func appendSignature(dst []byte, h hash.Hash, key ed25519.PrivateKey, table []byte) []byte {
+	h.Write(table)
+	sig := ed25519.Sign(key, signedMessage(h.Sum(nil)))
+	return appendMetadataFrame(dst, metadataSignature, sig)
+}
+
+// SignedMessage returns the message an ed25519 signature of the archive
+// signs: "seekable-zstd signature v1", a NUL byte and the SHA-256 of the
+// canonical bytes, which run from the start of the archive to the end of the
+// seek table, without a signature frame and without trailing data. It reads
+// the whole compressed archive, but decodes nothing.
+//
+// VerifySignature checks the signature stored in the archive against it. For
+// a detached signature, kept apart from the archive, pass the message to
+// ed25519.Verify instead. Archives opened with OpenLegacy or OpenWithSidecar,
+// and sub-readers, have no canonical bytes and fail.
+func (r *Reader) SignedMessage() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sum, _, err := r.signatureSum()
+	if err != nil {
+		return nil, err
+	}
+	return signedMessage(sum), nil
+}
+
+// VerifySignature checks the signature stored by a Writer created with
+// WithSignature against pub, reading the compressed archive but decoding
+// nothing, so a tampered archive can be rejected before any of it is
+// decoded. It returns ErrNoSignature if the archive has none, which callers
+// that require signed archives must treat as a failure, since removing the
+// signature frame leaves a valid unsigned archive. It returns an error
+// wrapping ErrSignatureMismatch if the signature does not verify.
+// SignedMessage describes what is signed.
+func (r *Reader) VerifySignature(pub ed25519.PublicKey) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("seekable: invalid ed25519 public key length %d", len(pub))
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h != nil && r.view.Load().table.metadata[metadataSignature] == nil {
+		return ErrNoSignature
+	}
+	sum, sig, err := r.signatureSum()
+	if err != nil {
+		return err
+	}
+	if sig == nil {
+		err = fmt.Errorf("%w: the signature frame does not immediately precede the seek table", ErrSignatureMismatch)
+	} else if !ed25519.Verify(pub, signedMessage(sum), sig) {
+		err = ErrSignatureMismatch
+	}
+	if l := r.cfg.logger; l != nil && err != nil {
+		l.LogAttrs(context.Background(), slog.LevelDebug, "seekable: signature verification failed",
+			slog.String("error", err.Error()))
+	}
+	return err
+}
+
+// signatureSum hashes the canonical bytes of the archive and returns the sum
+// along with the signature stored in the frame before the seek table, or nil
+// if there is no such frame. r.mu must be held.
+func (r *Reader) signatureSum() (sum, sig []byte, err error) {
+	if r.h == nil {
+		return nil, nil, ErrClosed
+	}
+	st := r.view.Load().table
+	if r.sub || st.leading || st.sidecar {
+		return nil, nil, errors.New("seekable: archive has no seek table in the source to sign")
+	}
+
+	// The signature frame, if any, is the one ending at the seek table.
+	sigOff := st.start
+	if off := st.start - signatureFrameSize; off >= st.dataEnd() {
+		frame := make([]byte, signatureFrameSize)
+		if err := readFullAt(r.src, frame, off); err != nil {
+			return nil, nil, fmt.Errorf("reading signature frame: %w", err)
+		}
+		if binary.LittleEndian.Uint32(frame) == skippableMagicMetadata &&
+			binary.LittleEndian.Uint32(frame[4:]) == metadataTagSize+ed25519.SignatureSize &&
+			bytes.Equal(frame[skippableHeaderSize:skippableHeaderSize+metadataTagSize], []byte(metadataSignature)) {
+			sigOff, sig = off, frame[skippableHeaderSize+metadataTagSize:]
+		}
+	}
+
+	h := sha256.New()
+	if err := r.hashSource(h, 0, sigOff); err != nil {
+		return nil, nil, err
+	}
+	if err := r.hashSource(h, st.start, st.end); err != nil {
+		return nil, nil, err
+	}
+	return h.Sum(nil), sig, nil
+}
+
+// hashSource writes the compressed bytes in [start, end) of the source to h.
+func (r *Reader) hashSource(h hash.Hash, start, end int64) error {
+	buf := make([]byte, min(end-start, 1<<20))
+	for off := start; off < end; {
+		n := min(end-off, int64(len(buf)))
+		if err := readFullAt(r.src, buf[:n], off); err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+		h.Write(buf[:n])
+		off += n
+	}
+	return nil
+}
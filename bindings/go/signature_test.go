@@ -0,0 +1,151 @@
+package seekable
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+// testSigningKey returns a fixed ed25519 key pair.
+func testSigningKey(seed byte) (ed25519.PublicKey, ed25519.PrivateKey) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+	return key.Public().(ed25519.PublicKey), key
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, key := testSigningKey(1)
+	content := interopContent()
+	wopts := []WriterOption{WithFrameSize(16 * 1024), WithContentHash()}
+	signed := writeTestArchive(t, content, append(wopts, WithSignature(key))...)
+	unsigned := writeTestArchive(t, content, wopts...)
+
+	// The signature frame is all that the signature adds.
+	if len(signed) != len(unsigned)+signatureFrameSize {
+		t.Fatalf("signed archive is %d bytes, want %d", len(signed), len(unsigned)+signatureFrameSize)
+	}
+
+	r, err := OpenBytes(signed)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	if err := r.VerifySignature(pub); err != nil {
+		t.Fatalf("VerifySignature failed: %v", err)
+	}
+	if err := r.VerifyContent(); err != nil {
+		t.Errorf("VerifyContent failed: %v", err)
+	}
+
+	// The signed message is the context and the SHA-256 of the archive
+	// without its signature frame, the same bytes as the unsigned archive.
+	sum := sha256.Sum256(unsigned)
+	want := append([]byte("seekable-zstd signature v1\x00"), sum[:]...)
+	msg, err := r.SignedMessage()
+	if err != nil || !bytes.Equal(msg, want) {
+		t.Errorf("SignedMessage = (%x, %v), want %x", msg, err, want)
+	}
+	u, err := OpenBytes(unsigned)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer u.Close()
+	if msg, err := u.SignedMessage(); err != nil || !bytes.Equal(msg, want) {
+		t.Errorf("SignedMessage of the unsigned archive = (%x, %v), want %x", msg, err, want)
+	}
+	if err := u.VerifySignature(pub); !errors.Is(err, ErrNoSignature) {
+		t.Errorf("VerifySignature of an unsigned archive = %v, want ErrNoSignature", err)
+	}
+
+	// A detached signature verifies against the same message.
+	if !ed25519.Verify(pub, want, ed25519.Sign(key, want)) {
+		t.Error("detached signature does not verify")
+	}
+
+	// Trailing data is not signed; the core decoder path verifies too.
+	for name, data := range map[string][]byte{
+		"trailing data": append(bytes.Clone(signed), "trailer"...),
+		"file":          signed,
+	} {
+		r := openArchivePath(t, writeArchive(t, data))
+		if err := r.VerifySignature(pub); err != nil {
+			t.Errorf("%s: VerifySignature failed: %v", name, err)
+		}
+	}
+
+	otherPub, _ := testSigningKey(2)
+	if err := r.VerifySignature(otherPub); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("VerifySignature with another key = %v, want ErrSignatureMismatch", err)
+	}
+	if err := r.VerifySignature(pub[:8]); err == nil {
+		t.Error("Expected error for a short public key")
+	}
+
+	sub, err := r.SubReader(0, 1)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+	if err := sub.VerifySignature(pub); err == nil {
+		t.Error("Expected VerifySignature of a sub-reader to fail")
+	}
+
+	r.Close()
+	if _, err := r.SignedMessage(); !errors.Is(err, ErrClosed) {
+		t.Errorf("SignedMessage after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestVerifySignatureTampered(t *testing.T) {
+	pub, key := testSigningKey(1)
+	signed := writeTestArchive(t, interopContent(), WithFrameSize(16*1024), WithSignature(key))
+	r, err := OpenBytes(signed)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	tableSize := skippableHeaderSize + 12*int(r.FrameCount()) + seekTableFooterSize
+	sigOff := len(signed) - tableSize - signatureFrameSize
+	r.Close()
+
+	for name, off := range map[string]int{
+		"data frame":       100,
+		"signature":        sigOff + signatureFrameSize - 1,
+		"seek table entry": sigOff + signatureFrameSize + skippableHeaderSize + 8,
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := bytes.Clone(signed)
+			data[off] ^= 1
+			r, err := OpenBytes(data)
+			if err != nil {
+				t.Fatalf("OpenBytes failed: %v", err)
+			}
+			defer r.Close()
+			if err := r.VerifySignature(pub); !errors.Is(err, ErrSignatureMismatch) {
+				t.Errorf("VerifySignature = %v, want ErrSignatureMismatch", err)
+			}
+		})
+	}
+
+	// Stripping the signature leaves an unsigned archive.
+	data := bytes.Clone(signed)
+	data[sigOff+skippableHeaderSize] ^= 1
+	r, err = OpenBytes(data)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	if err := r.VerifySignature(pub); !errors.Is(err, ErrNoSignature) {
+		t.Errorf("VerifySignature without the signature tag = %v, want ErrNoSignature", err)
+	}
+}
+
+func TestWithSignatureOptions(t *testing.T) {
+	_, key := testSigningKey(1)
+	if _, err := NewWriter(&bytes.Buffer{}, WithSignature(key[:10])); err == nil {
+		t.Error("Expected error for a short private key")
+	}
+	if _, err := NewWriter(&bytes.Buffer{}, WithSignature(key), WithSidecarSeekTable(&bytes.Buffer{})); err == nil {
+		t.Error("Expected error combining WithSignature and WithSidecarSeekTable")
+	}
+}
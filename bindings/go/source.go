@@ -0,0 +1,284 @@
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// maxEmptyReads bounds how many consecutive zero-byte, nil-error reads
+// readFullAt tolerates before giving up.
+const maxEmptyReads = 100
+
+// readerIDs numbers reader-backed archives that have no cache key, so their
+// frame cache entries are never shared.
+var readerIDs atomic.Uint64
+
+// readFullAt reads exactly len(p) bytes from ra at off.
+//
+// io.ReaderAt implementations, especially network-backed ones, may return
+// fewer bytes than requested with a nil error, or with io.EOF before the
+// requested range is exhausted. Partial reads are retried until p is full.
+func readFullAt(ra io.ReaderAt, p []byte, off int64) error {
+	empty := 0
+	for n := 0; n < len(p); {
+		m, err := ra.ReadAt(p[n:], off+int64(n))
+		n += m
+		if n == len(p) {
+			return nil
+		}
+
+		switch {
+		case err == io.EOF:
+			return io.ErrUnexpectedEOF
+		case err != nil:
+			return err
+		case m == 0:
+			empty++
+			if empty >= maxEmptyReads {
+				return io.ErrNoProgress
+			}
+		default:
+			empty = 0
+		}
+	}
+	return nil
+}
+
+// readFrameBytes reads the compressed bytes of a frame from the source into
+// p, retrying transient failures as configured by WithFrameRetry. Retrying
+// stops once ctx is done, including while waiting out a backoff.
+//
+// With WithReadTimeout, the source reads straight into p, and a read that
+// times out may still write into p after it is abandoned. readFrameBytes
+// then retries into a fresh buffer. It returns the buffer holding the bytes,
+// or nil if the read failed after a timeout, in which case the caller must
+// no longer use p.
+func (r *Reader) readFrameBytes(ctx context.Context, p []byte, off int64) ([]byte, error) {
+	n := len(p)
+	src := r.src
+	t, direct := src.(*timeoutReaderAt)
+	if direct {
+		src = directReaderAt{t}
+	}
+	err := readFullAt(src, p, off)
+	if direct && errors.Is(err, os.ErrDeadlineExceeded) {
+		p = nil
+	}
+	for attempt := 1; attempt <= r.cfg.retries && err != nil && retryable(err); attempt++ {
+		if b := r.cfg.retryBackoff; b != nil {
+			if werr := sleepCtx(ctx, b(attempt)); werr != nil {
+				return p, fmt.Errorf("%w (retry abandoned: %w)", err, werr)
+			}
+		} else if werr := ctx.Err(); werr != nil {
+			return p, fmt.Errorf("%w (retry abandoned: %w)", err, werr)
+		}
+		if l := r.cfg.logger; l != nil {
+			l.LogAttrs(context.Background(), slog.LevelDebug, "seekable: retrying frame read",
+				slog.Int64("offset", off),
+				slog.Int("attempt", attempt),
+				slog.String("error", err.Error()))
+		}
+		if p == nil {
+			p = make([]byte, n)
+		}
+		err = readFullAt(src, p, off)
+		if direct && errors.Is(err, os.ErrDeadlineExceeded) {
+			p = nil
+		}
+		if err != nil && attempt == r.cfg.retries {
+			err = fmt.Errorf("%w (after %d attempts)", err, attempt+1)
+		}
+	}
+	return p, err
+}
+
+// sleepCtx waits for d, returning early with ctx's error if ctx is done first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// retryable reports whether a failed source read may succeed if repeated.
+func retryable(err error) bool {
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, context.Canceled):
+		return false
+	}
+	return true
+}
+
+// OpenReader opens a seekable zstd archive stored in ra, whose total size is
+// size bytes. The archive may be followed by trailing data.
+//
+// Frames are decoded in Go as they are read, fetching only the compressed
+// bytes of the frames a read needs. Partial reads from ra are retried, so ra
+// may return short reads. The caller keeps ownership of ra: Close does not
+// close it, and ra must remain usable while the Reader is open.
+func OpenReader(ra io.ReaderAt, size int64, opts ...Option) (*Reader, error) {
+	cfg := newConfig(opts)
+	ra = cfg.source(ra)
+
+	table, err := findSeekTable(ra, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.checkDecodeParams(); err != nil {
+		return nil, err
+	}
+
+	r := &Reader{cfg: cfg, src: ra, srcSize: size, h: &handles{refs: 1}}
+	r.setTable(table)
+	r.setIdentity(fmt.Sprintf("reader:%d", readerIDs.Add(1)))
+	return r.finishOpen()
+}
+
+// maxStalledReads bounds the reads of one source that may still be running
+// after timing out. A read cannot be interrupted, so each one holds a
+// goroutine and its buffer until the source returns.
+const maxStalledReads = 16
+
+// timeoutReaderAt bounds the duration of each read from an underlying
+// io.ReaderAt.
+type timeoutReaderAt struct {
+	ra      io.ReaderAt
+	timeout time.Duration
+	// stalled counts the reads that timed out and have not yet returned.
+	stalled atomic.Int32
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+// Read states, for the handoff between a read and its caller.
+const (
+	readRunning int32 = iota
+	readFinished
+	readAbandoned
+)
+
+// ReadAt reads into a private buffer so that a read which outlives its
+// deadline can never write into p after ReadAt has returned.
+func (t *timeoutReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	buf := make([]byte, len(p))
+	n, err := t.read(buf, off)
+	copy(p, buf[:n])
+	return n, err
+}
+
+// read reads into p with the timeout. After a timeout, the abandoned read
+// may still write into p. Once maxStalledReads reads are abandoned, read
+// fails at once until one of them returns.
+func (t *timeoutReaderAt) read(p []byte, off int64) (int, error) {
+	if n := t.stalled.Load(); n >= maxStalledReads {
+		return 0, fmt.Errorf("seekable: %d source reads still stalled after timing out: %w", n, os.ErrDeadlineExceeded)
+	}
+
+	var state atomic.Int32
+	done := make(chan readResult, 1)
+	go func() {
+		n, err := t.ra.ReadAt(p, off)
+		done <- readResult{n, err}
+		if !state.CompareAndSwap(readRunning, readFinished) {
+			t.stalled.Add(-1)
+		}
+	}()
+
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-done:
+		return res.n, res.err
+	case <-timer.C:
+		if !state.CompareAndSwap(readRunning, readAbandoned) {
+			// The read finished as the timer fired.
+			res := <-done
+			return res.n, res.err
+		}
+		t.stalled.Add(1)
+		return 0, fmt.Errorf("seekable: source read at offset %d timed out after %v: %w", off, t.timeout, os.ErrDeadlineExceeded)
+	}
+}
+
+// directReaderAt reads from a timeoutReaderAt straight into the caller's
+// buffer, for callers that stop using the buffer after a timeout.
+type directReaderAt struct {
+	t *timeoutReaderAt
+}
+
+func (d directReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return d.t.read(p, off)
+}
+
+// OpenBytes opens a seekable zstd archive held in memory.
+//
+// The Reader reads data in place: the slice is neither copied nor handed to
+// the core decoder, so any number of Readers can share one buffer, each with
+// its own independent state. The caller must not modify data while any Reader
+// over it is open.
+func OpenBytes(data []byte, opts ...Option) (*Reader, error) {
+	return OpenReader(bytes.NewReader(data), int64(len(data)), opts...)
+}
+
+// OpenFS opens the seekable zstd archive name in fsys, such as an embed.FS.
+//
+// A file that implements io.ReaderAt, as the files of embed.FS, os.DirFS and
+// fstest.MapFS do, is read in place like OpenReader reads its source, and is
+// closed by Close. Other files are read into memory in full and opened with
+// OpenBytes. Errors opening or reading the file are returned as fsys reports
+// them; a file that is not a valid archive fails with an error wrapping
+// ErrInvalidArchive.
+func OpenFS(fsys fs.FS, name string, opts ...Option) (*Reader, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ra, ok := f.(io.ReaderAt)
+	if !ok {
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+		}
+		return OpenBytes(data, opts...)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r, err := OpenReader(ra, info.Size(), opts...)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if osf, ok := f.(*os.File); ok {
+		r.h.file = osf
+		r.modTime = info.ModTime()
+	} else {
+		r.h.closer = f
+	}
+	return r, nil
+}
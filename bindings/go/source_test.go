@@ -0,0 +1,504 @@
+package seekable
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// chunkyReaderAt returns at most one byte per call, with a nil error until
+// the end of the data.
+type chunkyReaderAt struct {
+	data  []byte
+	calls int
+}
+
+func (c *chunkyReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.calls++
+	if off >= int64(len(c.data)) {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	p[0] = c.data[off]
+	return 1, nil
+}
+
+// stalledReaderAt never returns data and never reports an error.
+type stalledReaderAt struct{}
+
+func (stalledReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return 0, nil
+}
+
+func TestOpenReader(t *testing.T) {
+	data := buildArchive(testChunks...)
+
+	r, err := OpenReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	if r.Size() != uint64(len(testContent)) {
+		t.Errorf("Expected size %d, got %d", len(testContent), r.Size())
+	}
+
+	got, err := r.ReadRange(3, 22)
+	if err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if string(got) != testContent[3:22] {
+		t.Errorf("Expected '%s', got '%s'", testContent[3:22], string(got))
+	}
+}
+
+func TestOpenReaderPartialReads(t *testing.T) {
+	src := &chunkyReaderAt{data: buildArchive(testChunks...)}
+
+	r, err := OpenReader(src, int64(len(src.data)))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	all, err := r.DecompressAll()
+	if err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+	if string(all) != testContent {
+		t.Errorf("Expected '%s', got '%s'", testContent, string(all))
+	}
+	if src.calls < len(testContent) {
+		t.Errorf("Expected one source call per byte, got %d calls", src.calls)
+	}
+}
+
+func TestOpenReaderStalledSource(t *testing.T) {
+	_, err := OpenReader(stalledReaderAt{}, 1024)
+	if !errors.Is(err, io.ErrNoProgress) {
+		t.Errorf("Expected io.ErrNoProgress, got %v", err)
+	}
+}
+
+func TestOpenReaderTruncatedSource(t *testing.T) {
+	data := buildArchive(testChunks...)
+
+	// Claim a larger size than the source holds.
+	_, err := OpenReader(bytes.NewReader(data), int64(len(data))+10)
+	if err == nil {
+		t.Error("Expected error for truncated source")
+	}
+}
+
+func TestOpenBytesShared(t *testing.T) {
+	data := buildArchive(testChunks...)
+
+	readers := make([]*Reader, 4)
+	for i := range readers {
+		r, err := OpenBytes(data)
+		if err != nil {
+			t.Fatalf("OpenBytes failed: %v", err)
+		}
+		defer r.Close()
+		readers[i] = r
+	}
+
+	var wg sync.WaitGroup
+	for i, r := range readers {
+		i, r := i, r
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := uint64(i * 5)
+			got, err := r.ReadRange(start, start+5)
+			if err != nil || string(got) != testContent[start:start+5] {
+				t.Errorf("ReadRange(%d) = (%q, %v)", start, got, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Closing one Reader leaves the others, and the buffer, usable.
+	readers[0].Close()
+	got, err := readers[1].DecompressAll()
+	if err != nil || string(got) != testContent {
+		t.Errorf("DecompressAll = (%q, %v)", got, err)
+	}
+	if !bytes.Equal(data, buildArchive(testChunks...)) {
+		t.Error("OpenBytes modified the buffer")
+	}
+}
+
+// blockingReaderAt blocks reads below offset limit until release is closed.
+type blockingReaderAt struct {
+	data    []byte
+	limit   int64
+	release chan struct{}
+}
+
+func (b *blockingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < b.limit {
+		<-b.release
+	}
+	return bytes.NewReader(b.data).ReadAt(p, off)
+}
+
+func TestWithDecodeTimeout(t *testing.T) {
+	data := buildArchive(testChunks...)
+	src := &blockingReaderAt{data: data, limit: 20, release: make(chan struct{})}
+
+	r, err := OpenReader(src, int64(len(data)), WithDecodeTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := r.ReadAt(make([]byte, 4), 0); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("ReadAt() = %v, want os.ErrDeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ReadAt took %v despite the timeout", elapsed)
+	}
+	if got, err := r.ReadRange(20, 25); err != nil || string(got) != "delta" {
+		t.Errorf("ReadRange(20, 25) = (%q, %v)", got, err)
+	}
+
+	// The abandoned decode finishes after Close without touching the Reader.
+	h := r.h
+	r.Close()
+	close(src.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h.mu.Lock()
+		refs := h.refs
+		h.mu.Unlock()
+		if refs == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("abandoned decode never released the handles")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Decodes that finish in time, including through the core decoder.
+	fr := openArchive(t, testChunks, WithDecodeTimeout(time.Minute))
+	if got, err := fr.DecompressAll(); err != nil || string(got) != testContent {
+		t.Errorf("DecompressAll = (%q, %v)", got, err)
+	}
+}
+
+func TestWithDecodeTimeoutCoreFallback(t *testing.T) {
+	r := openArchive(t, testChunks, WithDecodeTimeout(20*time.Millisecond))
+	if r.ptr == nil {
+		t.Skip("archive is not decoded by the core decoder")
+	}
+
+	// A core decode stuck behind the decoder lock times out, and later
+	// frames are decoded in Go instead of queueing behind it.
+	r.h.decode.Lock()
+	if _, err := r.ReadAt(make([]byte, 5), 0); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("ReadAt with the core decoder busy = %v, want os.ErrDeadlineExceeded", err)
+	}
+	if got, err := r.ReadRange(6, 12); err != nil || string(got) != "bravo-" {
+		t.Errorf("ReadRange after a core timeout = (%q, %v)", got, err)
+	}
+	r.h.decode.Unlock()
+}
+
+func TestWithReadTimeout(t *testing.T) {
+	data := buildArchive(testChunks...)
+	// Frames are stored first, so blocking the first 20 bytes stalls frame
+	// fetches while the seek table still loads.
+	src := &blockingReaderAt{data: data, limit: 20, release: make(chan struct{})}
+	defer close(src.release)
+
+	r, err := OpenReader(src, int64(len(data)), WithReadTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	start := time.Now()
+	_, err = r.ReadAt(make([]byte, 4), 0)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("ReadAt() = %v, want os.ErrDeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ReadAt took %v despite the timeout", elapsed)
+	}
+
+	// Frames outside the stalled region still read.
+	got, err := r.ReadRange(20, 25)
+	if err != nil || string(got) != "delta" {
+		t.Errorf("ReadRange(20, 25) = (%q, %v)", got, err)
+	}
+}
+
+func TestWithReadTimeoutStalledReads(t *testing.T) {
+	data := buildArchive(testChunks...)
+	src := &blockingReaderAt{data: data, limit: 20, release: make(chan struct{})}
+	r, err := OpenReader(src, int64(len(data)), WithReadTimeout(time.Millisecond))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+	ta := r.src.(*timeoutReaderAt)
+
+	for i := 0; i < maxStalledReads; i++ {
+		if _, err := r.ReadRange(0, 5); !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatalf("ReadRange %d = %v, want os.ErrDeadlineExceeded", i, err)
+		}
+	}
+	if n := ta.stalled.Load(); n != maxStalledReads {
+		t.Fatalf("%d stalled reads, want %d", n, maxStalledReads)
+	}
+	// Past the bound, reads fail without starting another goroutine.
+	if _, err := r.ReadRange(20, 25); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("ReadRange with too many stalled reads = %v, want os.ErrDeadlineExceeded", err)
+	}
+
+	close(src.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for ta.stalled.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("stalled reads never finished")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got, err := r.ReadRange(0, 5); err != nil || string(got) != "alpha" {
+		t.Errorf("ReadRange after the stalled reads finished = (%q, %v)", got, err)
+	}
+}
+
+func TestWithKnownSize(t *testing.T) {
+	data := buildArchive(testChunks...)
+	path := writeArchive(t, data)
+
+	r, err := OpenBytes(data, WithKnownSize(uint64(len(testContent))))
+	if err != nil {
+		t.Fatalf("OpenBytes with the right size failed: %v", err)
+	}
+	r.Close()
+
+	if _, err := OpenBytes(data, WithKnownSize(7)); err == nil {
+		t.Error("OpenBytes with the wrong size succeeded")
+	}
+	if _, err := Open(path, WithKnownSize(uint64(len(testContent))+1)); err == nil {
+		t.Error("Open with the wrong size succeeded")
+	}
+	openArchivePath(t, path, WithKnownSize(uint64(len(testContent))))
+}
+
+func TestWithMaxExpansionRatio(t *testing.T) {
+	// A frame of 14 bytes that claims to decompress to 1 GiB.
+	frame := rawFrame([]byte("alpha"))
+	data := append(frame, seekTableFrame([]uint32{uint32(len(frame))}, []uint32{1 << 30})...)
+	path := writeArchive(t, data)
+
+	if _, err := OpenBytes(data); !errors.Is(err, ErrSuspiciousArchive) {
+		t.Errorf("OpenBytes = %v, want ErrSuspiciousArchive", err)
+	}
+	if _, err := Open(path); !errors.Is(err, ErrSuspiciousArchive) {
+		t.Errorf("Open = %v, want ErrSuspiciousArchive", err)
+	}
+	if _, err := OpenBytes(data, WithMaxExpansionRatio(1<<20)); !errors.Is(err, ErrSuspiciousArchive) {
+		t.Errorf("OpenBytes with a ratio of 1<<20 = %v, want ErrSuspiciousArchive", err)
+	}
+
+	// Disabling the check, or allowing the ratio, opens the archive.
+	for _, ratio := range []uint64{0, 1 << 27} {
+		r, err := OpenBytes(data, WithMaxExpansionRatio(ratio))
+		if err != nil {
+			t.Fatalf("OpenBytes with a ratio of %d failed: %v", ratio, err)
+		}
+		r.Close()
+	}
+
+	// Ordinary archives are below the default.
+	openArchivePath(t, writeArchive(t, buildArchive(testChunks...)), WithMaxExpansionRatio(1))
+}
+
+// flakyReaderAt fails the next failures reads of the data frames, those
+// before tableStart, with a transient error, and counts the reads there.
+type flakyReaderAt struct {
+	data       []byte
+	tableStart int64
+	failures   int
+	calls      int
+}
+
+func (f *flakyReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < f.tableStart {
+		f.calls++
+		if f.failures > 0 {
+			f.failures--
+			return 0, errFlaky
+		}
+	}
+	return bytes.NewReader(f.data).ReadAt(p, off)
+}
+
+func TestWithFrameRetry(t *testing.T) {
+	data := buildArchive(testChunks...)
+	var framesLen int64
+	for _, c := range testChunks {
+		framesLen += int64(len(rawFrame([]byte(c))))
+	}
+
+	open := func(t *testing.T, src *flakyReaderAt, opts ...Option) *Reader {
+		t.Helper()
+		r, err := OpenReader(src, int64(len(src.data)), opts...)
+		if err != nil {
+			t.Fatalf("OpenReader failed: %v", err)
+		}
+		t.Cleanup(func() { r.Close() })
+		return r
+	}
+
+	t.Run("recovers", func(t *testing.T) {
+		src := &flakyReaderAt{data: data, tableStart: framesLen}
+		var waits []int
+		r := open(t, src, WithFrameRetry(3, func(attempt int) time.Duration {
+			waits = append(waits, attempt)
+			return time.Millisecond
+		}))
+		src.failures = 3
+		if got, err := r.ReadRange(0, r.Size()); err != nil || string(got) != testContent {
+			t.Fatalf("ReadRange = (%q, %v)", got, err)
+		}
+		if fmt.Sprint(waits) != "[1 2 3]" {
+			t.Errorf("backoff called for attempts %v, want [1 2 3]", waits)
+		}
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		src := &flakyReaderAt{data: data, tableStart: framesLen}
+		r := open(t, src, WithFrameRetry(2, nil))
+		src.failures = 3
+		if _, err := r.ReadRange(0, 5); !errors.Is(err, errFlaky) {
+			t.Fatalf("ReadRange = %v, want the source error", err)
+		}
+		if src.calls != 3 {
+			t.Errorf("source read %d times, want 3", src.calls)
+		}
+		// The source has recovered, so the next read succeeds.
+		if got, err := r.ReadRange(0, 5); err != nil || string(got) != "alpha" {
+			t.Errorf("ReadRange after failure = (%q, %v)", got, err)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		src := &flakyReaderAt{data: data, tableStart: framesLen}
+		ctx, cancel := context.WithCancel(context.Background())
+		r := open(t, src, WithFrameRetry(5, func(int) time.Duration {
+			cancel()
+			return time.Hour
+		}))
+		src.failures = 5
+		buf := make([]byte, 5)
+		if _, err := r.ReadAtContext(ctx, buf, 0); !errors.Is(err, context.Canceled) || !errors.Is(err, errFlaky) {
+			t.Fatalf("ReadAtContext = %v, want the source error and context.Canceled", err)
+		}
+		if src.calls != 1 {
+			t.Errorf("source read %d times, want 1", src.calls)
+		}
+	})
+
+	t.Run("without retry", func(t *testing.T) {
+		src := &flakyReaderAt{data: data, tableStart: framesLen}
+		r := open(t, src)
+		src.failures = 1
+		if _, err := r.ReadRange(0, 5); !errors.Is(err, errFlaky) {
+			t.Errorf("ReadRange = %v, want the source error", err)
+		}
+	})
+
+	t.Run("truncated source", func(t *testing.T) {
+		src := &flakyReaderAt{data: data, tableStart: framesLen}
+		r := open(t, src, WithFrameRetry(3, nil))
+		src.data = data[:4]
+		if _, err := r.ReadRange(0, 5); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("ReadRange = %v, want io.ErrUnexpectedEOF", err)
+		}
+		if src.calls != 1 {
+			t.Errorf("source read %d times, want 1", src.calls)
+		}
+	})
+
+	t.Run("corrupt frame", func(t *testing.T) {
+		corrupt := bytes.Clone(data)
+		corrupt[6] |= 0x06 // reserved block type
+		src := &flakyReaderAt{data: corrupt, tableStart: framesLen}
+		r := open(t, src, WithFrameRetry(3, nil))
+		if _, err := r.ReadRange(0, 5); err == nil {
+			t.Fatal("Expected ReadRange of a corrupt frame to fail")
+		}
+		if src.calls != 1 {
+			t.Errorf("source read %d times, want 1", src.calls)
+		}
+	})
+}
+
+// readOnlyFS serves the files of an fs.FS as plain fs.File values, without
+// their io.ReaderAt method.
+type readOnlyFS struct{ fs.FS }
+
+func (f readOnlyFS) Open(name string) (fs.File, error) {
+	file, err := f.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{file}, nil
+}
+
+func TestOpenFS(t *testing.T) {
+	data := buildArchive(testChunks...)
+	path := writeArchive(t, data)
+	mapFS := fstest.MapFS{"ref/test.szst": {Data: data}, "ref/bad.szst": {Data: []byte("not an archive")}}
+
+	for name, tt := range map[string]struct {
+		fsys fs.FS
+		name string
+	}{
+		"ReaderAt":   {mapFS, "ref/test.szst"},
+		"read fully": {readOnlyFS{mapFS}, "ref/test.szst"},
+		"os.DirFS":   {os.DirFS(filepath.Dir(path)), filepath.Base(path)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r, err := OpenFS(tt.fsys, tt.name)
+			if err != nil {
+				t.Fatalf("OpenFS failed: %v", err)
+			}
+			if got, err := r.ReadRange(0, r.Size()); err != nil || string(got) != testContent {
+				t.Errorf("ReadRange = (%q, %v)", got, err)
+			}
+			if _, err := r.SizeErr(); err != nil {
+				t.Errorf("SizeErr failed: %v", err)
+			}
+			if err := r.Close(); err != nil {
+				t.Errorf("Close failed: %v", err)
+			}
+		})
+	}
+
+	if _, err := OpenFS(mapFS, "ref/missing.szst"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("OpenFS of a missing file = %v, want fs.ErrNotExist", err)
+	}
+	for _, fsys := range []fs.FS{mapFS, readOnlyFS{mapFS}} {
+		if _, err := OpenFS(fsys, "ref/bad.szst"); !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("OpenFS of an invalid archive = %v, want ErrInvalidArchive", err)
+		}
+	}
+}
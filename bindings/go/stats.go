@@ -0,0 +1,65 @@
+package seekable
+
+import "unsafe"
+
+// Stats is a snapshot of a Reader's resource use.
+type Stats struct {
+	// DecoderBytes estimates the state held by the core decoder, which is
+	// dominated by its window buffer. Readers that decode frames in Go hold
+	// no decoder between reads and report 0.
+	DecoderBytes uint64
+	// IndexBytes is the memory held by the parsed seek table and metadata.
+	IndexBytes uint64
+	// PinnedBytes is the decompressed size of the frames pinned by
+	// WithPinnedFrames, or of the whole content for a Reader opened with
+	// OpenFullyDecoded.
+	PinnedBytes uint64
+	// CacheBytes is the decompressed size of this archive's frames currently
+	// held in the FrameCache. Readers sharing a cache over the same archive
+	// each report the shared frames.
+	CacheBytes uint64
+}
+
+// MemoryUsage returns the sum of the Stats counters.
+func (s Stats) MemoryUsage() uint64 {
+	return s.DecoderBytes + s.IndexBytes + s.PinnedBytes + s.CacheBytes
+}
+
+// Stats returns current resource use statistics for r. A closed Reader
+// holds nothing and reports zero.
+func (r *Reader) Stats() Stats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var s Stats
+	if r.h == nil {
+		return s
+	}
+	v := r.view.Load()
+
+	if r.ptr != nil {
+		s.DecoderBytes = estimateDStreamSize(v.table.maxDecompSize)
+	}
+
+	s.IndexBytes = uint64(cap(v.table.frames)) * uint64(unsafe.Sizeof(frameEntry{}))
+	for tag, value := range v.table.metadata {
+		s.IndexBytes += uint64(len(tag) + len(value))
+	}
+
+	for _, p := range r.pinned {
+		s.PinnedBytes += uint64(len(p))
+	}
+	s.PinnedBytes += uint64(len(r.decoded))
+
+	if r.cfg.cache != nil {
+		s.CacheBytes = r.cfg.cache.archiveSize(r.id)
+	}
+	return s
+}
+
+// MemoryUsage returns an estimate of the memory, in bytes, that r holds: the
+// core decoder state, the seek table, pinned frames and this archive's frames
+// in the frame cache. It is meant for capacity planning, not exact
+// accounting; Stats reports the breakdown.
+func (r *Reader) MemoryUsage() uint64 {
+	return r.Stats().MemoryUsage()
+}
@@ -0,0 +1,53 @@
+package seekable
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	cache := NewFrameCache(1 << 20)
+	r := openArchive(t, testChunks, WithFrameCache(cache), WithPinnedFrames(2))
+	other := openArchive(t, []string{"unrelated"}, WithFrameCache(cache))
+
+	if _, err := r.DecompressAll(); err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+	if _, err := other.DecompressAll(); err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+
+	s := r.Stats()
+	if s.DecoderBytes == 0 {
+		t.Error("Expected a core decoder estimate")
+	}
+	if s.IndexBytes == 0 {
+		t.Error("Expected seek table bytes")
+	}
+	if s.PinnedBytes != 12 {
+		t.Errorf("Expected 12 pinned bytes, got %d", s.PinnedBytes)
+	}
+	if s.CacheBytes != uint64(len(testContent)) {
+		t.Errorf("Expected %d cached bytes, got %d", len(testContent), s.CacheBytes)
+	}
+	if got := r.MemoryUsage(); got != s.DecoderBytes+s.IndexBytes+s.PinnedBytes+s.CacheBytes {
+		t.Errorf("MemoryUsage() = %d, want the sum of %+v", got, s)
+	}
+}
+
+func TestStatsReader(t *testing.T) {
+	data := buildArchive(testChunks...)
+	r, err := OpenReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	s := r.Stats()
+	if s.DecoderBytes != 0 || s.PinnedBytes != 0 || s.CacheBytes != 0 {
+		t.Errorf("Unexpected usage for a reader without resident state: %+v", s)
+	}
+	if r.MemoryUsage() != s.IndexBytes {
+		t.Errorf("MemoryUsage() = %d, want %d", r.MemoryUsage(), s.IndexBytes)
+	}
+}
@@ -0,0 +1,89 @@
+package seekable
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// storedFixtureContent returns the content of stored-frames.szst: three
+// 4 KiB frames, the middle one random so that zstd stores it uncompressed.
+func storedFixtureContent() []byte {
+	text := bytes.Repeat([]byte("compressible text "), 4096/18+1)[:4096]
+	random := make([]byte, 4096)
+	rand.New(rand.NewSource(174)).Read(random)
+
+	content := append([]byte{}, text...)
+	content = append(content, random...)
+	return append(content, text...)
+}
+
+// firstBlockType returns the type of the first block of a zstd frame.
+func firstBlockType(t testing.TB, frame []byte) byte {
+	t.Helper()
+	fhd := frame[4]
+	singleSegment := fhd&0x20 != 0
+	n := 5 + []int{0, 1, 2, 4}[fhd&3]
+	if !singleSegment {
+		n++ // window descriptor
+	}
+	switch fhd >> 6 {
+	case 0:
+		if singleSegment {
+			n++
+		}
+	case 1:
+		n += 2
+	case 2:
+		n += 4
+	case 3:
+		n += 8
+	}
+	if len(frame) < n+3 {
+		t.Fatalf("frame of %d bytes has no block header", len(frame))
+	}
+	return frame[n] >> 1 & 3
+}
+
+func TestStoredFramesFixture(t *testing.T) {
+	content := storedFixtureContent()
+
+	for name, opts := range map[string][]Option{
+		"core": nil,
+		"go":   {WithSizeChecks()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := openFixture(t, "stored-frames.szst", opts...)
+			if r.FrameCount() != 3 || r.Size() != uint64(len(content)) {
+				t.Fatalf("got %d frames, %d bytes", r.FrameCount(), r.Size())
+			}
+
+			frame, err := r.ReadCompressedFrame(1)
+			if err != nil {
+				t.Fatalf("ReadCompressedFrame(1) failed: %v", err)
+			}
+			if len(frame) < 4096 || firstBlockType(t, frame) != 0 {
+				t.Fatalf("frame 1 is not stored: %d bytes, block type %d", len(frame), firstBlockType(t, frame))
+			}
+
+			for _, rng := range [][2]uint64{
+				{4096, 8192}, // the stored frame
+				{5000, 6000}, // inside it
+				{4000, 8300}, // across both of its boundaries
+				{0, uint64(len(content))},
+			} {
+				got, err := r.ReadRange(rng[0], rng[1])
+				if err != nil {
+					t.Fatalf("ReadRange(%d, %d) failed: %v", rng[0], rng[1], err)
+				}
+				if !bytes.Equal(got, content[rng[0]:rng[1]]) {
+					t.Errorf("ReadRange(%d, %d) content does not match", rng[0], rng[1])
+				}
+			}
+
+			if err := r.DeepValidate(); err != nil {
+				t.Errorf("DeepValidate failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package seekable
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// StreamOption configures NewStream.
+type StreamOption func(*streamConfig)
+
+// streamConfig holds the settings applied by StreamOption values.
+type streamConfig struct {
+	depth       int
+	maxBuffered uint64
+}
+
+// WithStreamPipelineDepth makes the stream decode up to n frames ahead of
+// the read position on a background goroutine, so decoding overlaps with
+// consumption. Decoded frames wait in a ring of n+1 buffers, one of them
+// being read, and decoding pauses while the ring is full, so memory use is
+// bounded by n+1 times the largest frame. Values below 1, the default, decode
+// each frame when it is first read, like RangeReader, as does any depth on a
+// Reader opened with WithNoReadahead.
+func WithStreamPipelineDepth(n int) StreamOption {
+	return func(c *streamConfig) {
+		c.depth = n
+	}
+}
+
+// WithMaxBufferedBytes caps the decoded bytes a pipelined stream holds ahead
+// of the read position at n, whatever its pipeline depth: decoding pauses
+// until the reader has consumed enough for the next frame to fit. A frame
+// larger than n is still decoded once nothing else is buffered, so the
+// stream always makes progress. Buffers are released as soon as they are
+// read rather than kept for reuse, so memory use follows the cap. Zero, the
+// default, leaves only the depth limit. It has no effect without
+// WithStreamPipelineDepth.
+func WithMaxBufferedBytes(n uint64) StreamOption {
+	return func(c *streamConfig) {
+		c.maxBuffered = n
+	}
+}
+
+// Stream reads a range of decompressed content, optionally decoding ahead of
+// the read position; see NewStream.
+type Stream struct {
+	// rr serves streams without a pipeline; the other fields are unused.
+	rr *rangeReader
+
+	filled chan streamChunk
+	free   chan []byte
+	stop   chan struct{}
+	done   chan struct{}
+	// space is signalled when the reader consumes bytes, waking a decoder
+	// waiting for the buffered bytes to drop below maxBuffered.
+	space chan struct{}
+
+	// buffered counts the bytes decoded, or being decoded, ahead of the read
+	// position; limit is the budget Pressure measures it against.
+	buffered    atomic.Uint64
+	maxBuffered uint64
+	limit       uint64
+
+	// cur is the buffer being read and pending its unread part.
+	cur     []byte
+	pending []byte
+	err     error
+	closed  bool
+}
+
+// NewStream returns a reader over the decompressed bytes in [start, end),
+// like RangeReader, configured by opts. Closing the stream stops any
+// background decoding and waits for it to finish; the Reader itself stays
+// open.
+func (r *Reader) NewStream(start, end uint64, opts ...StreamOption) (*Stream, error) {
+	if start > end {
+		return nil, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
+	}
+
+	if end > r.Size() {
+		return nil, fmt.Errorf("range end (%d) exceeds size (%d)", end, r.Size())
+	}
+
+	var cfg streamConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.depth < 1 || r.cfg.noReadahead {
+		return &Stream{rr: &rangeReader{r: r, off: start, end: end}}, nil
+	}
+
+	s := &Stream{
+		filled:      make(chan streamChunk, cfg.depth-1),
+		free:        make(chan []byte, cfg.depth+1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		space:       make(chan struct{}, 1),
+		maxBuffered: cfg.maxBuffered,
+		limit:       uint64(cfg.depth) * r.view.Load().table.maxDecompSize,
+	}
+	if s.maxBuffered > 0 {
+		s.limit = min(s.limit, s.maxBuffered)
+	}
+	for i := 0; i <= cfg.depth; i++ {
+		s.free <- nil
+	}
+	go s.decode(r, start, end)
+	return s, nil
+}
+
+// streamChunk is a decoded part of a frame, or the error that ended decoding.
+type streamChunk struct {
+	data []byte
+	err  error
+}
+
+// decode decodes [start, end) chunk by chunk into buffers taken from free and
+// sends them to filled, until the range ends, a decode fails or the stream
+// is closed.
+//
+// Buffers circulate between free, the decoder, filled and the reader, so
+// their number never exceeds the capacity of free. With one buffer held by
+// the reader and one by the decoder, filled holds at most depth-1, which
+// keeps decoding at most depth chunks ahead.
+func (s *Stream) decode(r *Reader, start, end uint64) {
+	v := r.view.Load()
+	defer close(s.done)
+	defer close(s.filled)
+
+	for off := start; off < end; {
+		var buf []byte
+		select {
+		case buf = <-s.free:
+		case <-s.stop:
+			return
+		}
+
+		f := v.table.frames[v.table.frameIndex(off)]
+		chunkEnd := min(f.decompOffset+f.decompSize, end)
+		n := chunkEnd - off
+		if !s.reserve(n) {
+			return
+		}
+
+		if uint64(cap(buf)) < n {
+			buf = make([]byte, n)
+		}
+		buf = buf[:n]
+		err := r.readChunk(buf, off)
+
+		select {
+		case s.filled <- streamChunk{data: buf, err: err}:
+		case <-s.stop:
+			return
+		}
+		if err != nil {
+			return
+		}
+		off = chunkEnd
+	}
+}
+
+// reserve waits until n more bytes fit under maxBuffered, or nothing is
+// buffered, and counts them as buffered. It reports false if the stream was
+// closed while waiting.
+func (s *Stream) reserve(n uint64) bool {
+	for s.maxBuffered > 0 {
+		b := s.buffered.Load()
+		if b == 0 || b+n <= s.maxBuffered {
+			break
+		}
+		select {
+		case <-s.space:
+		case <-s.stop:
+			return false
+		}
+	}
+	s.buffered.Add(n)
+	return true
+}
+
+// Read implements io.Reader.
+func (s *Stream) Read(p []byte) (int, error) {
+	if s.rr != nil {
+		return s.rr.Read(p)
+	}
+	if s.closed {
+		return 0, errRangeReaderClosed
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if len(s.pending) == 0 {
+		if s.cur != nil {
+			s.release(s.cur)
+			s.cur = nil
+		}
+		c, ok := <-s.filled
+		if !ok {
+			s.err = io.EOF
+			return 0, io.EOF
+		}
+		if c.err != nil {
+			s.err = c.err
+			return 0, c.err
+		}
+		s.cur, s.pending = c.data, c.data
+	}
+
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	s.buffered.Add(^uint64(n - 1))
+	select {
+	case s.space <- struct{}{}:
+	default:
+	}
+	return n, nil
+}
+
+// release hands a consumed buffer back to the decoder, or drops it when the
+// stream caps its buffered bytes.
+func (s *Stream) release(buf []byte) {
+	if s.maxBuffered > 0 {
+		buf = nil
+	}
+	s.free <- buf
+}
+
+// Buffered returns the number of decoded bytes the stream holds ahead of the
+// read position, including a frame being decoded. It is 0 for streams
+// without a pipeline, and safe to call from any goroutine.
+func (s *Stream) Buffered() uint64 {
+	if s.rr != nil {
+		return 0
+	}
+	return s.buffered.Load()
+}
+
+// Pressure returns Buffered as a fraction of the stream's budget: the
+// WithMaxBufferedBytes cap, or the pipeline depth times the largest frame if
+// that is smaller. A value near 1 means decoding is waiting for the consumer;
+// a value near 0 means the consumer is waiting for decoding. It may exceed 1
+// briefly when a frame larger than the cap is buffered, and is 0 for streams
+// without a pipeline. It is safe to call from any goroutine.
+func (s *Stream) Pressure() float64 {
+	if s.rr != nil || s.limit == 0 {
+		return 0
+	}
+	return float64(s.buffered.Load()) / float64(s.limit)
+}
+
+// Close stops the background decoder and waits for it to exit, which may take
+// until the frame it is decoding is done. Reads after Close fail.
+func (s *Stream) Close() error {
+	if s.rr != nil {
+		return s.rr.Close()
+	}
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.stop)
+	<-s.done
+	s.cur, s.pending = nil, nil
+	return nil
+}
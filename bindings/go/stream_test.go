@@ -0,0 +1,203 @@
+package seekable
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestNewStream(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	for _, depth := range []int{0, 1, 3} {
+		for _, rng := range [][2]uint64{{1, 4}, {4, 22}, {0, uint64(len(testContent))}, {7, 7}} {
+			t.Run(fmt.Sprintf("depth %d [%d,%d)", depth, rng[0], rng[1]), func(t *testing.T) {
+				s, err := r.NewStream(rng[0], rng[1], WithStreamPipelineDepth(depth))
+				if err != nil {
+					t.Fatalf("NewStream failed: %v", err)
+				}
+				defer s.Close()
+
+				if err := iotest.TestReader(s, []byte(testContent[rng[0]:rng[1]])); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+
+	if _, err := r.NewStream(5, 4); err == nil {
+		t.Error("Expected error for start > end")
+	}
+	if _, err := r.NewStream(0, 100); err == nil {
+		t.Error("Expected error for end past size")
+	}
+}
+
+// decodedCount waits for c to report want decoded frames and returns the
+// count once it has been stable for a while.
+func decodedCount(c *recordingCollector, want int) int {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		n := len(c.decoded)
+		c.mu.Unlock()
+		if n >= want {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.decoded)
+}
+
+func TestNewStreamBackpressure(t *testing.T) {
+	chunks := make([]string, 20)
+	for i := range chunks {
+		chunks[i] = fmt.Sprintf("frame %02d;", i)
+	}
+	content := strings.Join(chunks, "")
+
+	c := &recordingCollector{}
+	r := openArchive(t, chunks, WithCollector(c))
+
+	s, err := r.NewStream(0, r.Size(), WithStreamPipelineDepth(3))
+	if err != nil {
+		t.Fatalf("NewStream failed: %v", err)
+	}
+	defer s.Close()
+
+	// Without a reader, decoding stops three frames ahead.
+	if n := decodedCount(c, 3); n != 3 {
+		t.Fatalf("decoded %d frames ahead, want 3", n)
+	}
+
+	// Taking one frame lets the decoder run one further.
+	buf := make([]byte, len(chunks[0]))
+	if _, err := io.ReadFull(s, buf); err != nil || string(buf) != chunks[0] {
+		t.Fatalf("ReadFull = (%q, %v)", buf, err)
+	}
+	if n := decodedCount(c, 4); n != 4 {
+		t.Fatalf("decoded %d frames after reading one, want 4", n)
+	}
+
+	rest, err := io.ReadAll(s)
+	if err != nil || string(rest) != content[len(chunks[0]):] {
+		t.Errorf("ReadAll = (%q, %v)", rest, err)
+	}
+}
+
+func TestNewStreamClose(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	s, err := r.NewStream(0, r.Size(), WithStreamPipelineDepth(2))
+	if err != nil {
+		t.Fatalf("NewStream failed: %v", err)
+	}
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(s, buf); err != nil || string(buf) != "alp" {
+		t.Fatalf("ReadFull = (%q, %v)", buf, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := s.Read(buf); err == nil {
+		t.Error("Expected Read after Close to fail")
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+
+	// The Reader is still usable.
+	if got, err := r.ReadRange(0, 5); err != nil || string(got) != "alpha" {
+		t.Errorf("ReadRange = (%q, %v)", got, err)
+	}
+}
+
+func TestNewStreamDecodeError(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	s, err := r.NewStream(0, r.Size(), WithStreamPipelineDepth(2))
+	if err != nil {
+		t.Fatalf("NewStream failed: %v", err)
+	}
+	defer s.Close()
+	r.Close()
+
+	if _, err := io.ReadAll(s); err == nil {
+		t.Error("Expected ReadAll of a stream over a closed Reader to fail")
+	}
+}
+
+func TestWithMaxBufferedBytes(t *testing.T) {
+	chunks := make([]string, 20)
+	for i := range chunks {
+		chunks[i] = fmt.Sprintf("frame %03d;", i) // 10 bytes each
+	}
+	content := strings.Join(chunks, "")
+
+	c := &recordingCollector{}
+	r := openArchive(t, chunks, WithCollector(c))
+
+	s, err := r.NewStream(0, r.Size(), WithStreamPipelineDepth(8), WithMaxBufferedBytes(25))
+	if err != nil {
+		t.Fatalf("NewStream failed: %v", err)
+	}
+	defer s.Close()
+
+	// The byte cap stops decoding after two frames, well short of the depth.
+	if n := decodedCount(c, 2); n != 2 {
+		t.Fatalf("decoded %d frames ahead, want 2", n)
+	}
+	if got := s.Buffered(); got != 20 {
+		t.Errorf("Buffered() = %d, want 20", got)
+	}
+	if got := s.Pressure(); got != 0.8 {
+		t.Errorf("Pressure() = %v, want 0.8", got)
+	}
+
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(s, buf); err != nil {
+		t.Fatalf("ReadFull failed: %v", err)
+	}
+	if n := decodedCount(c, 3); n != 3 {
+		t.Fatalf("decoded %d frames after reading 5 bytes, want 3", n)
+	}
+
+	rest, err := io.ReadAll(s)
+	if err != nil || string(buf)+string(rest) != content {
+		t.Errorf("ReadAll = (%q, %v)", rest, err)
+	}
+	if got := s.Buffered(); got != 0 {
+		t.Errorf("Buffered() after ReadAll = %d, want 0", got)
+	}
+}
+
+func TestWithMaxBufferedBytesLargeFrame(t *testing.T) {
+	// Frames larger than the cap are still decoded one at a time.
+	r := openArchive(t, testChunks)
+	s, err := r.NewStream(0, r.Size(), WithStreamPipelineDepth(2), WithMaxBufferedBytes(1))
+	if err != nil {
+		t.Fatalf("NewStream failed: %v", err)
+	}
+	defer s.Close()
+	if got, err := io.ReadAll(s); err != nil || string(got) != testContent {
+		t.Errorf("ReadAll = (%q, %v)", got, err)
+	}
+}
+
+func TestStreamPressureWithoutPipeline(t *testing.T) {
+	r := openArchive(t, testChunks)
+	s, err := r.NewStream(0, r.Size())
+	if err != nil {
+		t.Fatalf("NewStream failed: %v", err)
+	}
+	defer s.Close()
+	if s.Buffered() != 0 || s.Pressure() != 0 {
+		t.Errorf("Buffered() = %d, Pressure() = %v, want 0", s.Buffered(), s.Pressure())
+	}
+}
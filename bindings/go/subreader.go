@@ -0,0 +1,185 @@
+package seekable
+
+/*
+#include "include/seekable_zstd.h"
+*/
+import "C"
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// handles holds the resources a Reader shares with its sub-readers. They are
+// released when the last Reader using them is closed.
+type handles struct {
+	mu    sync.Mutex
+	refs  int
+	ptr   *C.SeekableDecoder
+	file  *os.File
+	dctxs dctxPool
+	dicts *dictSet
+
+	// closer closes a source owned by the Reader that is not an *os.File,
+	// such as an fs.File opened by OpenFS.
+	closer io.Closer
+
+	// lazy is the archive file of a Reader opened with WithLazyFD, which
+	// holds no descriptor in file.
+	lazy *lazyFile
+
+	// decodedBytes counts the bytes charged against the decode quota.
+	decodedBytes atomic.Uint64
+
+	// decode serializes calls into the core decoder, which needs exclusive
+	// access to its state for every read.
+	decode sync.Mutex
+	// coreStalled is set once a core decode has timed out under
+	// WithDecodeTimeout. The abandoned decode may hold decode indefinitely,
+	// so frames are decoded in Go from then on.
+	coreStalled atomic.Bool
+}
+
+func (h *handles) acquire() {
+	h.mu.Lock()
+	h.refs++
+	h.mu.Unlock()
+}
+
+func (h *handles) release() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.refs--
+	if h.refs > 0 {
+		return nil
+	}
+
+	h.dctxs.close()
+	h.dicts.free()
+	if h.ptr != nil {
+		C.seekable_close(h.ptr)
+		h.ptr = nil
+	}
+	if h.file != nil {
+		err := h.file.Close()
+		h.file = nil
+		return err
+	}
+	if h.closer != nil {
+		err := h.closer.Close()
+		h.closer = nil
+		return err
+	}
+	return nil
+}
+
+// stat returns the archive file's info, reporting false if the Reader's
+// source is not a file.
+func (h *handles) stat() (os.FileInfo, bool, error) {
+	switch {
+	case h.file != nil:
+		info, err := h.file.Stat()
+		return info, true, err
+	case h.lazy != nil:
+		info, err := h.lazy.stat()
+		return info, true, err
+	}
+	return nil, false, nil
+}
+
+// closeDecoder frees the core decoder early, leaving the file open. Readers
+// sharing the handles must no longer use the decoder.
+func (h *handles) closeDecoder() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.ptr != nil {
+		C.seekable_close(h.ptr)
+		h.ptr = nil
+	}
+}
+
+// SubReader returns a Reader over frames [firstFrame, lastFrame) of r. Offsets
+// in the sub-reader are relative to the start of firstFrame, so its content
+// begins at 0 and its Size is the combined size of the selected frames.
+//
+// The sub-reader shares r's decoder, source and options, including any
+// FrameCache. Closing it does not affect r: the shared decoder is freed once r
+// and every sub-reader created from it have been closed. Frames pinned by r are
+// not pinned in the sub-reader.
+func (r *Reader) SubReader(firstFrame, lastFrame uint64) (*Reader, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h == nil {
+		return nil, ErrClosed
+	}
+
+	st := r.view.Load().table
+	count := uint64(len(st.frames))
+	if firstFrame > lastFrame {
+		return nil, fmt.Errorf("invalid frame range: first (%d) > last (%d)", firstFrame, lastFrame)
+	}
+	if lastFrame > count {
+		return nil, fmt.Errorf("frame range end (%d) exceeds frame count (%d)", lastFrame, count)
+	}
+
+	var base uint64
+	if firstFrame < count {
+		base = st.frames[firstFrame].decompOffset
+	} else {
+		base = st.size()
+	}
+
+	table := &seekTable{
+		frames:       make([]frameEntry, lastFrame-firstFrame),
+		hasChecksums: st.hasChecksums,
+		footer:       st.footer,
+		footerOff:    st.footerOff,
+		start:        st.start,
+		end:          st.end,
+	}
+	for i := range table.frames {
+		f := st.frames[firstFrame+uint64(i)]
+		f.decompOffset -= base
+		table.frames[i] = f
+		table.maxDecompSize = max(table.maxDecompSize, f.decompSize)
+	}
+
+	r.h.acquire()
+	sub := &Reader{
+		ptr:       r.ptr,
+		cfg:       r.cfg,
+		id:        r.id,
+		modTime:   r.modTime,
+		src:       r.src,
+		srcSize:   r.srcSize,
+		h:         r.h,
+		sub:       true,
+		base:      r.base + base,
+		frameBase: r.frameBase + int(firstFrame),
+	}
+	sub.setTable(table)
+	if r.decoded != nil {
+		sub.decoded = r.decoded[base : base+table.size()]
+	}
+	return sub, nil
+}
+
+// SectionReaderAt returns an io.ReaderAt over the n decompressed bytes
+// starting at off, with offsets relative to off. Unlike SubReader it need not
+// start on a frame boundary and holds no resources of its own: it only
+// translates offsets and reads through r, so it is cheap to create, safe to
+// use concurrently with r and other sections, and stops working once r is
+// closed. Reads past the end of the section return io.EOF like
+// io.SectionReader.
+//
+// The section is clamped to [0, Size()), so a range that extends past the
+// content yields a shorter section.
+func (r *Reader) SectionReaderAt(off, n int64) io.ReaderAt {
+	size := r.Size64()
+	off = min(max(off, 0), size)
+	n = min(max(n, 0), size-off)
+	return io.NewSectionReader(r, off, n)
+}
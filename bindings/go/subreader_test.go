@@ -0,0 +1,219 @@
+package seekable
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSubReader(t *testing.T) {
+	data := buildArchive(testChunks...)
+
+	for _, tt := range []struct {
+		name string
+		open func(t *testing.T) *Reader
+	}{
+		{"core", func(t *testing.T) *Reader {
+			return openArchivePath(t, writeArchive(t, data))
+		}},
+		{"reader", func(t *testing.T) *Reader {
+			r, err := OpenReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("OpenReader failed: %v", err)
+			}
+			t.Cleanup(func() { r.Close() })
+			return r
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.open(t)
+
+			sub, err := r.SubReader(1, 3)
+			if err != nil {
+				t.Fatalf("SubReader(1, 3) failed: %v", err)
+			}
+			defer sub.Close()
+
+			if sub.Size() != 14 {
+				t.Errorf("Expected size 14, got %d", sub.Size())
+			}
+			if sub.FrameCount() != 2 {
+				t.Errorf("Expected 2 frames, got %d", sub.FrameCount())
+			}
+
+			got, err := sub.DecompressAll()
+			if err != nil {
+				t.Fatalf("DecompressAll failed: %v", err)
+			}
+			if string(got) != "bravo-charlie-" {
+				t.Errorf("Expected 'bravo-charlie-', got '%s'", string(got))
+			}
+
+			got, err = sub.ReadRange(5, 10)
+			if err != nil {
+				t.Fatalf("ReadRange(5, 10) failed: %v", err)
+			}
+			if string(got) != "-char" {
+				t.Errorf("Expected '-char', got '%s'", string(got))
+			}
+
+			nested, err := sub.SubReader(1, 2)
+			if err != nil {
+				t.Fatalf("nested SubReader failed: %v", err)
+			}
+			got, err = nested.DecompressAll()
+			if err != nil {
+				t.Fatalf("nested DecompressAll failed: %v", err)
+			}
+			if string(got) != "charlie-" {
+				t.Errorf("Expected 'charlie-', got '%s'", string(got))
+			}
+			nested.Close()
+		})
+	}
+}
+
+func TestSubReaderClose(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	sub, err := r.SubReader(0, 2)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	if err := sub.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// The parent keeps the shared decoder alive.
+	got, err := r.DecompressAll()
+	if err != nil {
+		t.Fatalf("DecompressAll after sub-reader Close failed: %v", err)
+	}
+	if string(got) != testContent {
+		t.Errorf("Expected '%s', got '%s'", testContent, string(got))
+	}
+
+	// A sub-reader outlives its parent.
+	sub, err = r.SubReader(3, 4)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+	r.Close()
+
+	got, err = sub.DecompressAll()
+	if err != nil {
+		t.Fatalf("DecompressAll after parent Close failed: %v", err)
+	}
+	if string(got) != "delta" {
+		t.Errorf("Expected 'delta', got '%s'", string(got))
+	}
+
+	if _, err := r.SubReader(0, 1); err == nil {
+		t.Error("Expected error from SubReader on closed reader")
+	}
+}
+
+func TestSubReaderCacheKeys(t *testing.T) {
+	cache := NewFrameCache(1 << 20)
+	r := openArchive(t, testChunks, WithFrameCache(cache))
+
+	sub, err := r.SubReader(2, 4)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+
+	if _, err := sub.DecompressAll(); err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+
+	// Frames cached through the sub-reader must be served to the parent at
+	// their archive positions.
+	got, err := r.DecompressAll()
+	if err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+	if string(got) != testContent {
+		t.Errorf("Expected '%s', got '%s'", testContent, string(got))
+	}
+	if cache.Len() != 4 {
+		t.Errorf("Expected 4 cached frames, got %d", cache.Len())
+	}
+}
+
+func TestSubReaderInvalid(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	for _, tt := range []struct {
+		first, last uint64
+	}{{2, 1}, {0, 5}, {5, 5}} {
+		if _, err := r.SubReader(tt.first, tt.last); err == nil {
+			t.Errorf("Expected error for SubReader(%d, %d)", tt.first, tt.last)
+		}
+	}
+
+	sub, err := r.SubReader(4, 4)
+	if err != nil {
+		t.Fatalf("SubReader(4, 4) failed: %v", err)
+	}
+	defer sub.Close()
+	if sub.Size() != 0 {
+		t.Errorf("Expected empty sub-reader, got size %d", sub.Size())
+	}
+}
+
+func TestSectionReaderAt(t *testing.T) {
+	r := openArchive(t, testChunks)
+
+	sec := r.SectionReaderAt(3, 12)
+	want := testContent[3:15]
+	if err := iotest.TestReader(io.NewSectionReader(sec, 0, 12), []byte(want)); err != nil {
+		t.Error(err)
+	}
+
+	buf := make([]byte, 5)
+	if n, err := sec.ReadAt(buf, 10); n != 2 || err != io.EOF || string(buf[:n]) != want[10:] {
+		t.Errorf("ReadAt past the section end = (%d, %v), want (2, EOF)", n, err)
+	}
+	if n, err := sec.ReadAt(buf, 12); n != 0 || err != io.EOF {
+		t.Errorf("ReadAt at the section end = (%d, %v), want (0, EOF)", n, err)
+	}
+
+	// Sections are clamped to the content.
+	tail := r.SectionReaderAt(20, 100)
+	got := make([]byte, 10)
+	if n, err := tail.ReadAt(got, 0); n != 5 || err != io.EOF || string(got[:n]) != "delta" {
+		t.Errorf("ReadAt on clamped section = (%q, %v)", got[:n], err)
+	}
+	if n, err := r.SectionReaderAt(-4, 100).ReadAt(got, 0); n != 10 || err != nil || string(got) != testContent[:10] {
+		t.Errorf("ReadAt on section from -4 = (%q, %v)", got[:n], err)
+	}
+
+	// Sections read concurrently with each other and the parent.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			off := int64(i * 3)
+			sec := r.SectionReaderAt(off, 4)
+			p := make([]byte, 4)
+			n, _ := sec.ReadAt(p, 0)
+			if string(p[:n]) != testContent[off:min(off+4, int64(len(testContent)))] {
+				t.Errorf("section at %d read %q", off, p[:n])
+			}
+			if _, err := r.ReadRange(0, uint64(len(testContent))); err != nil {
+				t.Errorf("ReadRange failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	r.Close()
+	if _, err := sec.ReadAt(buf, 0); err == nil {
+		t.Error("Expected ReadAt after the Reader is closed to fail")
+	}
+}
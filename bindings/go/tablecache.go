@@ -0,0 +1,82 @@
+package seekable
+
+import (
+	"container/list"
+	"os"
+	"sync"
+)
+
+// SeekTableCache is an LRU cache of parsed seek tables, bounded by the number
+// of tables it holds. Sharing one between Opens through WithSeekTableCache
+// lets repeated opens of an unchanged file skip reading and parsing its seek
+// table, metadata frames and entry index.
+//
+// Tables are keyed by file identity (device, inode, size and modification time
+// on Unix, path, size and modification time elsewhere), so a rewritten file
+// is parsed afresh and its stale table is eventually evicted. A
+// SeekTableCache is safe for concurrent use.
+type SeekTableCache struct {
+	mu        sync.Mutex
+	maxTables int
+	lru       *list.List
+	entries   map[string]*list.Element
+}
+
+type tableEntry struct {
+	key   string
+	table *seekTable
+}
+
+// NewSeekTableCache returns a cache holding up to maxTables seek tables.
+func NewSeekTableCache(maxTables int) *SeekTableCache {
+	return &SeekTableCache{
+		maxTables: maxTables,
+		lru:       list.New(),
+		entries:   make(map[string]*list.Element),
+	}
+}
+
+// Len returns the number of cached seek tables.
+func (c *SeekTableCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// load returns the table cached for the file, or parses it with parse and
+// caches it. A nil cache always parses.
+func (c *SeekTableCache) load(path string, info os.FileInfo, leading bool, parse func() (*seekTable, error)) (*seekTable, error) {
+	if c == nil {
+		return parse()
+	}
+
+	key := fileIdentity(path, info)
+	if leading {
+		key += ":leading"
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*tableEntry).table, nil
+	}
+	c.mu.Unlock()
+
+	st, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && c.maxTables > 0 {
+		c.entries[key] = c.lru.PushFront(&tableEntry{key: key, table: st})
+		for c.lru.Len() > c.maxTables {
+			back := c.lru.Back()
+			c.lru.Remove(back)
+			delete(c.entries, back.Value.(*tableEntry).key)
+		}
+	}
+	return st, nil
+}
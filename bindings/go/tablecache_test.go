@@ -0,0 +1,80 @@
+package seekable
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSeekTableCache(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"core": nil,
+		"go":   {WithSizeChecks()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writeArchive(t, buildArchive(testChunks...))
+			cache := NewSeekTableCache(4)
+			opts := append(opts, WithSeekTableCache(cache))
+
+			a := openArchivePath(t, path, opts...)
+			b := openArchivePath(t, path, opts...)
+			if cache.Len() != 1 {
+				t.Errorf("Len() = %d after two opens, want 1", cache.Len())
+			}
+			if a.view.Load().table != b.view.Load().table {
+				t.Error("second Open parsed the seek table again")
+			}
+			if got, err := b.DecompressAll(); err != nil || string(got) != testContent {
+				t.Errorf("DecompressAll = (%q, %v)", got, err)
+			}
+
+			// A rewritten file gets a fresh table.
+			if err := os.WriteFile(path, buildArchive("one-", "two"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			future := time.Now().Add(time.Hour)
+			if err := os.Chtimes(path, future, future); err != nil {
+				t.Fatal(err)
+			}
+			c := openArchivePath(t, path, opts...)
+			if got, err := c.DecompressAll(); err != nil || string(got) != "one-two" {
+				t.Errorf("DecompressAll after rewrite = (%q, %v)", got, err)
+			}
+			if cache.Len() != 2 {
+				t.Errorf("Len() = %d after rewrite, want 2", cache.Len())
+			}
+		})
+	}
+}
+
+func TestSeekTableCacheEviction(t *testing.T) {
+	cache := NewSeekTableCache(1)
+	first := writeArchive(t, buildArchive(testChunks...))
+	second := writeArchive(t, buildArchive("other"))
+
+	a := openArchivePath(t, first, WithSeekTableCache(cache))
+	openArchivePath(t, second, WithSeekTableCache(cache))
+	if cache.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", cache.Len())
+	}
+	if again := openArchivePath(t, first, WithSeekTableCache(cache)); again.view.Load().table == a.view.Load().table {
+		t.Error("evicted table was reused")
+	}
+}
+
+func TestSeekTableCacheLayouts(t *testing.T) {
+	path := writeArchive(t, buildLegacyArchive(testChunks...))
+	cache := NewSeekTableCache(4)
+
+	r, err := OpenLegacy(path, WithSeekTableCache(cache))
+	if err != nil {
+		t.Fatalf("OpenLegacy failed: %v", err)
+	}
+	r.Close()
+
+	// The cached legacy table does not make Open accept the legacy layout.
+	if r, err := Open(path, WithSeekTableCache(cache)); err == nil {
+		r.Close()
+		t.Error("Open accepted a leading-table archive from the cache")
+	}
+}
@@ -0,0 +1,147 @@
+package seekable
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTranscodeMismatch is returned by VerifyTranscode when the transcoded
+// stream does not decode to the archive's content.
+var ErrTranscodeMismatch = errors.New("seekable: transcoded content does not match")
+
+// Transcode writes the archive to w as a plain zstd stream: its data frames
+// in order, without the seek table or metadata frames. Any zstd decoder can
+// decode the result, which holds the same content. The frames of a Reader
+// opened with WithMagiclessFrames get their frame magic back. It returns the
+// number of bytes written.
+//
+// Frames are copied without recompressing them, so frames that need a
+// dictionary still need it to decode. The Reader is not locked while w is
+// written, so a Close meanwhile fails the next frame with ErrClosed.
+func (r *Reader) Transcode(w io.Writer) (int64, error) {
+	var magic [4]byte
+	binary.LittleEndian.PutUint32(magic[:], zstdFrameMagic)
+
+	r.mu.RLock()
+	closed, frames := r.h == nil, r.view.Load().table.frames
+	r.mu.RUnlock()
+	if closed {
+		return 0, ErrClosed
+	}
+
+	var written int64
+	var buf []byte
+	for i, f := range frames {
+		if f.compSize == 0 {
+			continue
+		}
+		comp, err := r.compressedFrame(i, buf)
+		if err != nil {
+			return written, err
+		}
+		buf = comp
+		if r.cfg.magicless {
+			n, err := w.Write(magic[:])
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+		}
+		n, err := w.Write(comp)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// compressedFrame is readCompressed for callers not holding r.mu. It fails
+// with ErrClosed once r is closed.
+func (r *Reader) compressedFrame(i int, buf []byte) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h == nil {
+		return nil, ErrClosed
+	}
+	return r.readCompressed(context.Background(), i, buf)
+}
+
+// VerifyTranscode transcodes the archive to plain zstd, decodes that stream
+// without the seek table and compares the result with the archive's content
+// read through the Reader. It returns ErrTranscodeMismatch if they differ.
+// Inconsistencies the Reader detects itself, such as a frame whose size
+// disagrees with the seek table, are returned as the Reader reports them.
+//
+// Both sides are streamed, so memory use does not grow with the archive.
+// Frames are decoded without dictionaries, so archives that use them fail
+// to verify.
+func (r *Reader) VerifyTranscode() error {
+	d, err := newDCtx()
+	if err != nil {
+		return err
+	}
+	defer d.free()
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := r.Transcode(pw)
+		pw.CloseWithError(err)
+	}()
+	defer func() {
+		pr.Close()
+		<-done
+	}()
+
+	plain, err := newStreamDecoder(d, pr)
+	if err != nil {
+		return err
+	}
+	cw := &compareWriter{r: plain}
+	if _, err := r.CopyRange(cw, 0, r.Size()); err != nil {
+		return err
+	}
+
+	// The plain stream must end where the content does.
+	var extra [1]byte
+	switch n, err := io.ReadFull(plain, extra[:]); {
+	case n > 0:
+		return fmt.Errorf("%w: plain stream is longer than %d bytes", ErrTranscodeMismatch, r.Size())
+	case err != io.EOF:
+		return err
+	}
+	return nil
+}
+
+// compareWriter checks that the bytes written to it are the next bytes of r.
+type compareWriter struct {
+	r   io.Reader
+	off uint64
+	buf []byte
+}
+
+func (c *compareWriter) Write(p []byte) (int, error) {
+	if cap(c.buf) < len(p) {
+		c.buf = make([]byte, len(p))
+	}
+	buf := c.buf[:len(p)]
+	n, err := io.ReadFull(c.r, buf)
+	for i := 0; i < n; i++ {
+		if buf[i] != p[i] {
+			return i, fmt.Errorf("%w: first difference at offset %d", ErrTranscodeMismatch, c.off+uint64(i))
+		}
+	}
+	c.off += uint64(n)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return n, fmt.Errorf("%w: plain stream ends at offset %d", ErrTranscodeMismatch, c.off)
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, nil
+}
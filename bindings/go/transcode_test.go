@@ -0,0 +1,119 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"testing"
+)
+
+// plainDecode decodes a plain zstd stream with streamDecoder.
+func plainDecode(t testing.TB, stream []byte) []byte {
+	t.Helper()
+	d, err := newDCtx()
+	if err != nil {
+		t.Fatalf("newDCtx failed: %v", err)
+	}
+	defer d.free()
+	s, err := newStreamDecoder(d, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("newStreamDecoder failed: %v", err)
+	}
+	out, err := io.ReadAll(s)
+	if err != nil {
+		t.Fatalf("decoding the plain stream failed: %v", err)
+	}
+	return out
+}
+
+func TestTranscode(t *testing.T) {
+	content := interopContent()
+	data := writeTestArchive(t, content, WithFrameSize(8*1024), WithContentHash())
+	r, err := OpenBytes(data)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	n, err := r.Transcode(&buf)
+	if err != nil {
+		t.Fatalf("Transcode failed: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("Transcode returned %d, wrote %d bytes", n, buf.Len())
+	}
+	// Only the data frames are kept.
+	if n != r.view.Load().table.dataEnd() {
+		t.Errorf("Transcode wrote %d bytes, want the %d bytes of data frames", n, r.view.Load().table.dataEnd())
+	}
+	if got := plainDecode(t, buf.Bytes()); !bytes.Equal(got, content) {
+		t.Errorf("plain stream decodes to %d bytes, want %d", len(got), len(content))
+	}
+	if err := r.VerifyTranscode(); err != nil {
+		t.Errorf("VerifyTranscode failed: %v", err)
+	}
+}
+
+func TestTranscodeMagicless(t *testing.T) {
+	r := openFixture(t, "magicless.szst", WithMagiclessFrames())
+	var buf bytes.Buffer
+	if _, err := r.Transcode(&buf); err != nil {
+		t.Fatalf("Transcode failed: %v", err)
+	}
+	want, err := r.DecompressAll()
+	if err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+
+	// The reference CLI decodes the restored frames.
+	cmd := exec.Command(zstdCLI(t), "-d", "-c", "-q")
+	cmd.Stdin = &buf
+	got, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("zstd -d failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("CLI decodes to %d bytes, want %d", len(got), len(want))
+	}
+	if err := r.VerifyTranscode(); err != nil {
+		t.Errorf("VerifyTranscode failed: %v", err)
+	}
+}
+
+func TestVerifyTranscodeInconsistentTable(t *testing.T) {
+	f0, f1 := rawFrame([]byte("alpha-")), rawFrame([]byte("bravo-"))
+	archive := append(append([]byte{}, f0...), f1...)
+	// The seek table describes both frames as one.
+	archive = append(archive, seekTableFrame([]uint32{uint32(len(archive))}, []uint32{12})...)
+
+	r, err := OpenBytes(archive)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	if err := r.VerifyTranscode(); err == nil {
+		t.Error("Expected VerifyTranscode to fail")
+	}
+}
+
+func TestCompareWriter(t *testing.T) {
+	for _, tc := range []struct {
+		stream, content string
+	}{
+		{"alpha-bravo", "alpha-brave"},
+		{"alpha", "alpha-bravo"},
+	} {
+		cw := &compareWriter{r: bytes.NewReader([]byte(tc.stream))}
+		_, err := io.Copy(cw, bytes.NewReader([]byte(tc.content)))
+		if !errors.Is(err, ErrTranscodeMismatch) {
+			t.Errorf("comparing %q with %q = %v, want ErrTranscodeMismatch", tc.content, tc.stream, err)
+		}
+	}
+
+	cw := &compareWriter{r: bytes.NewReader([]byte("alpha"))}
+	if _, err := cw.Write([]byte("alpha")); err != nil {
+		t.Errorf("Write of matching bytes failed: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package seekable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrChecksumMismatch is reported when a frame's content does not match the
+// checksum recorded in the seek table.
+var ErrChecksumMismatch = errors.New("seekable: frame checksum mismatch")
+
+// ErrSizeMismatch is reported when a frame decodes to a different size than
+// the seek table records.
+var ErrSizeMismatch = errors.New("seekable: frame size mismatch")
+
+// ErrInvalidArchive is reported when the seek table describes an impossible
+// layout, such as frames whose ranges overlap. The open functions return it,
+// wrapped, for sources that are not seekable archives, as distinct from
+// failures to read the source, such as a missing file, which they return as
+// the underlying error.
+var ErrInvalidArchive = errors.New("seekable: invalid archive")
+
+// FrameError reports a failure in a specific frame.
+type FrameError struct {
+	// Frame is the index of the frame.
+	Frame int
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *FrameError) Error() string {
+	return fmt.Sprintf("seekable: frame %d: %v", e.Frame, e.Err)
+}
+
+func (e *FrameError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the structure of the seek table without reading any frame:
+// frames must cover contiguous, non-overlapping ranges of both the compressed
+// and the decompressed content, and any frame with content must occupy
+// compressed bytes. Otherwise it returns a *FrameError naming the first
+// offending frame and wrapping ErrInvalidArchive.
+//
+// A frame that records content but no compressed bytes shares its compressed
+// offset with the next frame, so reads of it would decode the next frame's
+// data. Open accepts such tables unless WithStrictSeekTable is given.
+func (r *Reader) Validate() error {
+	return r.view.Load().table.validate()
+}
+
+// OpenVerifiedStructure opens the archive at path like Open, but first
+// checks the seek table as Validate does and fails with its error if the
+// structure is unsound, so no read is ever served from an impossible layout.
+// Frames are still decoded lazily; together with the expansion ratio check
+// every open applies (see WithMaxExpansionRatio), this costs no reads beyond
+// the seek table, unlike DeepValidate. It is equivalent to Open with
+// WithStrictSeekTable.
+func OpenVerifiedStructure(path string, opts ...Option) (*Reader, error) {
+	return Open(path, append(opts, WithStrictSeekTable())...)
+}
+
+func (st *seekTable) validate() error {
+	var compEnd, decompEnd uint64
+	if len(st.frames) > 0 {
+		// A sub-reader's window starts partway into the archive.
+		compEnd = st.frames[0].compOffset
+	}
+	for i, f := range st.frames {
+		switch {
+		case f.compOffset != compEnd:
+			return &FrameError{Frame: i, Err: fmt.Errorf("%w: compressed offset %d, previous frame ends at %d", ErrInvalidArchive, f.compOffset, compEnd)}
+		case f.decompOffset != decompEnd:
+			return &FrameError{Frame: i, Err: fmt.Errorf("%w: decompressed offset %d, previous frame ends at %d", ErrInvalidArchive, f.decompOffset, decompEnd)}
+		case f.compSize == 0 && f.decompSize > 0:
+			return &FrameError{Frame: i, Err: fmt.Errorf("%w: %d bytes of content in an empty frame", ErrInvalidArchive, f.decompSize)}
+		}
+		compEnd += f.compSize
+		decompEnd += f.decompSize
+	}
+	if compEnd > uint64(st.start) {
+		return fmt.Errorf("%w: frames span %d bytes, but seek table starts at %d", ErrInvalidArchive, compEnd, st.start)
+	}
+	return nil
+}
+
+// ValidateOption configures DeepValidate.
+type ValidateOption func(*validateConfig)
+
+// validateConfig holds the settings applied by ValidateOption values.
+type validateConfig struct {
+	parallelism int
+}
+
+// WithParallelism sets the number of frames DeepValidate decodes
+// concurrently. Values below 1 select runtime.GOMAXPROCS(0).
+func WithParallelism(n int) ValidateOption {
+	return func(c *validateConfig) {
+		c.parallelism = n
+	}
+}
+
+// DeepValidate checks the seek table's structure like Validate, then decodes
+// every frame and checks it against the seek table: the frame must decode
+// without error, produce exactly the recorded decompressed size and, if the
+// seek table stores checksums, match its checksum.
+//
+// Frames are read from the compressed archive and decoded independently,
+// bypassing the frame cache. With WithParallelism(n), n workers validate
+// frames concurrently; each holds one frame in memory at a time, so memory use
+// is bounded by n times the largest frame. The error always describes the
+// lowest-index failing frame, as a *FrameError, regardless of the order in
+// which workers find failures.
+func (r *Reader) DeepValidate(opts ...ValidateOption) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.h == nil || r.src == nil {
+		return ErrClosed
+	}
+	v := r.view.Load()
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	var cfg validateConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	workers := cfg.parallelism
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(v.table.frames))
+
+	var (
+		next atomic.Int64
+		// failed is the lowest failing frame index found so far. Frames are
+		// handed out in order, so once a frame fails, every lower frame has
+		// already been claimed and later frames can be skipped.
+		failed atomic.Int64
+		mu     sync.Mutex
+		errs   = make(map[int]error)
+		setup  error
+		wg     sync.WaitGroup
+	)
+	failed.Store(int64(len(v.table.frames)))
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			d, err := r.getDCtx()
+			if err != nil {
+				mu.Lock()
+				setup = err
+				mu.Unlock()
+				return
+			}
+			defer r.putDCtx(d)
+
+			var comp, out []byte
+			for {
+				i := int(next.Add(1) - 1)
+				if int64(i) >= failed.Load() {
+					return
+				}
+
+				release := acquireDecode()
+				comp, out, err = r.validateFrame(d, i, comp, out)
+				release()
+				if err == nil {
+					continue
+				}
+
+				mu.Lock()
+				errs[i] = err
+				if int64(i) < failed.Load() {
+					failed.Store(int64(i))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if i := int(failed.Load()); i < len(v.table.frames) {
+		return &FrameError{Frame: i, Err: errs[i]}
+	}
+	return setup
+}
+
+// validateFrame decodes frame i with d and checks it against the seek table.
+// comp and out are scratch buffers, returned for reuse.
+func (r *Reader) validateFrame(d *dctx, i int, comp, out []byte) ([]byte, []byte, error) {
+	v := r.view.Load()
+	f := v.table.frames[i]
+
+	if uint64(cap(comp)) < f.compSize {
+		comp = make([]byte, f.compSize)
+	}
+	comp, err := r.readFrameBytes(context.Background(), comp[:f.compSize], int64(f.compOffset))
+	if err != nil {
+		return comp, out, err
+	}
+
+	if uint64(cap(out)) < f.decompSize {
+		out = make([]byte, f.decompSize)
+	}
+	out = out[:f.decompSize]
+	if err := r.checkWindow(comp); err != nil {
+		return comp, out, err
+	}
+	if err := decodeExact(d, comp, out); err != nil {
+		return comp, out, err
+	}
+
+	if v.table.hasChecksums {
+		if sum := frameChecksum(out); sum != f.checksum {
+			if l := r.cfg.logger; l != nil {
+				l.LogAttrs(context.Background(), slog.LevelDebug, "seekable: frame checksum mismatch",
+					slog.Int("frame", i),
+					slog.String("checksum", fmt.Sprintf("%#08x", sum)),
+					slog.String("want", fmt.Sprintf("%#08x", f.checksum)))
+			}
+			return comp, out, fmt.Errorf("%w: got %#08x, seek table says %#08x", ErrChecksumMismatch, sum, f.checksum)
+		}
+	}
+	return comp, out, nil
+}
@@ -0,0 +1,214 @@
+package seekable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// checksummedArchive builds an archive with one raw frame per chunk and a
+// seek table recording the given checksums.
+func checksummedArchive(chunks []string, checksums []uint32) []byte {
+	var archive, entries []byte
+	for i, chunk := range chunks {
+		frame := rawFrame([]byte(chunk))
+		archive = append(archive, frame...)
+		entries = binary.LittleEndian.AppendUint32(entries, uint32(len(frame)))
+		entries = binary.LittleEndian.AppendUint32(entries, uint32(len(chunk)))
+		entries = binary.LittleEndian.AppendUint32(entries, checksums[i])
+	}
+
+	archive = binary.LittleEndian.AppendUint32(archive, skippableMagicSeekTable)
+	archive = binary.LittleEndian.AppendUint32(archive, uint32(len(entries)+seekTableFooterSize))
+	archive = append(archive, entries...)
+	archive = binary.LittleEndian.AppendUint32(archive, uint32(len(chunks)))
+	archive = append(archive, seekTableChecksumFlag)
+	return binary.LittleEndian.AppendUint32(archive, seekableMagic)
+}
+
+func TestDeepValidate(t *testing.T) {
+	content := []byte(strings.Repeat("validate every frame ", 2000))
+	data := writeTestArchive(t, content, WithFrameSize(1000))
+	r := openArchivePath(t, writeArchive(t, data))
+
+	for _, n := range []int{0, 1, 4, 100} {
+		if err := r.DeepValidate(WithParallelism(n)); err != nil {
+			t.Errorf("DeepValidate(WithParallelism(%d)) failed: %v", n, err)
+		}
+	}
+}
+
+func TestDeepValidateReportsLowestFrame(t *testing.T) {
+	chunks := make([]string, 64)
+	checksums := make([]uint32, len(chunks))
+	for i := range chunks {
+		chunks[i] = fmt.Sprintf("frame %02d content", i)
+		checksums[i] = frameChecksum([]byte(chunks[i]))
+	}
+	for _, i := range []int{50, 17, 40} {
+		checksums[i]++
+	}
+	data := checksummedArchive(chunks, checksums)
+	r := openArchivePath(t, writeArchive(t, data))
+
+	for _, n := range []int{1, 3, 16} {
+		err := r.DeepValidate(WithParallelism(n))
+		var fe *FrameError
+		if !errors.As(err, &fe) {
+			t.Fatalf("DeepValidate(WithParallelism(%d)) = %v, want *FrameError", n, err)
+		}
+		if fe.Frame != 17 {
+			t.Errorf("DeepValidate(WithParallelism(%d)) reported frame %d, want 17", n, fe.Frame)
+		}
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+		}
+	}
+}
+
+func TestDeepValidateSizeMismatch(t *testing.T) {
+	frames := [][]byte{rawFrame([]byte("alpha-")), rawFrame([]byte("bravo-"))}
+	data := append(append(append([]byte{}, frames[0]...), frames[1]...),
+		seekTableFrame([]uint32{uint32(len(frames[0])), uint32(len(frames[1]))}, []uint32{6, 5})...)
+
+	r, err := OpenReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	var fe *FrameError
+	if err := r.DeepValidate(); !errors.As(err, &fe) || fe.Frame != 1 {
+		t.Errorf("DeepValidate() = %v, want failure in frame 1", err)
+	}
+}
+
+func TestFrameChecksum(t *testing.T) {
+	chunks := []string{"one", "two", "three"}
+	sums := []uint32{frameChecksum([]byte("one")), 0xdeadbeef, frameChecksum([]byte("three"))}
+	r := openArchivePath(t, writeArchive(t, checksummedArchive(chunks, sums)))
+
+	for i, want := range sums {
+		got, ok, err := r.FrameChecksum(uint64(i))
+		if err != nil || !ok || got != want {
+			t.Errorf("FrameChecksum(%d) = (%#x, %v, %v), want (%#x, true, nil)", i, got, ok, err, want)
+		}
+	}
+	if _, _, err := r.FrameChecksum(3); err == nil {
+		t.Error("FrameChecksum(3) succeeded on a 3-frame archive")
+	}
+
+	plain := openArchive(t, chunks)
+	if _, ok, err := plain.FrameChecksum(0); ok || err != nil {
+		t.Errorf("FrameChecksum without checksums = (%v, %v), want (false, nil)", ok, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r := openArchive(t, []string{"alpha-", "", "bravo"})
+	if err := r.Validate(); err != nil {
+		t.Errorf("Validate failed on a well-formed archive: %v", err)
+	}
+	sub, err := r.SubReader(1, 3)
+	if err != nil {
+		t.Fatalf("SubReader failed: %v", err)
+	}
+	defer sub.Close()
+	if err := sub.Validate(); err != nil {
+		t.Errorf("Validate failed on a sub-reader: %v", err)
+	}
+}
+
+func TestOpenVerifiedStructure(t *testing.T) {
+	c := &recordingCollector{}
+	r, err := OpenVerifiedStructure(writeArchive(t, buildArchive(testChunks...)), WithCollector(c))
+	if err != nil {
+		t.Fatalf("OpenVerifiedStructure failed: %v", err)
+	}
+	defer r.Close()
+	if len(c.decoded) != 0 {
+		t.Errorf("OpenVerifiedStructure decoded frames %v", c.decoded)
+	}
+	if got, err := r.ReadRange(6, 12); err != nil || string(got) != "bravo-" {
+		t.Errorf("ReadRange(6, 12) = (%q, %v)", got, err)
+	}
+}
+
+func TestValidateOverlappingFrames(t *testing.T) {
+	// Frame 1 claims 5 bytes of content but no compressed bytes, so it
+	// overlaps frame 2 in the compressed stream.
+	wd, _ := os.Getwd()
+	path := filepath.Join(wd, "../../tests/fixtures/overlapping-frames.szst")
+
+	r := openArchivePath(t, path, WithSizeChecks())
+	var fe *FrameError
+	err := r.Validate()
+	if !errors.Is(err, ErrInvalidArchive) || !errors.As(err, &fe) || fe.Frame != 1 {
+		t.Fatalf("Validate() = %v, want ErrInvalidArchive for frame 1", err)
+	}
+	if err := r.DeepValidate(); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("DeepValidate() = %v, want ErrInvalidArchive", err)
+	}
+
+	if _, err := Open(path, WithStrictSeekTable()); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("Open with WithStrictSeekTable = %v, want ErrInvalidArchive", err)
+	}
+	if _, err := OpenVerifiedStructure(path); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("OpenVerifiedStructure = %v, want ErrInvalidArchive", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenBytes(data, WithStrictSeekTable()); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("OpenBytes with WithStrictSeekTable = %v, want ErrInvalidArchive", err)
+	}
+}
+
+func TestWithFrameVerifier(t *testing.T) {
+	chunks := []string{"first frame ", "second frame ", "third frame"}
+	castagnoli := crc32.MakeTable(crc32.Castagnoli)
+	sums := make([]uint32, len(chunks))
+	for i, c := range chunks {
+		sums[i] = crc32.Checksum([]byte(c), castagnoli)
+	}
+	sums[1]++
+
+	errBadCRC := errors.New("crc32c mismatch")
+	var verified []uint64
+	verify := func(index uint64, data []byte) error {
+		verified = append(verified, index)
+		if crc32.Checksum(data, castagnoli) != sums[index] {
+			return errBadCRC
+		}
+		return nil
+	}
+
+	path := writeArchive(t, buildArchive(chunks...))
+	r := openArchivePath(t, path, WithFrameVerifier(verify))
+
+	buf := make([]byte, len(chunks[0]))
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		t.Fatalf("ReadAt of frame 0 failed: %v", err)
+	}
+
+	_, err := r.ReadAt(buf, int64(len(chunks[0])))
+	var fe *FrameError
+	if !errors.As(err, &fe) || fe.Frame != 1 || !errors.Is(err, errBadCRC) {
+		t.Fatalf("ReadAt of frame 1: error = %v, want FrameError for frame 1 wrapping errBadCRC", err)
+	}
+
+	if _, err := r.ReadRange(uint64(len(chunks[0])+len(chunks[1])), r.Size()); err != nil {
+		t.Fatalf("ReadRange of frame 2 failed: %v", err)
+	}
+	if want := []uint64{0, 1, 2}; !slices.Equal(verified, want) {
+		t.Errorf("verified frames %v, want %v", verified, want)
+	}
+}
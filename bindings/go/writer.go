@@ -0,0 +1,509 @@
+package seekable
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+)
+
+// DefaultFrameSize is the decompressed size of the frames a Writer emits
+// unless WithFrameSize is given. It matches the core encoder.
+const DefaultFrameSize = 256 * 1024
+
+// DefaultCompressionLevel is the zstd compression level a Writer uses unless
+// WithCompressionLevel is given.
+const DefaultCompressionLevel = 3
+
+// WriterOption configures a Writer.
+type WriterOption func(*writerConfig)
+
+// writerConfig holds the settings applied by WriterOption values.
+type writerConfig struct {
+	frameSize   int
+	level       int
+	contentHash bool
+
+	// autoMin and autoMax bound the frame size chosen by WithAutoFrameSize,
+	// which is enabled when targetRatio is set.
+	autoMin, autoMax int
+	targetRatio      float64
+
+	// alignment is the WithFrameAlignment value, or 0.
+	alignment uint64
+
+	// sidecar receives the metadata frames and the seek table when set by
+	// WithSidecarSeekTable.
+	sidecar io.Writer
+
+	// signer is the WithSignature key.
+	signer ed25519.PrivateKey
+}
+
+func newWriterConfig(opts []WriterOption) writerConfig {
+	cfg := writerConfig{
+		frameSize: DefaultFrameSize,
+		level:     DefaultCompressionLevel,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return cfg
+}
+
+// WithFrameSize sets the decompressed size of each frame. Smaller frames give
+// finer-grained random access at the cost of compression ratio.
+func WithFrameSize(n int) WriterOption {
+	return func(c *writerConfig) {
+		c.frameSize = n
+	}
+}
+
+// WithCompressionLevel sets the zstd compression level.
+func WithCompressionLevel(level int) WriterOption {
+	return func(c *writerConfig) {
+		c.level = level
+	}
+}
+
+// WithContentHash makes the Writer compute the SHA-256 of all data written to
+// it and store it in a metadata frame on Close. Reader.ContentHash returns it
+// and Reader.VerifyContent checks the archive against it.
+func WithContentHash() WriterOption {
+	return func(c *writerConfig) {
+		c.contentHash = true
+	}
+}
+
+// WithAutoFrameSize makes the Writer adapt the frame size, within [min, max],
+// to reach a compression ratio of targetRatio (decompressed bytes per
+// compressed byte) with frames as small as possible.
+//
+// Frames start at the size set by WithFrameSize, clamped to the bounds. After
+// each frame the Writer compares the ratio of the last autoFrameWindow frames
+// with the target: if it falls short, the next frame is twice as large, since
+// larger frames compress better; if it is met, the next frame is half as
+// large, for finer-grained random access. The adjustment depends only on the
+// data written, so the same input always yields the same frames. A
+// targetRatio of 0 disables the adaptation.
+func WithAutoFrameSize(min, max int, targetRatio float64) WriterOption {
+	return func(c *writerConfig) {
+		c.autoMin = min
+		c.autoMax = max
+		c.targetRatio = targetRatio
+	}
+}
+
+// WithFrameAlignment makes every frame start at a decompressed offset that is
+// a multiple of n, so readers can locate frames by arithmetic. The frame size
+// is rounded down to a multiple of n, or raised to n if it is smaller; with
+// WithAutoFrameSize, the bounds and every adapted size are rounded the same
+// way. With a frame size of exactly n, frame i covers [i*n, (i+1)*n).
+//
+// The Writer only ends a frame early when Close writes the final one, so no
+// padding is ever inserted and the content is stored unchanged. Pack, which
+// starts every file in a new frame, rejects the option for that reason. The
+// cost is in compression ratio: rounding the frame size down yields smaller
+// frames, which compress somewhat worse, by an amount that depends on the
+// data and is negligible for alignments much smaller than the frame size.
+func WithFrameAlignment(n uint64) WriterOption {
+	return func(c *writerConfig) {
+		c.alignment = n
+	}
+}
+
+// WithSidecarSeekTable makes Close write the metadata frames and the seek
+// table to sidecar instead of after the frames, so the main output holds the
+// compressed frames alone. The two parts are opened together with
+// OpenWithSidecar. The frames on their own are a plain zstd stream that any
+// zstd decoder reads.
+func WithSidecarSeekTable(sidecar io.Writer) WriterOption {
+	return func(c *writerConfig) {
+		c.sidecar = sidecar
+	}
+}
+
+// autoFrameWindow is the number of recent frames whose compression ratio
+// steers WithAutoFrameSize.
+const autoFrameWindow = 4
+
+var errWriterClosed = errors.New("seekable: writer is closed")
+
+// Writer compresses data into a seekable zstd archive.
+//
+// Data is split into frames of a fixed decompressed size, or of sizes adapted
+// to the data with WithAutoFrameSize, each compressed independently and
+// carrying a content checksum. Close writes the final partial frame, any
+// metadata frames and the seek table. A Writer is not safe for concurrent use.
+//
+// The format allows at most 134,217,728 (0x8000000) frames per archive; writes
+// that would need more fail with ErrTooManyFrames and leave the Writer
+// unusable. Choose a frame size accordingly for very large inputs.
+type Writer struct {
+	w    io.Writer
+	cfg  writerConfig
+	cctx *cctx
+
+	// buf holds data for the frame being filled; dst receives compressed
+	// frames.
+	buf []byte
+	dst []byte
+
+	frames []frameEntry
+	// frameSize is the decompressed size of the frame being filled. It only
+	// changes with WithAutoFrameSize.
+	frameSize int
+	// maxFrames caps len(frames); it is only lowered in tests.
+	maxFrames int
+	hash      hash.Hash
+	// sigHash hashes the archive written so far for WithSignature.
+	sigHash hash.Hash
+
+	// written is the decompressed size of the emitted frames.
+	written uint64
+
+	entries    []indexEntry
+	entryNames map[string]bool
+
+	err    error
+	closed bool
+}
+
+// NewWriter returns a Writer that writes a seekable archive to w. Close must
+// be called to complete the archive; it does not close w.
+func NewWriter(w io.Writer, opts ...WriterOption) (*Writer, error) {
+	cfg := newWriterConfig(opts)
+	if cfg.frameSize <= 0 || uint64(cfg.frameSize) > math.MaxUint32 {
+		return nil, fmt.Errorf("seekable: invalid frame size %d", cfg.frameSize)
+	}
+	frameSize := cfg.frameSize
+	if cfg.targetRatio != 0 {
+		if cfg.autoMin <= 0 || cfg.autoMax < cfg.autoMin || uint64(cfg.autoMax) > math.MaxUint32 {
+			return nil, fmt.Errorf("seekable: invalid frame size bounds [%d, %d]", cfg.autoMin, cfg.autoMax)
+		}
+		if !(cfg.targetRatio > 0) || math.IsInf(cfg.targetRatio, 0) {
+			return nil, fmt.Errorf("seekable: invalid target compression ratio %v", cfg.targetRatio)
+		}
+		frameSize = min(max(frameSize, cfg.autoMin), cfg.autoMax)
+	}
+	if cfg.alignment != 0 {
+		if cfg.alignment > math.MaxUint32 {
+			return nil, fmt.Errorf("seekable: invalid frame alignment %d", cfg.alignment)
+		}
+		a := int(cfg.alignment)
+		frameSize = alignFrameSize(frameSize, a)
+		if cfg.targetRatio != 0 {
+			cfg.autoMin = alignFrameSize(cfg.autoMin+a-1, a)
+			cfg.autoMax = max(alignFrameSize(cfg.autoMax, a), cfg.autoMin)
+			frameSize = min(max(frameSize, cfg.autoMin), cfg.autoMax)
+		}
+	}
+
+	if cfg.signer != nil {
+		if len(cfg.signer) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("seekable: invalid ed25519 private key length %d", len(cfg.signer))
+		}
+		if cfg.sidecar != nil {
+			return nil, errors.New("seekable: WithSignature cannot be combined with WithSidecarSeekTable")
+		}
+	}
+
+	c, err := newCCtx(cfg.level)
+	if err != nil {
+		return nil, err
+	}
+
+	sw := &Writer{w: w, cfg: cfg, cctx: c, frameSize: frameSize, maxFrames: maxFrames}
+	if cfg.contentHash {
+		sw.hash = sha256.New()
+	}
+	if cfg.signer != nil {
+		sw.sigHash = sha256.New()
+	}
+	return sw, nil
+}
+
+// Write compresses p, emitting a frame each time a full frame of data has
+// been written.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errWriterClosed
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	if w.hash != nil {
+		w.hash.Write(p)
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		// Compress whole frames straight from p when nothing is buffered.
+		if len(w.buf) == 0 && len(p) >= w.frameSize {
+			size := w.frameSize
+			if err := w.writeFrame(p[:size]); err != nil {
+				return n - len(p), err
+			}
+			p = p[size:]
+			continue
+		}
+
+		k := min(len(p), w.frameSize-len(w.buf))
+		w.buf = append(w.frameBuffer(), p[:k]...)
+		p = p[k:]
+
+		if err := w.flushFull(); err != nil {
+			return n - len(p), err
+		}
+	}
+	return n, nil
+}
+
+// ReadFrom implements io.ReaderFrom. It reads src until io.EOF straight into
+// the buffer of the frame being filled, emitting a frame each time it is full,
+// and returns the number of bytes read. Frames never exceed the configured
+// frame size.
+func (w *Writer) ReadFrom(src io.Reader) (int64, error) {
+	if w.closed {
+		return 0, errWriterClosed
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	var total int64
+	for {
+		buf := w.frameBuffer()
+		n, err := src.Read(buf[len(buf):w.frameSize])
+		if n > 0 {
+			if w.hash != nil {
+				w.hash.Write(buf[len(buf) : len(buf)+n])
+			}
+			w.buf = buf[:len(buf)+n]
+			total += int64(n)
+
+			if ferr := w.flushFull(); ferr != nil {
+				return total, ferr
+			}
+		}
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// frameBuffer returns the buffer of the frame being filled, allocating it
+// with room for a whole frame on first use or when frames grow.
+func (w *Writer) frameBuffer() []byte {
+	if cap(w.buf) < w.frameSize {
+		w.buf = append(make([]byte, 0, w.frameSize), w.buf...)
+	}
+	return w.buf
+}
+
+// flushFull emits the buffered frame if it is full.
+func (w *Writer) flushFull() error {
+	if len(w.buf) < w.frameSize {
+		return nil
+	}
+	if err := w.writeFrame(w.buf); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// endFrame emits the buffered data as a frame, even if it is not full, so
+// the next byte written starts a new frame.
+func (w *Writer) endFrame() error {
+	if w.closed {
+		return errWriterClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	if err := w.writeFrame(w.buf); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Offset returns the decompressed offset at which the next byte written will
+// be stored, which is the total number of bytes written so far.
+func (w *Writer) Offset() uint64 {
+	return w.written + uint64(len(w.buf))
+}
+
+// writeFrame compresses data as one frame and writes it out. It fails with
+// ErrTooManyFrames, before writing anything, once the format's frame limit is
+// reached.
+func (w *Writer) writeFrame(data []byte) error {
+	if len(w.frames) >= w.maxFrames {
+		w.err = fmt.Errorf("%w: the format allows at most %d frames", ErrTooManyFrames, w.maxFrames)
+		return w.err
+	}
+
+	if bound := compressBound(len(data)); len(w.dst) < bound {
+		w.dst = make([]byte, bound)
+	}
+
+	n, err := w.cctx.compress(w.dst, data)
+	if err != nil {
+		w.err = err
+		return err
+	}
+	if uint64(n) > math.MaxUint32 {
+		w.err = fmt.Errorf("seekable: compressed frame too large (%d bytes)", n)
+		return w.err
+	}
+	frame := w.dst[:n]
+
+	if _, err := w.w.Write(frame); err != nil {
+		w.err = err
+		return err
+	}
+	if w.sigHash != nil {
+		w.sigHash.Write(frame)
+	}
+
+	w.written += uint64(len(data))
+	w.frames = append(w.frames, frameEntry{
+		compSize:   uint64(n),
+		decompSize: uint64(len(data)),
+		// The frame ends with the low 32 bits of its content's XXH64, which
+		// is also what the seek table records.
+		checksum: binary.LittleEndian.Uint32(frame[n-4:]),
+	})
+	w.tuneFrameSize()
+	return nil
+}
+
+// tuneFrameSize picks the size of the next frame for WithAutoFrameSize from
+// the compression ratio of the most recent frames.
+func (w *Writer) tuneFrameSize() {
+	if w.cfg.targetRatio == 0 {
+		return
+	}
+
+	var comp, decomp uint64
+	for _, f := range w.frames[max(len(w.frames)-autoFrameWindow, 0):] {
+		comp += f.compSize
+		decomp += f.decompSize
+	}
+
+	if float64(decomp) < w.cfg.targetRatio*float64(comp) {
+		if w.frameSize > w.cfg.autoMax/2 {
+			w.frameSize = w.cfg.autoMax
+		} else {
+			w.frameSize *= 2
+		}
+	} else {
+		w.frameSize = max(w.frameSize/2, w.cfg.autoMin)
+	}
+	if w.cfg.alignment != 0 {
+		w.frameSize = max(alignFrameSize(w.frameSize, int(w.cfg.alignment)), w.cfg.autoMin)
+	}
+}
+
+// alignFrameSize rounds size down to a multiple of alignment, but to no less
+// than alignment itself.
+func alignFrameSize(size, alignment int) int {
+	return max(size/alignment*alignment, alignment)
+}
+
+// Close writes any buffered data, the metadata frames and the seek table, the
+// latter two to the WithSidecarSeekTable writer if one is set. It does not
+// close the underlying writers. Calling Close again has no effect.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	defer w.cctx.free()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	if len(w.buf) > 0 {
+		if err := w.writeFrame(w.buf); err != nil {
+			return err
+		}
+		w.buf = nil
+	}
+
+	var tail []byte
+	if w.hash != nil {
+		tail = appendMetadataFrame(tail, metadataContentHash, w.hash.Sum(nil))
+	}
+	if len(w.entries) > 0 {
+		index := appendIndex(nil, w.entries)
+		if uint64(len(index)) > math.MaxUint32-metadataTagSize {
+			w.err = fmt.Errorf("seekable: entry index too large (%d bytes)", len(index))
+			return w.err
+		}
+		tail = appendMetadataFrame(tail, metadataIndex, index)
+	}
+	table := appendSeekTable(nil, w.frames)
+	if w.sigHash != nil {
+		w.sigHash.Write(tail)
+		tail = appendSignature(tail, w.sigHash, w.cfg.signer, table)
+	}
+	tail = append(tail, table...)
+
+	out := w.w
+	if w.cfg.sidecar != nil {
+		out = w.cfg.sidecar
+	}
+	if _, err := out.Write(tail); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+// abort closes the Writer without writing the buffered data, the metadata
+// frames or the seek table, leaving the output incomplete.
+func (w *Writer) abort() {
+	if w.closed {
+		return
+	}
+	w.closed = true
+	w.cctx.free()
+}
+
+// appendSeekTable appends a seek table with checksums for frames to dst.
+func appendSeekTable(dst []byte, frames []frameEntry) []byte {
+	const entrySize = 12
+
+	dst = binary.LittleEndian.AppendUint32(dst, skippableMagicSeekTable)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(frames)*entrySize+seekTableFooterSize))
+	for _, f := range frames {
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(f.compSize))
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(f.decompSize))
+		dst = binary.LittleEndian.AppendUint32(dst, f.checksum)
+	}
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(frames)))
+	dst = append(dst, seekTableChecksumFlag)
+	return binary.LittleEndian.AppendUint32(dst, seekableMagic)
+}
+
+// Ensure Writer implements io.WriteCloser and io.ReaderFrom
+var (
+	_ io.WriteCloser = (*Writer)(nil)
+	_ io.ReaderFrom  = (*Writer)(nil)
+)
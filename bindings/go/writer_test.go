@@ -0,0 +1,445 @@
+package seekable
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// writeTestArchive compresses content with a Writer and returns the archive.
+func writeTestArchive(t testing.TB, content []byte, opts ...WriterOption) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, opts...)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	content := []byte(strings.Repeat("seekable zstd writer ", 1000))
+	data := writeTestArchive(t, content, WithFrameSize(4096))
+
+	r := openArchivePath(t, writeArchive(t, data))
+	if r.Size() != uint64(len(content)) {
+		t.Errorf("Expected size %d, got %d", len(content), r.Size())
+	}
+	if want := uint64(len(content)+4095) / 4096; r.FrameCount() != want {
+		t.Errorf("Expected %d frames, got %d", want, r.FrameCount())
+	}
+
+	info, err := r.Stat()
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if !info.HasChecksums {
+		t.Error("Expected seek table checksums")
+	}
+
+	got, err := r.DecompressAll()
+	if err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Error("Decompressed content does not match")
+	}
+
+	got, err = r.ReadRange(4000, 9000)
+	if err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if !bytes.Equal(got, content[4000:9000]) {
+		t.Error("ReadRange content does not match")
+	}
+}
+
+func TestWriterSmallWrites(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithFrameSize(10))
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	for _, chunk := range testChunks {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Second Close failed: %v", err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("Expected Write after Close to fail")
+	}
+
+	r, err := OpenReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	if r.FrameCount() != 3 {
+		t.Errorf("Expected 3 frames, got %d", r.FrameCount())
+	}
+	got, err := r.DecompressAll()
+	if err != nil {
+		t.Fatalf("DecompressAll failed: %v", err)
+	}
+	if string(got) != testContent {
+		t.Errorf("Expected '%s', got '%s'", testContent, string(got))
+	}
+}
+
+func TestWriterReadFrom(t *testing.T) {
+	content := []byte(strings.Repeat("read from a source ", 300))
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithFrameSize(1000), WithContentHash())
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+
+	// A partial frame from Write is completed by ReadFrom.
+	if _, err := w.Write(content[:10]); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	n, err := w.ReadFrom(iotest.HalfReader(bytes.NewReader(content[10:])))
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	if n != int64(len(content)-10) {
+		t.Errorf("ReadFrom returned %d, want %d", n, len(content)-10)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := OpenReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	if want := uint64(len(content)+999) / 1000; r.FrameCount() != want {
+		t.Errorf("Expected %d frames, got %d", want, r.FrameCount())
+	}
+	if r.MaxFrameDecompressedSize() != 1000 {
+		t.Errorf("Expected frames of at most 1000 bytes, got %d", r.MaxFrameDecompressedSize())
+	}
+	if err := r.VerifyContent(); err != nil {
+		t.Errorf("VerifyContent failed: %v", err)
+	}
+}
+
+func TestWriterReadFromError(t *testing.T) {
+	w, err := NewWriter(io.Discard)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	src := io.MultiReader(strings.NewReader("abc"), iotest.ErrReader(errors.New("source failed")))
+	n, err := io.Copy(w, src)
+	if err == nil || err.Error() != "source failed" {
+		t.Errorf("io.Copy error = %v, want source failed", err)
+	}
+	if n != 3 {
+		t.Errorf("io.Copy copied %d bytes, want 3", n)
+	}
+}
+
+func TestOpenNotFinalized(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "partial.szst")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer f.Close()
+
+	w, err := NewWriter(f, WithFrameSize(100))
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+
+	// Nothing written yet, then whole frames flushed ahead of the seek table.
+	for _, content := range []string{"", strings.Repeat("x", 250)} {
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if _, err := Open(path); !errors.Is(err, ErrNotFinalized) {
+			t.Errorf("Open() = %v, want ErrNotFinalized", err)
+		}
+		data, _ := os.ReadFile(path)
+		if _, err := OpenBytes(data); !errors.Is(err, ErrNotFinalized) {
+			t.Errorf("OpenBytes() = %v, want ErrNotFinalized", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	r := openArchivePath(t, path)
+	if r.Size() != 250 {
+		t.Errorf("Expected size 250, got %d", r.Size())
+	}
+
+	if _, err := Open(writeArchive(t, []byte("definitely not an archive"))); errors.Is(err, ErrNotFinalized) {
+		t.Error("Unrelated data reported as not finalized")
+	}
+}
+
+func TestWriterTooManyFrames(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithFrameSize(10))
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	// Stand in for the format limit so the test need not write 2^27 frames.
+	w.maxFrames = 3
+
+	n, err := w.Write([]byte(strings.Repeat("x", 45)))
+	if !errors.Is(err, ErrTooManyFrames) {
+		t.Fatalf("Write() = %v, want ErrTooManyFrames", err)
+	}
+	if n != 30 {
+		t.Errorf("Expected 30 bytes accepted, got %d", n)
+	}
+	if err := w.Close(); !errors.Is(err, ErrTooManyFrames) {
+		t.Errorf("Close() = %v, want ErrTooManyFrames", err)
+	}
+	if _, err := OpenBytes(buf.Bytes()); err == nil {
+		t.Error("Expected the incomplete archive to fail to open")
+	}
+}
+
+func TestWriterAtFrameLimit(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, WithFrameSize(10))
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	w.maxFrames = 3
+
+	if _, err := w.Write([]byte(strings.Repeat("x", 30))); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	if r.FrameCount() != 3 {
+		t.Errorf("Expected 3 frames, got %d", r.FrameCount())
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	data := writeTestArchive(t, nil)
+	r := openArchivePath(t, writeArchive(t, data))
+	if r.Size() != 0 || r.FrameCount() != 0 {
+		t.Errorf("Expected empty archive, got size %d with %d frames", r.Size(), r.FrameCount())
+	}
+}
+
+func TestWriterInvalidFrameSize(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, WithFrameSize(0)); err == nil {
+		t.Error("Expected error for zero frame size")
+	}
+}
+
+// frameSizes returns the decompressed size of each frame of archive.
+func frameSizes(t *testing.T, archive []byte) []uint64 {
+	t.Helper()
+	r, err := OpenBytes(archive)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	sizes := make([]uint64, len(r.view.Load().table.frames))
+	for i, f := range r.view.Load().table.frames {
+		sizes[i] = f.decompSize
+	}
+	return sizes
+}
+
+func TestWithAutoFrameSize(t *testing.T) {
+	compressible := []byte(strings.Repeat("auto frame size ", 20000))
+	random := make([]byte, 200000)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	opts := []WriterOption{WithFrameSize(8192), WithAutoFrameSize(1024, 65536, 2)}
+
+	// Compressible data meets the target ratio, so frames shrink to the
+	// minimum for finer-grained access.
+	sizes := frameSizes(t, writeTestArchive(t, compressible, opts...))
+	if !slices.Equal(sizes[:5], []uint64{8192, 4096, 2048, 1024, 1024}) {
+		t.Errorf("compressible frame sizes start %v", sizes[:5])
+	}
+
+	// Random data never meets it, so frames grow to the maximum.
+	sizes = frameSizes(t, writeTestArchive(t, random, opts...))
+	if !slices.Equal(sizes[:5], []uint64{8192, 16384, 32768, 65536, 65536}) {
+		t.Errorf("random frame sizes start %v", sizes[:5])
+	}
+
+	// The layout depends only on the data, however it is written.
+	content := append(append([]byte(nil), random[:50000]...), compressible...)
+	whole := writeTestArchive(t, content, opts...)
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, opts...)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if _, err := w.ReadFrom(iotest.OneByteReader(bytes.NewReader(content))); err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), whole) {
+		t.Error("archives written with Write and ReadFrom differ")
+	}
+
+	r := openArchivePath(t, writeArchive(t, whole))
+	got, err := r.DecompressAll()
+	if err != nil || !bytes.Equal(got, content) {
+		t.Errorf("DecompressAll = (%d bytes, %v)", len(got), err)
+	}
+}
+
+func TestWithAutoFrameSizeInvalid(t *testing.T) {
+	for _, opt := range []WriterOption{
+		WithAutoFrameSize(0, 1024, 2),
+		WithAutoFrameSize(2048, 1024, 2),
+		WithAutoFrameSize(1024, 2048, -1),
+	} {
+		if _, err := NewWriter(&bytes.Buffer{}, opt); err == nil {
+			t.Error("NewWriter accepted invalid auto frame size options")
+		}
+	}
+}
+
+func TestWithFrameAlignment(t *testing.T) {
+	content := []byte(strings.Repeat("aligned frames ", 3000))
+	random := make([]byte, 100000)
+	rand.New(rand.NewSource(2)).Read(random)
+
+	tests := []struct {
+		name     string
+		content  []byte
+		opts     []WriterOption
+		wantSize uint64
+	}{
+		{"rounded down", content, []WriterOption{WithFrameSize(2500), WithFrameAlignment(1000)}, 2000},
+		{"raised", content, []WriterOption{WithFrameSize(100), WithFrameAlignment(1000)}, 1000},
+		{"auto", random, []WriterOption{WithFrameSize(1), WithAutoFrameSize(1500, 9000, 2), WithFrameAlignment(1000)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := writeTestArchive(t, tt.content, tt.opts...)
+			sizes := frameSizes(t, archive)
+			var off uint64
+			for i, size := range sizes[:len(sizes)-1] {
+				if tt.wantSize != 0 && size != tt.wantSize {
+					t.Errorf("frame %d has %d bytes, want %d", i, size, tt.wantSize)
+				}
+				// The auto bounds round to [2000, 9000].
+				if tt.wantSize == 0 && (size%1000 != 0 || size < 2000 || size > 9000) {
+					t.Errorf("frame %d has %d bytes", i, size)
+				}
+				off += size
+			}
+			if off%1000 != 0 {
+				t.Errorf("last frame starts at %d", off)
+			}
+
+			r := openArchivePath(t, writeArchive(t, archive))
+			got, err := r.DecompressAll()
+			if err != nil || !bytes.Equal(got, tt.content) {
+				t.Errorf("DecompressAll = (%d bytes, %v)", len(got), err)
+			}
+		})
+	}
+
+	if _, err := NewWriter(&bytes.Buffer{}, WithFrameAlignment(1<<32)); err == nil {
+		t.Error("NewWriter accepted an alignment above the frame size limit")
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	content := []byte(strings.Repeat("hash me ", 500))
+	data := writeTestArchive(t, content, WithFrameSize(1000), WithContentHash())
+	want := sha256.Sum256(content)
+
+	fromFile := openArchivePath(t, writeArchive(t, data))
+	fromReader, err := OpenReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer fromReader.Close()
+
+	for name, r := range map[string]*Reader{"file": fromFile, "reader": fromReader} {
+		t.Run(name, func(t *testing.T) {
+			got, err := r.ContentHash()
+			if err != nil {
+				t.Fatalf("ContentHash failed: %v", err)
+			}
+			if !bytes.Equal(got, want[:]) {
+				t.Errorf("ContentHash = %x, want %x", got, want)
+			}
+			if err := r.VerifyContent(); err != nil {
+				t.Errorf("VerifyContent failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestContentHashMismatch(t *testing.T) {
+	content := []byte("the original content")
+	data := writeTestArchive(t, content, WithContentHash())
+
+	// Corrupt the stored hash rather than the frames, which would fail to
+	// decode.
+	sum := sha256.Sum256(content)
+	i := bytes.Index(data, sum[:])
+	if i < 0 {
+		t.Fatal("Stored content hash not found")
+	}
+	data[i] ^= 0xff
+
+	r := openArchivePath(t, writeArchive(t, data))
+	if err := r.VerifyContent(); !errors.Is(err, ErrContentMismatch) {
+		t.Errorf("VerifyContent() = %v, want ErrContentMismatch", err)
+	}
+}
+
+func TestContentHashMissing(t *testing.T) {
+	r := openArchive(t, testChunks)
+	if _, err := r.ContentHash(); !errors.Is(err, ErrNoContentHash) {
+		t.Errorf("ContentHash() = %v, want ErrNoContentHash", err)
+	}
+	if err := r.VerifyContent(); !errors.Is(err, ErrNoContentHash) {
+		t.Errorf("VerifyContent() = %v, want ErrNoContentHash", err)
+	}
+}
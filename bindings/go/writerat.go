@@ -0,0 +1,242 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// WriterAt builds a seekable archive in an io.WriterAt from frames added in
+// any order, so they can be compressed on several goroutines. Frame i always
+// holds the data passed to AddAt(i, ...), so the archive depends only on the
+// frames, not on the order in which they are added.
+//
+// A frame's offset is the sum of the compressed sizes before it, so a frame is
+// written once every earlier frame has been added; until then it is held in
+// memory in compressed form. Memory use is therefore bounded by how far ahead
+// of the earliest missing frame the added frames run.
+type WriterAt struct {
+	w       io.WriterAt
+	level   int
+	sidecar io.Writer
+
+	mu sync.Mutex
+	// cctxs holds the compression contexts not in use by an AddAt call.
+	cctxs []*cctx
+
+	// frames describes the frames written so far, which are frames
+	// [0, len(frames)); off is the offset just past them.
+	frames []frameEntry
+	off    int64
+
+	// pending holds the compressed frames added ahead of the first missing
+	// one, with their seek table entries, keyed by index.
+	pending map[uint64]pendingFrame
+
+	// maxFrames caps the frame index; it is only lowered in tests.
+	maxFrames int
+
+	err    error
+	closed bool
+}
+
+// pendingFrame is a compressed frame waiting for its offset to be known.
+type pendingFrame struct {
+	data  []byte
+	entry frameEntry
+}
+
+// NewWriterAt returns a WriterAt that writes a seekable archive to w,
+// starting at offset 0. Close must be called to complete the archive.
+//
+// Of the Writer options only WithCompressionLevel and WithSidecarSeekTable
+// apply: frame sizes are up to the caller, and WithAutoFrameSize,
+// WithFrameAlignment, WithContentHash and WithSignature, which depend on
+// seeing the data in order, are rejected.
+func NewWriterAt(w io.WriterAt, opts ...WriterOption) (*WriterAt, error) {
+	cfg := newWriterConfig(opts)
+	switch {
+	case cfg.targetRatio != 0:
+		return nil, errors.New("seekable: NewWriterAt does not support WithAutoFrameSize")
+	case cfg.alignment != 0:
+		return nil, errors.New("seekable: NewWriterAt does not support WithFrameAlignment")
+	case cfg.contentHash:
+		return nil, errors.New("seekable: NewWriterAt does not support WithContentHash")
+	case cfg.signer != nil:
+		return nil, errors.New("seekable: NewWriterAt does not support WithSignature")
+	}
+
+	// Check the level now rather than in the first AddAt.
+	c, err := newCCtx(cfg.level)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WriterAt{
+		w:         w,
+		level:     cfg.level,
+		sidecar:   cfg.sidecar,
+		cctxs:     []*cctx{c},
+		pending:   make(map[uint64]pendingFrame),
+		maxFrames: maxFrames,
+	}, nil
+}
+
+// AddAt compresses data as frame index of the archive and writes it, and any
+// frames after it that were waiting for it, as soon as every earlier frame
+// has been added. It is safe to call from several goroutines at once; each
+// call compresses on its own goroutine. Adding an index twice is an error.
+//
+// A failure to write leaves the WriterAt unusable.
+func (w *WriterAt) AddAt(index uint64, data []byte) error {
+	if uint64(len(data)) > math.MaxUint32 {
+		return fmt.Errorf("seekable: frame %d too large (%d bytes)", index, len(data))
+	}
+
+	c, err := w.getCCtx(index)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, compressBound(len(data)))
+	n, err := c.compress(frame, data)
+	w.putCCtx(c)
+	if err != nil {
+		return err
+	}
+	if uint64(n) > math.MaxUint32 {
+		return fmt.Errorf("seekable: compressed frame too large (%d bytes)", n)
+	}
+	frame = frame[:n]
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.usable(); err != nil {
+		return err
+	}
+	if _, ok := w.pending[index]; ok || index < uint64(len(w.frames)) {
+		return fmt.Errorf("seekable: frame %d added twice", index)
+	}
+	w.pending[index] = pendingFrame{
+		data: frame,
+		entry: frameEntry{
+			compSize:   uint64(n),
+			decompSize: uint64(len(data)),
+			checksum:   binary.LittleEndian.Uint32(frame[n-4:]),
+		},
+	}
+	return w.flush()
+}
+
+// getCCtx checks that frame index may be added and returns an idle
+// compression context, creating one if none is idle.
+func (w *WriterAt) getCCtx(index uint64) (*cctx, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.usable(); err != nil {
+		return nil, err
+	}
+	if index >= uint64(w.maxFrames) {
+		return nil, fmt.Errorf("%w: frame index %d, the format allows at most %d frames", ErrTooManyFrames, index, w.maxFrames)
+	}
+
+	if n := len(w.cctxs); n > 0 {
+		c := w.cctxs[n-1]
+		w.cctxs = w.cctxs[:n-1]
+		return c, nil
+	}
+	return newCCtx(w.level)
+}
+
+// putCCtx returns c to the idle contexts, or frees it if the WriterAt has
+// been closed meanwhile.
+func (w *WriterAt) putCCtx(c *cctx) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		c.free()
+		return
+	}
+	w.cctxs = append(w.cctxs, c)
+}
+
+// usable returns the error that prevents adding frames, if any.
+func (w *WriterAt) usable() error {
+	if w.closed {
+		return errWriterClosed
+	}
+	return w.err
+}
+
+// flush writes the pending frames that directly follow the written ones.
+func (w *WriterAt) flush() error {
+	for {
+		next := uint64(len(w.frames))
+		p, ok := w.pending[next]
+		if !ok {
+			return nil
+		}
+		if err := writeFullAt(w.w, p.data, w.off); err != nil {
+			w.err = err
+			return err
+		}
+		delete(w.pending, next)
+
+		p.entry.compOffset = uint64(w.off)
+		w.frames = append(w.frames, p.entry)
+		w.off += int64(len(p.data))
+	}
+}
+
+// Close writes the seek table after the last frame, or to the
+// WithSidecarSeekTable writer. Every index below the highest one added must
+// have been added; otherwise Close fails and nothing more is written.
+// Calling Close again has no effect.
+func (w *WriterAt) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	for _, c := range w.cctxs {
+		c.free()
+	}
+	w.cctxs = nil
+
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.pending) > 0 {
+		w.err = fmt.Errorf("seekable: frame %d was never added", len(w.frames))
+		return w.err
+	}
+
+	table := appendSeekTable(nil, w.frames)
+	if w.sidecar != nil {
+		if _, err := w.sidecar.Write(table); err != nil {
+			w.err = err
+			return err
+		}
+		return nil
+	}
+	if err := writeFullAt(w.w, table, w.off); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+// writeFullAt writes p to w at off, treating a short write as an error.
+func writeFullAt(w io.WriterAt, p []byte, off int64) error {
+	n, err := w.WriteAt(p, off)
+	if err == nil && n != len(p) {
+		err = io.ErrShortWrite
+	}
+	return err
+}
+
+// Ensure WriterAt implements io.Closer
+var _ io.Closer = (*WriterAt)(nil)
@@ -0,0 +1,180 @@
+package seekable
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// writerAtFile returns a new file for a WriterAt to write to.
+func writerAtFile(t testing.TB) *os.File {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "archive.szst"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestWriterAtOutOfOrder(t *testing.T) {
+	var chunks [][]byte
+	var content []byte
+	for i := 0; i < 16; i++ {
+		chunk := bytes.Repeat([]byte(fmt.Sprintf("frame %d ", i)), 100+i*37)
+		chunks = append(chunks, chunk)
+		content = append(content, chunk...)
+	}
+
+	build := func(order []int) []byte {
+		t.Helper()
+		f := writerAtFile(t)
+		w, err := NewWriterAt(f)
+		if err != nil {
+			t.Fatalf("NewWriterAt failed: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, len(order))
+		for k, i := range order {
+			wg.Add(1)
+			go func(k, i int) {
+				defer wg.Done()
+				errs[k] = w.AddAt(uint64(i), chunks[i])
+			}(k, i)
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			t.Fatalf("AddAt failed: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		return data
+	}
+
+	inOrder := make([]int, len(chunks))
+	for i := range inOrder {
+		inOrder[i] = i
+	}
+	want := build(inOrder)
+
+	// The archive matches one built with a Writer that ends a frame after
+	// each chunk.
+	var buf bytes.Buffer
+	sw, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	for _, chunk := range chunks {
+		sw.Write(chunk)
+		sw.endFrame()
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatalf("Writer Close failed: %v", err)
+	}
+	if !bytes.Equal(want, buf.Bytes()) {
+		t.Error("WriterAt archive differs from the Writer archive")
+	}
+
+	rng := rand.New(rand.NewSource(180))
+	for round := 0; round < 5; round++ {
+		order := slices.Clone(inOrder)
+		rng.Shuffle(len(order), func(a, b int) { order[a], order[b] = order[b], order[a] })
+		if got := build(order); !bytes.Equal(got, want) {
+			t.Fatalf("archive built in order %v differs", order)
+		}
+	}
+
+	r, err := OpenBytes(want)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+	if r.FrameCount() != uint64(len(chunks)) {
+		t.Errorf("FrameCount() = %d, want %d", r.FrameCount(), len(chunks))
+	}
+	if all, err := r.DecompressAll(); err != nil || !bytes.Equal(all, content) {
+		t.Errorf("DecompressAll = (%d bytes, %v)", len(all), err)
+	}
+	if err := r.DeepValidate(); err != nil {
+		t.Errorf("DeepValidate failed: %v", err)
+	}
+}
+
+func TestWriterAtMissingFrame(t *testing.T) {
+	w, err := NewWriterAt(writerAtFile(t))
+	if err != nil {
+		t.Fatalf("NewWriterAt failed: %v", err)
+	}
+	if err := w.AddAt(0, []byte("alpha")); err != nil {
+		t.Fatalf("AddAt(0) failed: %v", err)
+	}
+	if err := w.AddAt(2, []byte("charlie")); err != nil {
+		t.Fatalf("AddAt(2) failed: %v", err)
+	}
+	if err := w.AddAt(2, []byte("charlie")); err == nil {
+		t.Error("Expected error adding frame 2 twice")
+	}
+	if err := w.AddAt(0, []byte("alpha")); err == nil {
+		t.Error("Expected error adding written frame 0 twice")
+	}
+	if err := w.Close(); err == nil {
+		t.Error("Expected Close to fail with frame 1 missing")
+	}
+	if err := w.AddAt(1, []byte("bravo")); !errors.Is(err, errWriterClosed) {
+		t.Errorf("AddAt after Close = %v, want errWriterClosed", err)
+	}
+}
+
+func TestWriterAtTooManyFrames(t *testing.T) {
+	w, err := NewWriterAt(writerAtFile(t))
+	if err != nil {
+		t.Fatalf("NewWriterAt failed: %v", err)
+	}
+	defer w.Close()
+	w.maxFrames = 2
+	if err := w.AddAt(2, []byte("x")); !errors.Is(err, ErrTooManyFrames) {
+		t.Errorf("AddAt(2) = %v, want ErrTooManyFrames", err)
+	}
+}
+
+func TestWriterAtEmpty(t *testing.T) {
+	f := writerAtFile(t)
+	w, err := NewWriterAt(f)
+	if err != nil {
+		t.Fatalf("NewWriterAt failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	r := openArchivePath(t, f.Name())
+	if r.Size() != 0 || r.FrameCount() != 0 {
+		t.Errorf("got %d frames, %d bytes", r.FrameCount(), r.Size())
+	}
+}
+
+func TestWriterAtRejectsOptions(t *testing.T) {
+	for name, opt := range map[string]WriterOption{
+		"auto frame size": WithAutoFrameSize(1024, 4096, 4),
+		"alignment":       WithFrameAlignment(4096),
+		"content hash":    WithContentHash(),
+		"signature":       WithSignature(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))),
+	} {
+		if _, err := NewWriterAt(writerAtFile(t), opt); err == nil {
+			t.Errorf("NewWriterAt accepted %s", name)
+		}
+	}
+}
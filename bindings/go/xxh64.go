@@ -0,0 +1,85 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// XXH64 primes. The seekable format's frame checksums are the low 32 bits of
+// the XXH64 (seed 0) of each frame's decompressed content, the same value zstd
+// stores as a frame's content checksum. They are variables so the seed
+// arithmetic below wraps instead of overflowing at compile time.
+var (
+	xxhPrime1 uint64 = 11400714785074694791
+	xxhPrime2 uint64 = 14029467366897019727
+	xxhPrime3 uint64 = 1609587929392839161
+	xxhPrime4 uint64 = 9650029242287828579
+	xxhPrime5 uint64 = 2870177450012600261
+)
+
+// xxh64 returns the XXH64 hash of b with seed 0.
+func xxh64(b []byte) uint64 {
+	n := len(b)
+	var h uint64
+
+	if n >= 32 {
+		v1 := xxhPrime1 + xxhPrime2
+		v2 := xxhPrime2
+		v3 := uint64(0)
+		v4 := -xxhPrime1
+		for ; len(b) >= 32; b = b[32:] {
+			v1 = xxhRound(v1, binary.LittleEndian.Uint64(b[0:]))
+			v2 = xxhRound(v2, binary.LittleEndian.Uint64(b[8:]))
+			v3 = xxhRound(v3, binary.LittleEndian.Uint64(b[16:]))
+			v4 = xxhRound(v4, binary.LittleEndian.Uint64(b[24:]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxhMergeRound(h, v1)
+		h = xxhMergeRound(h, v2)
+		h = xxhMergeRound(h, v3)
+		h = xxhMergeRound(h, v4)
+	} else {
+		h = xxhPrime5
+	}
+
+	h += uint64(n)
+
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxhRound(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*xxhPrime1 + xxhPrime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * xxhPrime1
+		h = bits.RotateLeft64(h, 23)*xxhPrime2 + xxhPrime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * xxhPrime5
+		h = bits.RotateLeft64(h, 11) * xxhPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxhPrime2
+	h ^= h >> 29
+	h *= xxhPrime3
+	h ^= h >> 32
+	return h
+}
+
+func xxhRound(acc, input uint64) uint64 {
+	acc += input * xxhPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxhPrime1
+}
+
+func xxhMergeRound(acc, val uint64) uint64 {
+	val = xxhRound(0, val)
+	acc ^= val
+	return acc*xxhPrime1 + xxhPrime4
+}
+
+// frameChecksum returns the seekable format checksum of decompressed frame
+// content.
+func frameChecksum(b []byte) uint32 {
+	return uint32(xxh64(b))
+}
@@ -0,0 +1,21 @@
+package seekable
+
+import "testing"
+
+func TestXXH64(t *testing.T) {
+	long := make([]byte, 1000)
+	for i := range long {
+		long[i] = byte((i*7 + 3) % 251)
+	}
+
+	if got := xxh64(nil); got != 0xef46db3751d8e999 {
+		t.Errorf("xxh64(\"\") = %#x", got)
+	}
+	if got := xxh64([]byte("abc")); got != 0x44bc2cf5ad770999 {
+		t.Errorf("xxh64(\"abc\") = %#x", got)
+	}
+	// Content checksum zstd --check stores for the same input.
+	if got := frameChecksum(long); got != 0x24085ea4 {
+		t.Errorf("frameChecksum(long) = %#x", got)
+	}
+}
@@ -0,0 +1,427 @@
+package seekable
+
+/*
+#include <stddef.h>
+#include "include/seekable_zstd.h"
+
+// The prebuilt core library statically links libzstd. Only the handful of
+// symbols needed to encode and decode individual frames are declared here;
+// decode parameters go through seekable_dctx_set_parameter instead.
+typedef struct ZSTD_DCtx_s ZSTD_DCtx;
+typedef struct ZSTD_CCtx_s ZSTD_CCtx;
+typedef struct ZSTD_DDict_s ZSTD_DDict;
+
+typedef struct {
+	const void *src;
+	size_t size;
+	size_t pos;
+} ZSTD_inBuffer;
+
+typedef struct {
+	void *dst;
+	size_t size;
+	size_t pos;
+} ZSTD_outBuffer;
+
+ZSTD_DCtx *ZSTD_createDCtx(void);
+size_t ZSTD_freeDCtx(ZSTD_DCtx *dctx);
+size_t ZSTD_DCtx_reset(ZSTD_DCtx *dctx, int reset);
+size_t ZSTD_decompressStream(ZSTD_DCtx *dctx, ZSTD_outBuffer *output, ZSTD_inBuffer *input);
+size_t ZSTD_estimateDStreamSize(size_t window_size);
+
+ZSTD_DDict *ZSTD_createDDict(const void *dict, size_t dict_size);
+size_t ZSTD_freeDDict(ZSTD_DDict *ddict);
+size_t ZSTD_DCtx_refDDict(ZSTD_DCtx *dctx, const ZSTD_DDict *ddict);
+unsigned ZSTD_getDictID_fromDDict(const ZSTD_DDict *ddict);
+
+ZSTD_CCtx *ZSTD_createCCtx(void);
+size_t ZSTD_freeCCtx(ZSTD_CCtx *cctx);
+size_t ZSTD_CCtx_setParameter(ZSTD_CCtx *cctx, int param, int value);
+size_t ZSTD_compress2(ZSTD_CCtx *cctx, void *dst, size_t dst_cap, const void *src, size_t src_size);
+size_t ZSTD_compressBound(size_t src_size);
+
+unsigned ZSTD_isError(size_t code);
+const char *ZSTD_getErrorName(size_t code);
+int ZSTD_getErrorCode(size_t code);
+
+#define SZST_RESET_SESSION_ONLY 1
+
+#define SZST_C_COMPRESSION_LEVEL 100
+#define SZST_C_CHECKSUM_FLAG 201
+
+// ZSTD_error_frameParameter_windowTooLarge
+#define SZST_ERROR_WINDOW_TOO_LARGE 16
+
+#define SZST_OK 0
+#define SZST_ZSTD_ERROR 1
+#define SZST_TRUNCATED 2
+#define SZST_OVERFLOW 3
+
+// szst_decompress_frame decodes the single frame in src into dst. The buffer
+// descriptors live on the C stack so Go memory is only passed as plain
+// pointers. The streaming API is used so every decode parameter applies.
+static int szst_decompress_frame(ZSTD_DCtx *dctx, void *dst, size_t dst_cap,
+                                 const void *src, size_t src_size,
+                                 size_t *produced, size_t *code) {
+	ZSTD_inBuffer in = {src, src_size, 0};
+	ZSTD_outBuffer out = {dst, dst_cap, 0};
+
+	*code = ZSTD_DCtx_reset(dctx, SZST_RESET_SESSION_ONLY);
+	if (ZSTD_isError(*code)) {
+		return SZST_ZSTD_ERROR;
+	}
+
+	for (;;) {
+		size_t in_pos = in.pos, out_pos = out.pos;
+
+		*code = ZSTD_decompressStream(dctx, &out, &in);
+		*produced = out.pos;
+		if (ZSTD_isError(*code)) {
+			return SZST_ZSTD_ERROR;
+		}
+		if (*code == 0) {
+			return SZST_OK;
+		}
+		if (in.pos == in_pos && out.pos == out_pos) {
+			return in.pos == in.size ? SZST_TRUNCATED : SZST_OVERFLOW;
+		}
+	}
+}
+
+// szst_decompress_step makes one streaming decode call over src[*src_pos:]
+// into dst[*dst_pos:] and advances both positions. Unlike
+// szst_decompress_frame it keeps the session, so a stream of concatenated
+// frames can be fed in pieces.
+static size_t szst_decompress_step(ZSTD_DCtx *dctx, void *dst, size_t dst_cap,
+                                   size_t *dst_pos, const void *src,
+                                   size_t src_size, size_t *src_pos) {
+	ZSTD_inBuffer in = {src, src_size, *src_pos};
+	ZSTD_outBuffer out = {dst, dst_cap, *dst_pos};
+
+	size_t code = ZSTD_decompressStream(dctx, &out, &in);
+	*src_pos = in.pos;
+	*dst_pos = out.pos;
+	return code;
+}
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// errFrameOverflow is returned by decompress when the frame decodes to more
+// bytes than dst holds.
+var errFrameOverflow = errors.New("zstd: frame exceeds the output buffer")
+
+// dctx is a zstd decompression context used to decode single frames in Go.
+type dctx struct {
+	ptr *C.ZSTD_DCtx
+
+	// dicts are the dictionaries frames may use, and dict the one the
+	// context currently references.
+	dicts *dictSet
+	dict  *ddict
+
+	// magicless makes decodeExact decode frames without the frame magic.
+	magicless bool
+}
+
+func newDCtx() (*dctx, error) {
+	ptr := C.ZSTD_createDCtx()
+	if ptr == nil {
+		return nil, errors.New("zstd: failed to allocate decompression context")
+	}
+	return &dctx{ptr: ptr}, nil
+}
+
+// setParameter sets an advanced decompression parameter (ZSTD_dParameter).
+func (d *dctx) setParameter(param, value int) error {
+	if C.seekable_dctx_set_parameter(unsafe.Pointer(d.ptr), C.int(param), C.int(value)) < 0 {
+		msg := "unknown error"
+		if errStr := C.seekable_last_error(); errStr != nil {
+			msg = C.GoString(errStr)
+		}
+		return fmt.Errorf("zstd: decode parameter %d = %d: %s", param, value, msg)
+	}
+	return nil
+}
+
+// decompress decodes the single zstd frame in src into dst and returns the
+// number of bytes written.
+func (d *dctx) decompress(dst, src []byte) (int, error) {
+	if len(src) == 0 {
+		return 0, errors.New("zstd: empty frame")
+	}
+
+	var dstPtr unsafe.Pointer
+	if len(dst) > 0 {
+		dstPtr = unsafe.Pointer(&dst[0])
+	}
+
+	var produced, code C.size_t
+	status := C.szst_decompress_frame(
+		d.ptr,
+		dstPtr,
+		C.size_t(len(dst)),
+		unsafe.Pointer(&src[0]),
+		C.size_t(len(src)),
+		&produced,
+		&code,
+	)
+
+	switch status {
+	case C.SZST_OK:
+		return int(produced), nil
+	case C.SZST_TRUNCATED:
+		return int(produced), errors.New("zstd: frame is truncated")
+	case C.SZST_OVERFLOW:
+		return int(produced), errFrameOverflow
+	default:
+		return int(produced), zstdError(code)
+	}
+}
+
+// streamDecoder decodes a plain zstd stream of concatenated frames read from
+// src, finding frame boundaries itself rather than from a seek table.
+// Skippable frames are skipped.
+type streamDecoder struct {
+	d   *dctx
+	src io.Reader
+	buf []byte
+	in  []byte // unconsumed part of buf
+	eof bool
+	// inFrame is set while a frame has started but not been fully decoded.
+	inFrame bool
+}
+
+func newStreamDecoder(d *dctx, src io.Reader) (*streamDecoder, error) {
+	res := C.ZSTD_DCtx_reset(d.ptr, C.SZST_RESET_SESSION_ONLY)
+	if C.ZSTD_isError(res) != 0 {
+		return nil, fmt.Errorf("zstd: %s", C.GoString(C.ZSTD_getErrorName(res)))
+	}
+	return &streamDecoder{d: d, src: src, buf: make([]byte, 64<<10)}, nil
+}
+
+// Read implements io.Reader.
+func (s *streamDecoder) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for {
+		if len(s.in) == 0 && !s.eof {
+			n, err := s.src.Read(s.buf)
+			s.in = s.buf[:n]
+			if err == io.EOF {
+				s.eof = true
+			} else if err != nil {
+				return 0, err
+			}
+		}
+
+		var srcPtr unsafe.Pointer
+		if len(s.in) > 0 {
+			srcPtr = unsafe.Pointer(&s.in[0])
+		}
+		var dstPos, srcPos C.size_t
+		code := C.szst_decompress_step(s.d.ptr, unsafe.Pointer(&p[0]), C.size_t(len(p)), &dstPos,
+			srcPtr, C.size_t(len(s.in)), &srcPos)
+		if C.ZSTD_isError(code) != 0 {
+			return int(dstPos), zstdError(code)
+		}
+		if srcPos > 0 || dstPos > 0 {
+			// A call without progress reports the next frame's input
+			// size hint even between frames.
+			s.in = s.in[srcPos:]
+			s.inFrame = code != 0
+		}
+		if dstPos > 0 {
+			return int(dstPos), nil
+		}
+
+		if len(s.in) == 0 && s.eof {
+			if s.inFrame {
+				return 0, errors.New("zstd: stream is truncated")
+			}
+			return 0, io.EOF
+		}
+	}
+}
+
+// zstdError converts a zstd error code into an error, wrapping
+// ErrWindowTooLarge for frames rejected by the window size limit.
+func zstdError(code C.size_t) error {
+	if C.ZSTD_getErrorCode(code) == C.SZST_ERROR_WINDOW_TOO_LARGE {
+		return fmt.Errorf("%w: zstd: %s", ErrWindowTooLarge, C.GoString(C.ZSTD_getErrorName(code)))
+	}
+	return fmt.Errorf("zstd: %s", C.GoString(C.ZSTD_getErrorName(code)))
+}
+
+// useDict makes d decode with dd, or without a dictionary if dd is nil. The
+// choice persists across frames until changed.
+func (d *dctx) useDict(dd *ddict) error {
+	if d.dict == dd {
+		return nil
+	}
+	var ptr *C.ZSTD_DDict
+	if dd != nil {
+		ptr = dd.ptr
+	}
+	res := C.ZSTD_DCtx_refDDict(d.ptr, ptr)
+	if C.ZSTD_isError(res) != 0 {
+		return fmt.Errorf("zstd: %s", C.GoString(C.ZSTD_getErrorName(res)))
+	}
+	d.dict = dd
+	return nil
+}
+
+// ddict is a decompression dictionary digested for reuse across frames.
+type ddict struct {
+	ptr *C.ZSTD_DDict
+}
+
+// newDDict digests dict. zstd copies it, so dict is not retained.
+func newDDict(dict []byte) (*ddict, error) {
+	if len(dict) == 0 {
+		return nil, errors.New("zstd: empty dictionary")
+	}
+	ptr := C.ZSTD_createDDict(unsafe.Pointer(&dict[0]), C.size_t(len(dict)))
+	if ptr == nil {
+		return nil, errors.New("zstd: failed to load dictionary")
+	}
+	return &ddict{ptr: ptr}, nil
+}
+
+// id returns the dictionary ID recorded in the dictionary's header, or 0 for
+// a raw content dictionary.
+func (dd *ddict) id() uint32 {
+	return uint32(C.ZSTD_getDictID_fromDDict(dd.ptr))
+}
+
+func (dd *ddict) free() {
+	if dd.ptr != nil {
+		C.ZSTD_freeDDict(dd.ptr)
+		dd.ptr = nil
+	}
+}
+
+// dctxPool keeps idle decompression contexts so frames decoded in Go reuse
+// them instead of allocating a context per frame. Contexts keep their
+// parameters between frames; decompress only resets the session. At most
+// GOMAXPROCS contexts are kept idle.
+type dctxPool struct {
+	mu     sync.Mutex
+	idle   []*dctx
+	closed bool
+}
+
+// get returns an idle context, or one made by newFn if none is idle.
+func (p *dctxPool) get(newFn func() (*dctx, error)) (*dctx, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		d := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return d, nil
+	}
+	p.mu.Unlock()
+	return newFn()
+}
+
+// put returns d to the pool, or frees it if the pool is full or closed.
+func (p *dctxPool) put(d *dctx) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || len(p.idle) >= runtime.GOMAXPROCS(0) {
+		d.free()
+		return
+	}
+	p.idle = append(p.idle, d)
+}
+
+// close frees the idle contexts. Contexts returned later are freed by put.
+func (p *dctxPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, d := range p.idle {
+		d.free()
+	}
+	p.idle = nil
+	p.closed = true
+}
+
+// estimateDStreamSize returns the memory a streaming decoder needs for frames
+// with the given window size.
+func estimateDStreamSize(windowSize uint64) uint64 {
+	return uint64(C.ZSTD_estimateDStreamSize(C.size_t(windowSize)))
+}
+
+func (d *dctx) free() {
+	if d.ptr != nil {
+		C.ZSTD_freeDCtx(d.ptr)
+		d.ptr = nil
+	}
+}
+
+// cctx is a zstd compression context used to encode single frames.
+type cctx struct {
+	ptr *C.ZSTD_CCtx
+}
+
+// newCCtx returns a compression context that writes frames at the given level.
+// Every frame carries a content checksum, which doubles as the frame's seek
+// table checksum.
+func newCCtx(level int) (*cctx, error) {
+	ptr := C.ZSTD_createCCtx()
+	if ptr == nil {
+		return nil, errors.New("zstd: failed to allocate compression context")
+	}
+	c := &cctx{ptr: ptr}
+
+	for _, p := range [][2]int{
+		{C.SZST_C_COMPRESSION_LEVEL, level},
+		{C.SZST_C_CHECKSUM_FLAG, 1},
+	} {
+		res := C.ZSTD_CCtx_setParameter(ptr, C.int(p[0]), C.int(p[1]))
+		if C.ZSTD_isError(res) != 0 {
+			c.free()
+			return nil, fmt.Errorf("zstd: compression parameter %d = %d: %s", p[0], p[1], C.GoString(C.ZSTD_getErrorName(res)))
+		}
+	}
+	return c, nil
+}
+
+// compressBound returns the largest frame compress may produce for n bytes.
+func compressBound(n int) int {
+	return int(C.ZSTD_compressBound(C.size_t(n)))
+}
+
+// compress encodes src as a single zstd frame into dst and returns the number
+// of bytes written. dst should hold at least compressBound(len(src)) bytes.
+func (c *cctx) compress(dst, src []byte) (int, error) {
+	if len(dst) == 0 {
+		return 0, errors.New("zstd: empty destination buffer")
+	}
+
+	var srcPtr unsafe.Pointer
+	if len(src) > 0 {
+		srcPtr = unsafe.Pointer(&src[0])
+	}
+
+	res := C.ZSTD_compress2(c.ptr, unsafe.Pointer(&dst[0]), C.size_t(len(dst)), srcPtr, C.size_t(len(src)))
+	if C.ZSTD_isError(res) != 0 {
+		return 0, fmt.Errorf("zstd: %s", C.GoString(C.ZSTD_getErrorName(res)))
+	}
+	return int(res), nil
+}
+
+func (c *cctx) free() {
+	if c.ptr != nil {
+		C.ZSTD_freeCCtx(c.ptr)
+		c.ptr = nil
+	}
+}
@@ -0,0 +1,76 @@
+package seekable
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDCtxPoolReuse(t *testing.T) {
+	var p dctxPool
+	d, err := p.get(newDCtx)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	p.put(d)
+
+	again, err := p.get(newDCtx)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if again != d {
+		t.Error("Expected the idle context to be reused")
+	}
+
+	p.close()
+	p.put(again)
+	if again.ptr != nil {
+		t.Error("Expected put on a closed pool to free the context")
+	}
+}
+
+// BenchmarkFrameDecode measures the per-read cost of decoding one small frame
+// in Go, with a fresh decompression context per frame and with pooling.
+func BenchmarkFrameDecode(b *testing.B) {
+	content := []byte(strings.Repeat("benchmark frame ", 256))
+	data := writeTestArchive(b, content, WithFrameSize(len(content)))
+	r, err := OpenBytes(data)
+	if err != nil {
+		b.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer r.Close()
+
+	comp, err := r.readCompressed(context.Background(), 0, nil)
+	if err != nil {
+		b.Fatalf("readCompressed failed: %v", err)
+	}
+	out := make([]byte, len(content))
+
+	b.Run("fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			d, err := r.cfg.newDCtx()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := r.decodeInto(d, 0, comp, out); err != nil {
+				b.Fatal(err)
+			}
+			d.free()
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			d, err := r.getDCtx()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := r.decodeInto(d, 0, comp, out); err != nil {
+				b.Fatal(err)
+			}
+			r.putDCtx(d)
+		}
+	})
+}
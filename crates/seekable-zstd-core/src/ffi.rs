@@ -3,9 +3,10 @@ use crate::parallel::ParallelDecoder;
 use std::cell::RefCell;
 use std::ffi::{CStr, CString};
 use std::fs::File;
-use std::os::raw::c_char;
+use std::os::raw::{c_char, c_int, c_void};
 use std::path::PathBuf;
 use std::ptr;
+use zstd_safe::zstd_sys;
 
 // Thread-local storage for the last error message
 thread_local! {
@@ -252,6 +253,38 @@ pub unsafe extern "C" fn seekable_read_ranges(
     0 // Success
 }
 
+// Declared here rather than taken from zstd-sys, whose `ZSTD_dParameter` enum
+// cannot hold the experimental parameters callers may pass through.
+extern "C" {
+    fn ZSTD_DCtx_setParameter(dctx: *mut c_void, param: c_int, value: c_int) -> usize;
+}
+
+/// Sets an advanced decompression parameter (`ZSTD_dParameter`) on a zstd
+/// decompression context.
+///
+/// # Safety
+/// `dctx` must be a valid `ZSTD_DCtx` pointer.
+#[no_mangle]
+pub unsafe extern "C" fn seekable_dctx_set_parameter(
+    dctx: *mut c_void,
+    param: c_int,
+    value: c_int,
+) -> i32 {
+    if dctx.is_null() {
+        set_error(&"Decompression context pointer is null");
+        return -1;
+    }
+
+    let code = unsafe { ZSTD_DCtx_setParameter(dctx, param, value) };
+    if unsafe { zstd_sys::ZSTD_isError(code) } != 0 {
+        let name = unsafe { CStr::from_ptr(zstd_sys::ZSTD_getErrorName(code)) };
+        set_error(&name.to_string_lossy());
+        return -3;
+    }
+
+    0 // Success
+}
+
 /// Closes the decoder and frees resources.
 ///
 /// # Safety